@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/katuhangugi/tiktok-account-system/internal/config"
+	"github.com/katuhangugi/tiktok-account-system/internal/database/database"
+	"github.com/katuhangugi/tiktok-account-system/internal/repositories"
+	"github.com/katuhangugi/tiktok-account-system/internal/services"
+	"github.com/katuhangugi/tiktok-account-system/pkg/logger"
+)
+
+// backfill-uploads recomputes daily_analytics upload counts from the
+// stored video count history, for snapshots taken before uploads were
+// derived or after the derivation rules changed
+func main() {
+	accountID := flag.Uint("account", 0, "only recompute this account ID (default all accounts)")
+	dryRun := flag.Bool("dry-run", false, "report changes without writing them")
+	flag.Parse()
+
+	log := logger.Default()
+	defer log.Close()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	db, err := database.Init(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}()
+
+	analyticsRepo := repositories.NewAnalyticsRepository(db)
+	tikTokService := services.NewTikTokService(repositories.NewAccountRepository(db), analyticsRepo, nil, log)
+
+	accountIDs := []uint{uint(*accountID)}
+	if *accountID == 0 {
+		if accountIDs, err = analyticsRepo.ListAccountIDs(); err != nil {
+			log.Fatalf("Failed to list accounts: %v", err)
+		}
+	}
+
+	total := 0
+	for _, id := range accountIDs {
+		changed, err := tikTokService.RecomputeUploads(id, *dryRun)
+		if err != nil {
+			log.Fatalf("Failed to recompute uploads of account %d: %v", id, err)
+		}
+		if changed > 0 {
+			log.Infof("Account %d: %d snapshots updated", id, changed)
+		}
+		total += changed
+	}
+
+	if *dryRun {
+		log.Infof("Dry run: %d snapshots across %d accounts would change", total, len(accountIDs))
+	} else {
+		log.Infof("Updated %d snapshots across %d accounts", total, len(accountIDs))
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+
+	"github.com/katuhangugi/tiktok-account-system/pkg/logger"
+	"github.com/katuhangugi/tiktok-account-system/pkg/tiktok/faketiktok"
+)
+
+// faketiktok serves scripted TikTok profile pages so the refresh pipeline
+// can run without network access. Point the server at it with
+// TIKTOK_TIKTOKAPI_ENDPOINT=http://localhost:9090/@%s
+func main() {
+	addr := flag.String("addr", ":9090", "listen address")
+	scenarioPath := flag.String("scenario", "", "JSON scenario file")
+	flag.Parse()
+
+	log := logger.Default()
+	defer log.Close()
+
+	var scenario *faketiktok.Scenario
+	if *scenarioPath != "" {
+		var err error
+		if scenario, err = faketiktok.LoadScenario(*scenarioPath); err != nil {
+			log.Fatalf("Failed to load scenario: %v", err)
+		}
+		log.Infof("Loaded %d scripted accounts from %s", len(scenario.Accounts), *scenarioPath)
+	}
+
+	log.Infof("Fake TikTok listening on %s", *addr)
+	if err := http.ListenAndServe(*addr, faketiktok.NewServer(scenario)); err != nil {
+		log.Fatalf("Server failed: %v", err)
+	}
+}
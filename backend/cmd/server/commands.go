@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/katuhangugi/tiktok-account-system/internal/database/database"
+	"github.com/katuhangugi/tiktok-account-system/internal/database/migrations"
+	"github.com/katuhangugi/tiktok-account-system/internal/database/seeds"
+	"github.com/katuhangugi/tiktok-account-system/pkg/logger"
+	"gorm.io/gorm"
+)
+
+const commandUsage = `usage: server [command]
+
+Without a command the HTTP server is started.
+
+commands:
+  migrate up [n]     apply pending migrations (all, or the next n)
+  migrate down [n]   roll back the latest n migrations (default 1)
+  migrate status     list migrations and whether they are applied
+  seed               load demo users and groups into an empty database`
+
+// runCommand runs the maintenance subcommand named by args
+func runCommand(args []string, db *gorm.DB, log *logger.Logger) {
+	switch args[0] {
+	case "migrate":
+		runMigrate(args[1:], db, log)
+	case "seed":
+		applied, err := database.Seed(db, seeds.FS)
+		if err != nil {
+			log.Fatalf("Failed to seed database: %v", err)
+		}
+		if applied {
+			log.Info("Seed data loaded")
+		} else {
+			log.Info("Database already has users, seed data skipped")
+		}
+	default:
+		log.Fatalf("Unknown command %q\n%s", args[0], commandUsage)
+	}
+}
+
+func runMigrate(args []string, db *gorm.DB, log *logger.Logger) {
+	if len(args) == 0 {
+		log.Fatalf("Missing migrate action\n%s", commandUsage)
+	}
+
+	migrator, err := database.NewMigrator(db, migrations.FS)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	steps := 0
+	if len(args) > 1 {
+		if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+			log.Fatalf("Invalid migration count %q", args[1])
+		}
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(steps)
+		for _, m := range applied {
+			log.Infof("Applied migration %04d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		if len(applied) == 0 {
+			log.Info("Database is up to date")
+		}
+	case "down":
+		if steps == 0 {
+			steps = 1
+		}
+		rolledBack, err := migrator.Down(steps)
+		for _, m := range rolledBack {
+			log.Infof("Rolled back migration %04d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("Rollback failed: %v", err)
+		}
+		if len(rolledBack) == 0 {
+			log.Info("No applied migrations to roll back")
+		}
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		printMigrationStatus(statuses)
+	default:
+		log.Fatalf("Unknown migrate action %q\n%s", args[0], commandUsage)
+	}
+}
+
+func printMigrationStatus(statuses []database.MigrationStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		state, appliedAt := "pending", ""
+		if s.AppliedAt != nil {
+			state, appliedAt = "applied", s.AppliedAt.Format(time.RFC3339)
+		}
+		if s.Modified {
+			state = "modified"
+		}
+		if s.Missing {
+			state = "missing"
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+	}
+	w.Flush()
+}
@@ -10,11 +10,17 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/katuhangugi/tiktok-account-system/internal/config"
-	"github.com/katuhangugi/tiktok-account-system/internal/database"
+	"github.com/katuhangugi/tiktok-account-system/internal/database/database"
+	"github.com/katuhangugi/tiktok-account-system/internal/database/migrations"
 	"github.com/katuhangugi/tiktok-account-system/internal/handlers"
+	"github.com/katuhangugi/tiktok-account-system/internal/jobs"
 	"github.com/katuhangugi/tiktok-account-system/internal/middleware"
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"github.com/katuhangugi/tiktok-account-system/internal/repositories"
+	"github.com/katuhangugi/tiktok-account-system/internal/scheduler"
+	"github.com/katuhangugi/tiktok-account-system/internal/services"
 	"github.com/katuhangugi/tiktok-account-system/pkg/logger"
-	
+	"github.com/katuhangugi/tiktok-account-system/pkg/tiktok"
 )
 
 func main() {
@@ -55,9 +61,25 @@ func main() {
 		}
 	}()
 
-	// Run database migrations
-	if err := database.AutoMigrate(db); err != nil {
-		log.Fatalf("Failed to run database migrations: %v", err)
+	// Run a maintenance command such as "migrate up" instead of the server
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1:], db, log)
+		return
+	}
+
+	// Apply pending database migrations
+	if cfg.Database.AutoMigrate {
+		migrator, err := database.NewMigrator(db, migrations.FS)
+		if err != nil {
+			log.Fatalf("Failed to load database migrations: %v", err)
+		}
+		applied, err := migrator.Up(0)
+		if err != nil {
+			log.Fatalf("Failed to run database migrations: %v", err)
+		}
+		for _, m := range applied {
+			log.Infof("Applied migration %04d_%s", m.Version, m.Name)
+		}
 	}
 
 	// Create Gin router with production mode if not in debug
@@ -75,8 +97,14 @@ func main() {
 		middleware.RequestIDMiddleware(),
 	)
 
+	// Initialize the TikTok data providers
+	tikTokClient, err := tiktok.NewRouter(cfg.TikTokAPI)
+	if err != nil {
+		log.Fatalf("Failed to initialize TikTok provider: %v", err)
+	}
+
 	// Initialize handlers
-	handler := handlers.NewHandler(db, cfg, log)
+	handler := handlers.NewHandler(db, cfg, log, tikTokClient)
 
 	// Setup routes
 	setupRoutes(router, handler)
@@ -90,6 +118,25 @@ func main() {
 		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
+	accountRepo := repositories.NewAccountRepository(db)
+	tikTokService := services.NewTikTokService(accountRepo, repositories.NewAnalyticsRepository(db),
+		tikTokClient, log)
+
+	// Start the refresh job workers
+	jobPool := jobs.NewPool(cfg.Jobs, repositories.NewJobRepository(db), accountRepo, tikTokService, log)
+	jobPool.Start()
+
+	// Start the daily snapshot scheduler
+	var snapshotScheduler *scheduler.Scheduler
+	if cfg.Scheduler.Enabled {
+		snapshotScheduler, err = scheduler.New(cfg.Scheduler, accountRepo,
+			repositories.NewSchedulerRunRepository(db), tikTokService, log)
+		if err != nil {
+			log.Fatalf("Failed to create scheduler: %v", err)
+		}
+		snapshotScheduler.Start()
+	}
+
 	// Start server in a goroutine
 	go func() {
 		log.Infof("Starting server on %s", cfg.Server.Address)
@@ -113,10 +160,38 @@ func main() {
 		log.Errorf("Server forced to shutdown: %v", err)
 	}
 
+	// Stop the scheduler, letting in-flight fetches finish within the deadline
+	if snapshotScheduler != nil {
+		if err := snapshotScheduler.Stop(ctx); err != nil {
+			log.Errorf("Scheduler forced to stop: %v", err)
+		}
+	}
+
+	// Stop the job workers; unfinished items are requeued on next start
+	if err := jobPool.Stop(ctx); err != nil {
+		log.Errorf("Job workers forced to stop: %v", err)
+	}
+
 	log.Info("Server exited properly")
 }
 
 func setupRoutes(router *gin.Engine, handler *handlers.Handler) {
+	authRequired := middleware.AuthRequired(handler.Authenticator())
+	authz := handler.Authorizer()
+	// groupAccess and accountAccess scope routes whose :id is a group or
+	// an account to the groups the user may perform action in
+	groupAccess := func(action string) gin.HandlerFunc {
+		return middleware.GroupAccess(authz, action)
+	}
+	accountAccess := func(action string) gin.HandlerFunc {
+		return middleware.AccountAccess(authz, handler.AccountGroupResolver(), action)
+	}
+	// keyAuth also accepts API keys granted scope. Every route that takes
+	// keys names its scope, so a key never reaches more than it was given.
+	keyAuth := func(scope string) gin.HandlerFunc {
+		return middleware.AuthOrAPIKeyRequired(handler.Authenticator(), handler.APIKeyAuthenticator(), scope)
+	}
+
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
@@ -126,68 +201,146 @@ func setupRoutes(router *gin.Engine, handler *handlers.Handler) {
 	auth := router.Group("/api/auth")
 	{
 		auth.POST("/login", handler.Login)
-		auth.POST("/logout", middleware.AuthRequired(), handler.Logout)
-		auth.GET("/me", middleware.AuthRequired(), handler.GetCurrentUser)
+		auth.POST("/logout", authRequired, handler.Logout)
+		auth.POST("/logout-all", authRequired, handler.LogoutAll)
+		auth.GET("/me", authRequired, handler.GetCurrentUser)
 		auth.POST("/refresh", handler.RefreshToken)
+		auth.GET("/sessions", authRequired, handler.ListSessions)
+		auth.DELETE("/sessions/:id", authRequired, handler.RevokeSession)
+		auth.POST("/2fa/verify", handler.VerifyTwoFactor)
+		auth.POST("/2fa/enroll-challenge", handler.EnrollTwoFactorAtLogin)
+		auth.GET("/2fa", authRequired, handler.GetTwoFactorStatus)
+		auth.POST("/2fa/enroll", authRequired, handler.EnrollTwoFactor)
+		auth.POST("/2fa/confirm", authRequired, handler.ConfirmTwoFactor)
+		auth.POST("/2fa/disable", authRequired, handler.DisableTwoFactor)
+		auth.GET("/api-keys", authRequired, handler.ListAPIKeys)
+		auth.POST("/api-keys", authRequired, handler.CreateAPIKey)
+		auth.DELETE("/api-keys/:id", authRequired, handler.RevokeAPIKey)
 	}
 
 	// User management routes
-	users := router.Group("/api/users").Use(middleware.AuthRequired())
+	users := router.Group("/api/users").Use(authRequired)
 	{
-		users.GET("", middleware.RoleRequired("super_admin", "manager"), handler.ListUsers)
-		users.POST("", middleware.RoleRequired("super_admin", "manager"), handler.CreateUser)
-		users.GET("/:id", middleware.RoleRequired("super_admin", "manager"), handler.GetUser)
-		users.PUT("/:id", middleware.RoleRequired("super_admin", "manager"), handler.UpdateUser)
-		users.DELETE("/:id", middleware.RoleRequired("super_admin", "manager"), handler.DeleteUser)
-		users.GET("/by-role/:role", middleware.RoleRequired("super_admin", "manager"), handler.GetUsersByRole)
-		users.POST("/assign-group", middleware.RoleRequired("super_admin", "manager"), handler.AssignUserToGroup)
-		users.POST("/assign-manager", middleware.RoleRequired("super_admin"), handler.AssignManagerToGroup)
+		users.GET("", middleware.PermissionRequired(authz, models.PermUserRead), handler.ListUsers)
+		users.POST("", middleware.PermissionRequired(authz, models.PermUserCreate), handler.CreateUser)
+		users.GET("/:id", middleware.PermissionRequired(authz, models.PermUserRead), handler.GetUser)
+		users.PUT("/:id", middleware.PermissionRequired(authz, models.PermUserUpdate), handler.UpdateUser)
+		users.DELETE("/:id", middleware.PermissionRequired(authz, models.PermUserDelete), handler.DeleteUser)
+		users.GET("/by-role/:role", middleware.PermissionRequired(authz, models.PermUserRead), handler.GetUsersByRole)
+		users.POST("/assign-group", middleware.PermissionRequired(authz, models.PermUserAssignGroup), handler.AssignUserToGroup)
+		users.POST("/assign-manager", middleware.PermissionRequired(authz, models.PermUserAssignManager), handler.AssignManagerToGroup)
+		users.GET("/:id/sessions", middleware.PermissionRequired(authz, models.PermUserSecurity), handler.ListUserSessions)
+		users.DELETE("/:id/sessions", middleware.PermissionRequired(authz, models.PermUserSecurity), handler.RevokeUserSessions)
+		users.DELETE("/:id/sessions/:session_id", middleware.PermissionRequired(authz, models.PermUserSecurity), handler.RevokeUserSessions)
+		users.DELETE("/:id/2fa", middleware.PermissionRequired(authz, models.PermUserSecurity), handler.ResetUserTwoFactor)
+		users.DELETE("/:id/lockout", middleware.PermissionRequired(authz, models.PermUserSecurity), handler.UnlockUser)
+		users.GET("/:id/api-keys", middleware.PermissionRequired(authz, models.PermUserSecurity), handler.ListUserAPIKeys)
+		users.POST("/:id/api-keys", middleware.PermissionRequired(authz, models.PermUserSecurity), handler.CreateUserAPIKey)
+		users.DELETE("/:id/api-keys/:key_id", middleware.PermissionRequired(authz, models.PermUserSecurity), handler.RevokeUserAPIKey)
 	}
 
 	// Group management routes
-	groups := router.Group("/api/groups").Use(middleware.AuthRequired())
+	groups := router.Group("/api/groups")
 	{
-		groups.GET("", middleware.RoleRequired("super_admin", "manager"), handler.ListGroups)
-		groups.POST("", middleware.RoleRequired("super_admin", "manager"), handler.CreateGroup)
-		groups.GET("/:id", middleware.RoleRequired("super_admin", "manager"), handler.GetGroup)
-		groups.PUT("/:id", middleware.RoleRequired("super_admin", "manager"), handler.UpdateGroup)
-		groups.DELETE("/:id", middleware.RoleRequired("super_admin"), handler.DeleteGroup)
-		groups.GET("/managed", middleware.RoleRequired("manager"), handler.GetManagedGroups)
-		groups.POST("/assign-manager", middleware.RoleRequired("super_admin"), handler.AssignManagerToGroup)
-		groups.GET("/:id/users", middleware.RoleRequired("super_admin", "manager"), handler.GetGroupUsers)
+		groupsRead := keyAuth(models.ScopeGroupsRead)
+		groupsWrite := keyAuth(models.ScopeGroupsWrite)
+		groupsMembers := keyAuth(models.ScopeGroupsMembers)
+		groupsAdmin := keyAuth(models.ScopeGroupsAdmin)
+
+		groups.GET("", groupsRead, middleware.PermissionRequired(authz, models.PermGroupRead), handler.ListGroups)
+		groups.POST("", groupsWrite, middleware.PermissionRequired(authz, models.PermGroupCreate), handler.CreateGroup)
+		groups.GET("/:id", groupsRead, groupAccess(models.PermGroupRead), handler.GetGroup)
+		groups.PUT("/:id", groupsWrite, groupAccess(models.PermGroupUpdate), handler.UpdateGroup)
+		groups.DELETE("/:id", groupsAdmin, groupAccess(models.PermGroupDelete), handler.DeleteGroup)
+		groups.POST("/:id/move", groupsWrite, groupAccess(models.PermGroupUpdate), handler.MoveGroup)
+		groups.POST("/:id/archive", groupsAdmin, groupAccess(models.PermGroupDelete), handler.ArchiveGroup)
+		groups.POST("/:id/unarchive", groupsAdmin, groupAccess(models.PermGroupDelete), handler.UnarchiveGroup)
+		groups.POST("/:id/merge", groupsAdmin, groupAccess(models.PermGroupDelete), handler.MergeGroup)
+		groups.GET("/:id/policy", groupsRead, groupAccess(models.PermGroupRead), handler.GetGroupPolicy)
+		groups.PUT("/:id/policy", groupsAdmin, groupAccess(models.PermGroupPolicy), handler.UpdateGroupPolicy)
+		groups.GET("/managed", groupsRead, middleware.PermissionRequired(authz, models.PermGroupUpdate), handler.GetManagedGroups)
+		groups.POST("/assign-manager", groupsMembers, middleware.PermissionRequired(authz, models.PermUserAssignManager), handler.AssignManagerToGroup)
+		groups.GET("/:id/users", groupsRead, groupAccess(models.PermGroupRead), handler.GetGroupUsers)
+		groups.GET("/:id/stats", groupsRead, groupAccess(models.PermAnalyticsRead), handler.GetGroupStats)
+		groups.GET("/:id/members", groupsRead, groupAccess(models.PermGroupRead), handler.ListGroupMembers)
+		groups.PUT("/:id/members/:user_id", groupsMembers, groupAccess(models.PermGroupRead), handler.SetGroupMember)
+		groups.DELETE("/:id/members/:user_id", groupsMembers, groupAccess(models.PermGroupRead), handler.RemoveGroupMember)
 	}
 
 	// TikTok account routes
-	accounts := router.Group("/api/accounts").Use(middleware.AuthRequired())
+	accounts := router.Group("/api/accounts")
 	{
-		accounts.GET("", handler.ListAccounts)
-		accounts.POST("", middleware.RoleRequired("super_admin", "manager", "operator"), handler.CreateAccount)
-		accounts.GET("/:id", handler.GetAccount)
-		accounts.PUT("/:id", middleware.RoleRequired("super_admin", "manager", "operator"), handler.UpdateAccount)
-		accounts.DELETE("/:id", middleware.RoleRequired("super_admin", "manager"), handler.DeleteAccount)
-		accounts.POST("/import", middleware.RoleRequired("super_admin", "manager"), handler.ImportAccounts)
-		accounts.GET("/export", middleware.RoleRequired("super_admin", "manager", "operator"), handler.ExportAccounts)
-		accounts.GET("/by-group/:id", handler.GetAccountsByGroup)
-		accounts.POST("/transfer-group", middleware.RoleRequired("super_admin", "manager"), handler.TransferAccountToGroup)
+		accountsRead := keyAuth(models.ScopeAccountsRead)
+		accountsWrite := keyAuth(models.ScopeAccountsWrite)
+		accountsTransfer := keyAuth(models.ScopeAccountsTransfer)
+
+		accounts.GET("", accountsRead, handler.ListAccounts)
+		accounts.POST("", accountsWrite, middleware.PermissionRequired(authz, models.PermAccountCreate), handler.CreateAccount)
+		accounts.GET("/:id", accountsRead, accountAccess(models.PermAccountRead), handler.GetAccount)
+		accounts.PUT("/:id", accountsWrite, accountAccess(models.PermAccountUpdate), handler.UpdateAccount)
+		accounts.DELETE("/:id", keyAuth(models.ScopeAccountsDelete), accountAccess(models.PermAccountDelete), handler.DeleteAccount)
+		accounts.POST("/import", keyAuth(models.ScopeAccountsImport), middleware.PermissionRequired(authz, models.PermAccountImport), handler.ImportAccounts)
+		accounts.GET("/export", accountsRead, middleware.PermissionRequired(authz, models.PermAccountExport), handler.ExportAccounts)
+		accounts.GET("/by-group/:id", accountsRead, groupAccess(models.PermAccountRead), handler.GetAccountsByGroup)
+		accounts.POST("/transfer-group", accountsTransfer, middleware.PermissionRequired(authz, models.PermAccountTransfer), handler.TransferAccountToGroup)
+		accounts.GET("/transfers", accountsRead, handler.ListAccountTransfers)
+		accounts.POST("/transfers/:id/approve", accountsTransfer, middleware.PermissionRequired(authz, models.PermAccountTransfer), handler.ApproveAccountTransfer)
+		accounts.POST("/transfers/:id/reject", accountsTransfer, middleware.PermissionRequired(authz, models.PermAccountTransfer), handler.RejectAccountTransfer)
+		accounts.POST("/transfers/:id/cancel", accountsTransfer, handler.CancelAccountTransfer)
+		accounts.GET("/:id/group-history", accountsRead, accountAccess(models.PermAccountRead), handler.GetAccountGroupHistory)
 	}
 
 	// Analytics routes
-	analytics := router.Group("/api/analytics").Use(middleware.AuthRequired())
+	analytics := router.Group("/api/analytics")
 	{
-		analytics.GET("/dashboard", handler.GetDashboardData)
-		analytics.GET("/:id/trends", handler.GetAccountTrends)
-		analytics.GET("/compare", handler.CompareAccounts)
-		analytics.POST("/refresh", middleware.RoleRequired("super_admin", "manager"), handler.RefreshAccountData)
-		analytics.GET("/group/:id", handler.GetGroupAnalytics)
-		analytics.GET("/summary", handler.GetSummaryAnalytics)
+		analyticsRead := keyAuth(models.ScopeAnalyticsRead)
+
+		analytics.GET("/dashboard", analyticsRead, handler.GetDashboardData)
+		analytics.GET("/:id/trends", analyticsRead, accountAccess(models.PermAnalyticsRead), handler.GetAccountTrends)
+		analytics.GET("/compare", analyticsRead, handler.CompareAccounts)
+		analytics.POST("/refresh", keyAuth(models.ScopeAnalyticsWrite), middleware.PermissionRequired(authz, models.PermAccountRefresh), handler.RefreshAccountData)
+		analytics.GET("/group/:id", analyticsRead, groupAccess(models.PermAnalyticsRead), handler.GetGroupAnalytics)
+		analytics.GET("/summary", analyticsRead, handler.GetSummaryAnalytics)
 	}
 
 	// TikTok API integration routes
-	tiktok := router.Group("/api/tiktok").Use(middleware.AuthRequired())
+	tiktok := router.Group("/api/tiktok").Use(authRequired)
+	{
+		tiktok.POST("/fetch", middleware.PermissionRequired(authz, models.PermAccountRefresh), handler.FetchAccountData)
+		tiktok.GET("/status", middleware.PermissionRequired(authz, models.PermTikTokStatus), handler.GetTikTokAPIStatus)
+		tiktok.POST("/validate", middleware.PermissionRequired(authz, models.PermAccountCreate), handler.ValidateAccount)
+	}
+
+	// Background job routes
+	jobRoutes := router.Group("/api/jobs")
+	{
+		jobsWrite := keyAuth(models.ScopeJobsWrite)
+
+		jobRoutes.POST("/refresh", jobsWrite, middleware.PermissionRequired(authz, models.PermAccountRefresh), handler.SubmitRefreshJob)
+		jobRoutes.GET("/:id", keyAuth(models.ScopeJobsRead), handler.GetJob)
+		jobRoutes.POST("/:id/cancel", jobsWrite, handler.CancelJob)
+	}
+
+	// Audit trail routes
+	audit := router.Group("/api/audit").Use(authRequired)
+	{
+		audit.GET("", middleware.PermissionRequired(authz, models.PermAuditRead), handler.ListAuditLogs)
+	}
+
+	// Role management routes
+	roles := router.Group("/api/roles").Use(authRequired)
+	{
+		roles.GET("", handler.ListRoles)
+		roles.POST("", middleware.PermissionRequired(authz, models.PermRoleManage), handler.CreateRole)
+		roles.PUT("/:name", middleware.PermissionRequired(authz, models.PermRoleManage), handler.UpdateRole)
+		roles.DELETE("/:name", middleware.PermissionRequired(authz, models.PermRoleManage), handler.DeleteRole)
+	}
+
+	// Login lockout routes
+	lockouts := router.Group("/api/lockouts").Use(authRequired)
 	{
-		tiktok.POST("/fetch", middleware.RoleRequired("super_admin", "manager"), handler.FetchAccountData)
-		tiktok.GET("/status", middleware.RoleRequired("super_admin", "manager"), handler.GetTikTokAPIStatus)
-		tiktok.POST("/validate", middleware.RoleRequired("super_admin", "manager", "operator"), handler.ValidateAccount)
+		lockouts.GET("", middleware.PermissionRequired(authz, models.PermLockoutManage), handler.ListLockouts)
+		lockouts.DELETE("/ip/:ip", middleware.PermissionRequired(authz, models.PermLockoutManage), handler.UnlockIP)
 	}
 
 	// 404 handler
@@ -1,199 +1,582 @@
-package main
+// internal/config/config.go
+package config
 
 import (
-	"context"
-	"net/http"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
 	"os"
-	"os/signal"
+	"strconv"
 	"strings"
-	"syscall"
-
-	"github.com/gin-gonic/gin"
-	"github.com/katuhangugi/tiktok-account-system/internal/config"
-	"github.com/katuhangugi/tiktok-account-system/internal/database/database"
-	"github.com/katuhangugi/tiktok-account-system/internal/handlers"
-	"github.com/katuhangugi/tiktok-account-system/internal/middleware"
-	"github.com/katuhangugi/tiktok-account-system/pkg/logger"
+	"time"
+
+	"github.com/katuhangugi/tiktok-account-system/pkg/cron"
+	"gopkg.in/yaml.v3"
 )
 
-func main() {
-	// Initialize logger
-	log := logger.Default()
-	defer log.Close()
+// DefaultPath is the configuration file read when TIKTOK_CONFIG is not set
+const DefaultPath = "config.yaml"
+
+// Config is the root application configuration
+type Config struct {
+	Server    ServerConfig    `yaml:"server"`
+	Database  DatabaseConfig  `yaml:"database"`
+	JWT       JWTConfig       `yaml:"jwt"`
+	Auth      AuthConfig      `yaml:"auth"`
+	TikTokAPI TikTokAPIConfig `yaml:"tiktokapi"`
+	Logging   LoggingConfig   `yaml:"logging"`
+	Scheduler SchedulerConfig `yaml:"scheduler"`
+	Jobs      JobsConfig      `yaml:"jobs"`
+}
+
+// ServerConfig holds HTTP server settings
+type ServerConfig struct {
+	Address         string        `yaml:"address"`
+	Debug           bool          `yaml:"debug"`
+	ReadTimeout     time.Duration `yaml:"readTimeout"`
+	WriteTimeout    time.Duration `yaml:"writeTimeout"`
+	IdleTimeout     time.Duration `yaml:"idleTimeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+}
+
+// DatabaseConfig holds MySQL connection settings
+type DatabaseConfig struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"sslmode"`
+	// AutoMigrate applies pending schema migrations when the server starts
+	AutoMigrate bool `yaml:"autoMigrate"`
+}
+
+// JWTConfig holds token signing settings
+type JWTConfig struct {
+	Secret          string        `yaml:"secret"`
+	AccessTokenTTL  time.Duration `yaml:"accessTokenTTL"`
+	RefreshTokenTTL time.Duration `yaml:"refreshTokenTTL"`
+	Issuer          string        `yaml:"issuer"`
+}
+
+// AuthConfig holds login security settings
+type AuthConfig struct {
+	// TwoFactorRoles must sign in with a TOTP code as well as a password
+	TwoFactorRoles []string `yaml:"twoFactorRoles"`
+	// TwoFactorIssuer is the account label shown in authenticator apps
+	TwoFactorIssuer string `yaml:"twoFactorIssuer"`
+	// TwoFactorKey encrypts stored TOTP secrets; enrollment is refused
+	// while it is empty
+	TwoFactorKey string `yaml:"twoFactorKey"`
+	// ChallengeTTL is how long the second login step may take
+	ChallengeTTL time.Duration `yaml:"challengeTTL"`
+	// MaxLoginFailures locks a username for LockoutDuration after that many
+	// failed logins, and MaxIPFailures does the same for a client IP.
+	// Failures older than FailureWindow are forgotten; 0 disables a limit.
+	MaxLoginFailures int           `yaml:"maxLoginFailures"`
+	MaxIPFailures    int           `yaml:"maxIPFailures"`
+	FailureWindow    time.Duration `yaml:"failureWindow"`
+	LockoutDuration  time.Duration `yaml:"lockoutDuration"`
+	// After LoginDelayAfter failures of a username, each further attempt
+	// must wait LoginDelayBase, doubling per failure up to LoginDelayMax
+	LoginDelayAfter int           `yaml:"loginDelayAfter"`
+	LoginDelayBase  time.Duration `yaml:"loginDelayBase"`
+	LoginDelayMax   time.Duration `yaml:"loginDelayMax"`
+}
+
+// TikTokAPIConfig holds settings for the TikTok data source
+type TikTokAPIConfig struct {
+	// Provider is the default data source: scraper, official or fixture
+	Provider string `yaml:"provider"`
+	// GroupProviders overrides Provider for individual group IDs
+	GroupProviders map[uint]string `yaml:"groupProviders"`
+	Endpoint       string          `yaml:"endpoint"`
+	// APIEndpoint is the official API user info URL
+	APIEndpoint string        `yaml:"apiEndpoint"`
+	Key         string        `yaml:"key"`
+	Timeout     time.Duration `yaml:"timeout"`
+	// FixtureDir holds <account_name>.json files for the fixture provider
+	FixtureDir string `yaml:"fixtureDir"`
+	// FailedPageDir, when set, receives the raw HTML of profile pages the
+	// scraper could not parse
+	FailedPageDir string `yaml:"failedPageDir"`
+	// RateLimit is the number of scraper requests per second allowed to
+	// each host, with bursts of up to Burst; 0 disables throttling
+	RateLimit float64 `yaml:"rateLimit"`
+	Burst     int     `yaml:"burst"`
+	// MaxRetries is how often a request is retried after a 429, a 5xx or a
+	// network error, backing off from RetryBackoffBase up to RetryBackoffMax
+	MaxRetries       int           `yaml:"maxRetries"`
+	RetryBackoffBase time.Duration `yaml:"retryBackoffBase"`
+	RetryBackoffMax  time.Duration `yaml:"retryBackoffMax"`
+	// UserAgents are rotated across requests; empty uses a built-in list
+	UserAgents []string `yaml:"userAgents"`
+	// Proxies are optional proxy URLs used in rotation. A proxy failing
+	// ProxyMaxFailures times in a row is skipped for ProxyCooldown.
+	Proxies          []string      `yaml:"proxies"`
+	ProxyMaxFailures int           `yaml:"proxyMaxFailures"`
+	ProxyCooldown    time.Duration `yaml:"proxyCooldown"`
+}
+
+// LoggingConfig holds logger settings
+type LoggingConfig struct {
+	Level string `yaml:"level"`
+	File  string `yaml:"file"`
+}
 
-	// Load configuration
-	cfg, err := config.Load()
+// SchedulerConfig controls the background snapshot of active accounts
+type SchedulerConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Schedule is a five-field cron expression, a descriptor such as
+	// @daily, or "@every <duration>"
+	Schedule string `yaml:"schedule"`
+	// Jitter is the maximum random delay added before each account fetch
+	Jitter      time.Duration `yaml:"jitter"`
+	Concurrency int           `yaml:"concurrency"`
+}
+
+// JobsConfig controls the background job workers
+type JobsConfig struct {
+	Workers      int           `yaml:"workers"`
+	MaxAttempts  int           `yaml:"maxAttempts"`
+	BackoffBase  time.Duration `yaml:"backoffBase"`
+	BackoffMax   time.Duration `yaml:"backoffMax"`
+	PollInterval time.Duration `yaml:"pollInterval"`
+}
+
+// FieldError describes a single invalid configuration value
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationErrors collects every invalid field found by Validate
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return "invalid configuration: " + strings.Join(msgs, "; ")
+}
+
+// Default returns a configuration populated with built-in defaults
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Address:         ":8080",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    15 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 5 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Host:    "localhost",
+			Port:    "3306",
+			User:    "root",
+			Name:    "tiktok_management_system",
+			SSLMode: "disable",
+
+			AutoMigrate: true,
+		},
+		JWT: JWTConfig{
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 24 * time.Hour,
+			Issuer:          "tiktok-account-system",
+		},
+		Auth: AuthConfig{
+			TwoFactorIssuer: "TikTok Account System",
+			ChallengeTTL:    5 * time.Minute,
+
+			MaxLoginFailures: 10,
+			MaxIPFailures:    50,
+			FailureWindow:    15 * time.Minute,
+			LockoutDuration:  15 * time.Minute,
+			LoginDelayAfter:  3,
+			LoginDelayBase:   time.Second,
+			LoginDelayMax:    30 * time.Second,
+		},
+		TikTokAPI: TikTokAPIConfig{
+			Provider: "scraper",
+			Endpoint: "https://www.tiktok.com/@%s",
+			Timeout:  10 * time.Second,
+
+			RateLimit:        1,
+			Burst:            3,
+			MaxRetries:       3,
+			RetryBackoffBase: 2 * time.Second,
+			RetryBackoffMax:  30 * time.Second,
+			ProxyMaxFailures: 3,
+			ProxyCooldown:    5 * time.Minute,
+		},
+		Logging: LoggingConfig{
+			Level: "info",
+		},
+		Scheduler: SchedulerConfig{
+			Enabled:     true,
+			Schedule:    "0 2 * * *",
+			Jitter:      30 * time.Second,
+			Concurrency: 4,
+		},
+		Jobs: JobsConfig{
+			Workers:      4,
+			MaxAttempts:  3,
+			BackoffBase:  10 * time.Second,
+			BackoffMax:   5 * time.Minute,
+			PollInterval: 2 * time.Second,
+		},
+	}
+}
+
+// Load reads the configuration file named by TIKTOK_CONFIG (or DefaultPath),
+// applies TIKTOK_* environment overrides and validates the result
+func Load() (*Config, error) {
+	path := os.Getenv("TIKTOK_CONFIG")
+	explicit := path != ""
+	if !explicit {
+		path = DefaultPath
+	}
+
+	return LoadFile(path, explicit)
+}
+
+// LoadFile loads configuration from path. A missing file is only an error
+// when required is true; otherwise defaults and environment are used.
+func LoadFile(path string, required bool) (*Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		if !errors.Is(err, os.ErrNotExist) || required {
+			return nil, fmt.Errorf("failed to read config file %s: %v", path, err)
+		}
+	} else if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+
+	if err := cfg.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// envBinding maps an environment variable onto a configuration field
+type envBinding struct {
+	name  string
+	apply func(value string) error
+}
+
+func (c *Config) envBindings() []envBinding {
+	return []envBinding{
+		{"TIKTOK_SERVER_ADDRESS", setString(&c.Server.Address)},
+		{"TIKTOK_SERVER_DEBUG", setBool(&c.Server.Debug)},
+		{"TIKTOK_SERVER_READ_TIMEOUT", setDuration(&c.Server.ReadTimeout)},
+		{"TIKTOK_SERVER_WRITE_TIMEOUT", setDuration(&c.Server.WriteTimeout)},
+		{"TIKTOK_SERVER_IDLE_TIMEOUT", setDuration(&c.Server.IdleTimeout)},
+		{"TIKTOK_SERVER_SHUTDOWN_TIMEOUT", setDuration(&c.Server.ShutdownTimeout)},
+		{"TIKTOK_DATABASE_HOST", setString(&c.Database.Host)},
+		{"TIKTOK_DATABASE_PORT", setString(&c.Database.Port)},
+		{"TIKTOK_DATABASE_USER", setString(&c.Database.User)},
+		{"TIKTOK_DATABASE_PASSWORD", setString(&c.Database.Password)},
+		{"TIKTOK_DATABASE_NAME", setString(&c.Database.Name)},
+		{"TIKTOK_DATABASE_SSLMODE", setString(&c.Database.SSLMode)},
+		{"TIKTOK_DATABASE_AUTO_MIGRATE", setBool(&c.Database.AutoMigrate)},
+		{"TIKTOK_JWT_SECRET", setString(&c.JWT.Secret)},
+		{"TIKTOK_JWT_ACCESS_TOKEN_TTL", setDuration(&c.JWT.AccessTokenTTL)},
+		{"TIKTOK_JWT_REFRESH_TOKEN_TTL", setDuration(&c.JWT.RefreshTokenTTL)},
+		{"TIKTOK_JWT_ISSUER", setString(&c.JWT.Issuer)},
+		{"TIKTOK_AUTH_TWO_FACTOR_ROLES", setList(&c.Auth.TwoFactorRoles)},
+		{"TIKTOK_AUTH_TWO_FACTOR_ISSUER", setString(&c.Auth.TwoFactorIssuer)},
+		{"TIKTOK_AUTH_TWO_FACTOR_KEY", setString(&c.Auth.TwoFactorKey)},
+		{"TIKTOK_AUTH_CHALLENGE_TTL", setDuration(&c.Auth.ChallengeTTL)},
+		{"TIKTOK_AUTH_MAX_LOGIN_FAILURES", setInt(&c.Auth.MaxLoginFailures)},
+		{"TIKTOK_AUTH_MAX_IP_FAILURES", setInt(&c.Auth.MaxIPFailures)},
+		{"TIKTOK_AUTH_FAILURE_WINDOW", setDuration(&c.Auth.FailureWindow)},
+		{"TIKTOK_AUTH_LOCKOUT_DURATION", setDuration(&c.Auth.LockoutDuration)},
+		{"TIKTOK_AUTH_LOGIN_DELAY_AFTER", setInt(&c.Auth.LoginDelayAfter)},
+		{"TIKTOK_AUTH_LOGIN_DELAY_BASE", setDuration(&c.Auth.LoginDelayBase)},
+		{"TIKTOK_AUTH_LOGIN_DELAY_MAX", setDuration(&c.Auth.LoginDelayMax)},
+		{"TIKTOK_TIKTOKAPI_PROVIDER", setString(&c.TikTokAPI.Provider)},
+		{"TIKTOK_TIKTOKAPI_ENDPOINT", setString(&c.TikTokAPI.Endpoint)},
+		{"TIKTOK_TIKTOKAPI_API_ENDPOINT", setString(&c.TikTokAPI.APIEndpoint)},
+		{"TIKTOK_TIKTOKAPI_KEY", setString(&c.TikTokAPI.Key)},
+		{"TIKTOK_TIKTOKAPI_TIMEOUT", setDuration(&c.TikTokAPI.Timeout)},
+		{"TIKTOK_TIKTOKAPI_FIXTURE_DIR", setString(&c.TikTokAPI.FixtureDir)},
+		{"TIKTOK_TIKTOKAPI_FAILED_PAGE_DIR", setString(&c.TikTokAPI.FailedPageDir)},
+		{"TIKTOK_TIKTOKAPI_RATE_LIMIT", setFloat(&c.TikTokAPI.RateLimit)},
+		{"TIKTOK_TIKTOKAPI_BURST", setInt(&c.TikTokAPI.Burst)},
+		{"TIKTOK_TIKTOKAPI_MAX_RETRIES", setInt(&c.TikTokAPI.MaxRetries)},
+		{"TIKTOK_TIKTOKAPI_RETRY_BACKOFF_BASE", setDuration(&c.TikTokAPI.RetryBackoffBase)},
+		{"TIKTOK_TIKTOKAPI_RETRY_BACKOFF_MAX", setDuration(&c.TikTokAPI.RetryBackoffMax)},
+		{"TIKTOK_TIKTOKAPI_USER_AGENTS", setList(&c.TikTokAPI.UserAgents)},
+		{"TIKTOK_TIKTOKAPI_PROXIES", setList(&c.TikTokAPI.Proxies)},
+		{"TIKTOK_TIKTOKAPI_PROXY_MAX_FAILURES", setInt(&c.TikTokAPI.ProxyMaxFailures)},
+		{"TIKTOK_TIKTOKAPI_PROXY_COOLDOWN", setDuration(&c.TikTokAPI.ProxyCooldown)},
+		{"TIKTOK_LOGGING_LEVEL", setString(&c.Logging.Level)},
+		{"TIKTOK_LOGGING_FILE", setString(&c.Logging.File)},
+		{"TIKTOK_SCHEDULER_ENABLED", setBool(&c.Scheduler.Enabled)},
+		{"TIKTOK_SCHEDULER_SCHEDULE", setString(&c.Scheduler.Schedule)},
+		{"TIKTOK_SCHEDULER_JITTER", setDuration(&c.Scheduler.Jitter)},
+		{"TIKTOK_SCHEDULER_CONCURRENCY", setInt(&c.Scheduler.Concurrency)},
+		{"TIKTOK_JOBS_WORKERS", setInt(&c.Jobs.Workers)},
+		{"TIKTOK_JOBS_MAX_ATTEMPTS", setInt(&c.Jobs.MaxAttempts)},
+		{"TIKTOK_JOBS_BACKOFF_BASE", setDuration(&c.Jobs.BackoffBase)},
+		{"TIKTOK_JOBS_BACKOFF_MAX", setDuration(&c.Jobs.BackoffMax)},
+		{"TIKTOK_JOBS_POLL_INTERVAL", setDuration(&c.Jobs.PollInterval)},
 	}
+}
 
-	// Set log level from config
-	if cfg.Logging.Level != "" {
-		switch strings.ToLower(cfg.Logging.Level) {
-		case "debug":
-			log.SetLevel(logger.DEBUG)
-		case "info":
-			log.SetLevel(logger.INFO)
-		case "warn":
-			log.SetLevel(logger.WARN)
-		case "error":
-			log.SetLevel(logger.ERROR)
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	var errs ValidationErrors
+	for _, b := range c.envBindings() {
+		value, ok := lookup(b.name)
+		if !ok {
+			continue
+		}
+		if err := b.apply(value); err != nil {
+			errs = append(errs, FieldError{Field: b.name, Message: err.Error()})
 		}
 	}
 
-	// Initialize database
-	db, err := database.Init(cfg)
-	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func setString(dst *string) func(string) error {
+	return func(v string) error {
+		*dst = v
+		return nil
+	}
+}
+
+func setBool(dst *bool) func(string) error {
+	return func(v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", v)
+		}
+		*dst = b
+		return nil
+	}
+}
+
+func setInt(dst *int) func(string) error {
+	return func(v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", v)
+		}
+		*dst = n
+		return nil
+	}
+}
+
+func setFloat(dst *float64) func(string) error {
+	return func(v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", v)
+		}
+		*dst = f
+		return nil
 	}
-	defer func() {
-		if sqlDB, err := db.DB(); err == nil {
-			if err := sqlDB.Close(); err != nil {
-				log.Errorf("Failed to close database connection: %v", err)
+}
+
+// setList splits a comma separated value, dropping empty entries. User
+// agents contain commas, so list entries may also be separated by "|".
+func setList(dst *[]string) func(string) error {
+	return func(v string) error {
+		sep := ","
+		if strings.Contains(v, "|") {
+			sep = "|"
+		}
+		var list []string
+		for _, item := range strings.Split(v, sep) {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
 			}
 		}
-	}()
+		*dst = list
+		return nil
+	}
+}
+
+func setDuration(dst *time.Duration) func(string) error {
+	return func(v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", v)
+		}
+		*dst = d
+		return nil
+	}
+}
 
-	// Run database migrations
-	if err := database.AutoMigrate(db); err != nil {
-		log.Fatalf("Failed to run database migrations: %v", err)
+// Validate checks every field and reports all problems at once
+func (c *Config) Validate() error {
+	var errs ValidationErrors
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
 	}
 
-	// Create Gin router with production mode if not in debug
-	if !cfg.Server.Debug {
-		gin.SetMode(gin.ReleaseMode)
-	}
-
-	router := gin.New()
-
-	// Apply middleware
-	router.Use(
-		middleware.LoggingMiddleware(log),
-		middleware.RecoveryMiddleware(log),
-		middleware.CORS(),
-		middleware.RequestIDMiddleware(),
-	)
-
-	// Initialize handlers
-	handler := handlers.NewHandler(db, cfg, log)
-
-	// Setup routes
-	setupRoutes(router, handler)
-
-	// Create HTTP server with timeouts
-	server := &http.Server{
-		Addr:         cfg.Server.Address,
-		Handler:      router,
-		ReadTimeout:  cfg.Server.ReadTimeout,
-		WriteTimeout: cfg.Server.WriteTimeout,
-		IdleTimeout:  cfg.Server.IdleTimeout,
-	}
-
-	// Start server in a goroutine
-	go func() {
-		log.Infof("Starting server on %s", cfg.Server.Address)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
-		}
-	}()
-
-	// Wait for interrupt signal to gracefully shutdown the server
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	log.Info("Shutting down server...")
-
-	// Create a deadline to wait for
-	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
-	defer cancel()
-
-	// Gracefully shutdown the server
-	if err := server.Shutdown(ctx); err != nil {
-		log.Errorf("Server forced to shutdown: %v", err)
-	}
-
-	log.Info("Server exited properly")
-}
-
-func setupRoutes(router *gin.Engine, handler *handlers.Handler) {
-	// Health check endpoint
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
-	})
-
-	// Authentication routes
-	auth := router.Group("/api/auth")
-	{
-		auth.POST("/login", handler.Login)
-		auth.POST("/logout", middleware.AuthRequired(), handler.Logout)
-		auth.GET("/me", middleware.AuthRequired(), handler.GetCurrentUser)
-		auth.POST("/refresh", handler.RefreshToken)
-	}
-
-	// User management routes
-	users := router.Group("/api/users").Use(middleware.AuthRequired())
-	{
-		users.GET("", middleware.RoleRequired("super_admin", "manager"), handler.ListUsers)
-		users.POST("", middleware.RoleRequired("super_admin", "manager"), handler.CreateUser)
-		users.GET("/:id", middleware.RoleRequired("super_admin", "manager"), handler.GetUser)
-		users.PUT("/:id", middleware.RoleRequired("super_admin", "manager"), handler.UpdateUser)
-		users.DELETE("/:id", middleware.RoleRequired("super_admin", "manager"), handler.DeleteUser)
-		users.GET("/by-role/:role", middleware.RoleRequired("super_admin", "manager"), handler.GetUsersByRole)
-		users.POST("/assign-group", middleware.RoleRequired("super_admin", "manager"), handler.AssignUserToGroup)
-		users.POST("/assign-manager", middleware.RoleRequired("super_admin"), handler.AssignManagerToGroup)
-	}
-
-	// Group management routes
-	groups := router.Group("/api/groups").Use(middleware.AuthRequired())
-	{
-		groups.GET("", middleware.RoleRequired("super_admin", "manager"), handler.ListGroups)
-		groups.POST("", middleware.RoleRequired("super_admin", "manager"), handler.CreateGroup)
-		groups.GET("/:id", middleware.RoleRequired("super_admin", "manager"), handler.GetGroup)
-		groups.PUT("/:id", middleware.RoleRequired("super_admin", "manager"), handler.UpdateGroup)
-		groups.DELETE("/:id", middleware.RoleRequired("super_admin"), handler.DeleteGroup)
-		groups.GET("/managed", middleware.RoleRequired("manager"), handler.GetManagedGroups)
-		groups.POST("/assign-manager", middleware.RoleRequired("super_admin"), handler.AssignManagerToGroup)
-		groups.GET("/:id/users", middleware.RoleRequired("super_admin", "manager"), handler.GetGroupUsers)
-	}
-
-	// TikTok account routes
-	accounts := router.Group("/api/accounts").Use(middleware.AuthRequired())
-	{
-		accounts.GET("", handler.ListAccounts)
-		accounts.POST("", middleware.RoleRequired("super_admin", "manager", "operator"), handler.CreateAccount)
-		accounts.GET("/:id", handler.GetAccount)
-		accounts.PUT("/:id", middleware.RoleRequired("super_admin", "manager", "operator"), handler.UpdateAccount)
-		accounts.DELETE("/:id", middleware.RoleRequired("super_admin", "manager"), handler.DeleteAccount)
-		accounts.POST("/import", middleware.RoleRequired("super_admin", "manager"), handler.ImportAccounts)
-		accounts.GET("/export", middleware.RoleRequired("super_admin", "manager", "operator"), handler.ExportAccounts)
-		accounts.GET("/by-group/:id", handler.GetAccountsByGroup)
-		accounts.POST("/transfer-group", middleware.RoleRequired("super_admin", "manager"), handler.TransferAccountToGroup)
-	}
-
-	// Analytics routes
-	analytics := router.Group("/api/analytics").Use(middleware.AuthRequired())
-	{
-		analytics.GET("/dashboard", handler.GetDashboardData)
-		analytics.GET("/:id/trends", handler.GetAccountTrends)
-		analytics.GET("/compare", handler.CompareAccounts)
-		analytics.POST("/refresh", middleware.RoleRequired("super_admin", "manager"), handler.RefreshAccountData)
-		analytics.GET("/group/:id", handler.GetGroupAnalytics)
-		analytics.GET("/summary", handler.GetSummaryAnalytics)
-	}
-
-	// TikTok API integration routes
-	tiktok := router.Group("/api/tiktok").Use(middleware.AuthRequired())
-	{
-		tiktok.POST("/fetch", middleware.RoleRequired("super_admin", "manager"), handler.FetchAccountData)
-		tiktok.GET("/status", middleware.RoleRequired("super_admin", "manager"), handler.GetTikTokAPIStatus)
-		tiktok.POST("/validate", middleware.RoleRequired("super_admin", "manager", "operator"), handler.ValidateAccount)
-	}
-
-	// 404 handler
-	router.NoRoute(func(c *gin.Context) {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "Not Found",
-			"message": "The requested resource was not found",
-		})
-	})
+	if _, _, err := net.SplitHostPort(c.Server.Address); err != nil {
+		add("server.address", "invalid listen address %q", c.Server.Address)
+	}
+	for _, d := range []struct {
+		field string
+		value time.Duration
+	}{
+		{"server.readTimeout", c.Server.ReadTimeout},
+		{"server.writeTimeout", c.Server.WriteTimeout},
+		{"server.idleTimeout", c.Server.IdleTimeout},
+		{"server.shutdownTimeout", c.Server.ShutdownTimeout},
+		{"jwt.accessTokenTTL", c.JWT.AccessTokenTTL},
+		{"jwt.refreshTokenTTL", c.JWT.RefreshTokenTTL},
+		{"auth.challengeTTL", c.Auth.ChallengeTTL},
+		{"auth.failureWindow", c.Auth.FailureWindow},
+		{"auth.lockoutDuration", c.Auth.LockoutDuration},
+		{"auth.loginDelayBase", c.Auth.LoginDelayBase},
+		{"auth.loginDelayMax", c.Auth.LoginDelayMax},
+		{"tiktokapi.timeout", c.TikTokAPI.Timeout},
+		{"tiktokapi.retryBackoffBase", c.TikTokAPI.RetryBackoffBase},
+		{"tiktokapi.retryBackoffMax", c.TikTokAPI.RetryBackoffMax},
+		{"tiktokapi.proxyCooldown", c.TikTokAPI.ProxyCooldown},
+		{"jobs.backoffBase", c.Jobs.BackoffBase},
+		{"jobs.backoffMax", c.Jobs.BackoffMax},
+		{"jobs.pollInterval", c.Jobs.PollInterval},
+	} {
+		if d.value <= 0 {
+			add(d.field, "must be a positive duration")
+		}
+	}
+
+	if c.Database.Host == "" {
+		add("database.host", "is required")
+	}
+	if port, err := strconv.Atoi(c.Database.Port); err != nil || port <= 0 || port > 65535 {
+		add("database.port", "invalid port %q", c.Database.Port)
+	}
+	if c.Database.User == "" {
+		add("database.user", "is required")
+	}
+	if c.Database.Name == "" {
+		add("database.name", "is required")
+	}
+
+	if strings.TrimSpace(c.JWT.Secret) == "" {
+		add("jwt.secret", "is required")
+	}
+	if c.JWT.RefreshTokenTTL > 0 && c.JWT.RefreshTokenTTL < c.JWT.AccessTokenTTL {
+		add("jwt.refreshTokenTTL", "must not be shorter than jwt.accessTokenTTL")
+	}
+
+	// Roles are defined in the database, so only their form is checked here
+	for i, role := range c.Auth.TwoFactorRoles {
+		if strings.TrimSpace(role) == "" {
+			add(fmt.Sprintf("auth.twoFactorRoles[%d]", i), "must not be empty")
+		}
+	}
+	if len(c.Auth.TwoFactorRoles) > 0 && strings.TrimSpace(c.Auth.TwoFactorKey) == "" {
+		add("auth.twoFactorKey", "is required when auth.twoFactorRoles is set")
+	}
+	if c.Auth.MaxLoginFailures < 0 {
+		add("auth.maxLoginFailures", "must not be negative")
+	}
+	if c.Auth.MaxIPFailures < 0 {
+		add("auth.maxIPFailures", "must not be negative")
+	}
+	if c.Auth.LoginDelayAfter < 0 {
+		add("auth.loginDelayAfter", "must not be negative")
+	}
+	if c.Auth.LoginDelayMax < c.Auth.LoginDelayBase {
+		add("auth.loginDelayMax", "must not be shorter than auth.loginDelayBase")
+	}
+
+	if c.TikTokAPI.Provider == "" {
+		add("tiktokapi.provider", "is required")
+	}
+	for groupID, name := range c.TikTokAPI.GroupProviders {
+		if name == "" {
+			add(fmt.Sprintf("tiktokapi.groupProviders.%d", groupID), "is empty")
+		}
+	}
+	if c.TikTokAPI.Endpoint != "" {
+		u, err := url.Parse(strings.Replace(c.TikTokAPI.Endpoint, "%s", "user", 1))
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			add("tiktokapi.endpoint", "invalid URL %q", c.TikTokAPI.Endpoint)
+		}
+	}
+	if c.TikTokAPI.RateLimit < 0 {
+		add("tiktokapi.rateLimit", "must not be negative")
+	}
+	if c.TikTokAPI.RateLimit > 0 && c.TikTokAPI.Burst < 1 {
+		add("tiktokapi.burst", "must be at least 1")
+	}
+	if c.TikTokAPI.MaxRetries < 0 {
+		add("tiktokapi.maxRetries", "must not be negative")
+	}
+	if c.TikTokAPI.RetryBackoffMax < c.TikTokAPI.RetryBackoffBase {
+		add("tiktokapi.retryBackoffMax", "must not be shorter than tiktokapi.retryBackoffBase")
+	}
+	for i, proxy := range c.TikTokAPI.Proxies {
+		u, err := url.Parse(proxy)
+		if err != nil || u.Host == "" {
+			add(fmt.Sprintf("tiktokapi.proxies[%d]", i), "invalid URL")
+			continue
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			add(fmt.Sprintf("tiktokapi.proxies[%d]", i), "unsupported scheme %q", u.Scheme)
+		}
+	}
+	if c.TikTokAPI.ProxyMaxFailures < 1 {
+		add("tiktokapi.proxyMaxFailures", "must be at least 1")
+	}
+
+	switch strings.ToLower(c.Logging.Level) {
+	case "", "debug", "info", "warn", "error":
+	default:
+		add("logging.level", "unknown level %q", c.Logging.Level)
+	}
+
+	if c.Scheduler.Enabled {
+		if _, err := cron.Parse(c.Scheduler.Schedule); err != nil {
+			add("scheduler.schedule", "%v", err)
+		}
+		if c.Scheduler.Concurrency < 1 {
+			add("scheduler.concurrency", "must be at least 1")
+		}
+	}
+	if c.Scheduler.Jitter < 0 {
+		add("scheduler.jitter", "must not be negative")
+	}
+
+	if c.Jobs.Workers < 0 {
+		add("jobs.workers", "must not be negative")
+	}
+	if c.Jobs.MaxAttempts < 1 {
+		add("jobs.maxAttempts", "must be at least 1")
+	}
+	if c.Jobs.BackoffMax < c.Jobs.BackoffBase {
+		add("jobs.backoffMax", "must not be shorter than jobs.backoffBase")
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
 }
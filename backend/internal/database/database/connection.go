@@ -7,7 +7,6 @@ import (
 	"time"
 
 	"github.com/katuhangugi/tiktok-account-system/internal/config"
-	"github.com/katuhangugi/tiktok-account-system/internal/models"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -45,19 +44,3 @@ func Init(cfg *config.Config) (*gorm.DB, error) {
 	log.Println("Successfully connected to database")
 	return DB, nil
 }
-
-func AutoMigrate(db *gorm.DB) error {
-	// List all your models here
-	err := db.AutoMigrate(
-		&models.User{},
-		&models.Group{},
-		&models.TikTokAccount{},
-		&models.DailyAnalytics{},
-	)
-
-	if err != nil {
-		return fmt.Errorf("failed to auto-migrate database: %v", err)
-	}
-
-	return nil
-}
@@ -0,0 +1,365 @@
+// internal/database/migrate.go
+package database
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrationLockName is the MySQL named lock held while migrations run, so
+// two processes never apply the same migration concurrently
+const migrationLockName = "schema_migrations"
+
+// migrationLockTimeout is how long to wait for another migrator to finish
+const migrationLockTimeout = 30 * time.Second
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version  uint
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// MigrationStatus describes whether a migration has been applied
+type MigrationStatus struct {
+	Version   uint
+	Name      string
+	AppliedAt *time.Time
+	// Modified is set when the up script changed after it was applied
+	Modified bool
+	// Missing is set when the database has a migration this build does not
+	Missing bool
+}
+
+// schemaMigration is a row of the schema_migrations table
+type schemaMigration struct {
+	Version   uint
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Migrator applies and rolls back the SQL migrations in a filesystem
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator loads the migrations in files, usually migrations.FS
+func NewMigrator(db *gorm.DB, files fs.FS) (*Migrator, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %v", err)
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		match := migrationFileRe.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 32)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version in %q", entry.Name())
+		}
+		content, err := fs.ReadFile(files, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", entry.Name(), err)
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[m.Version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", m.Version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			sum := sha256.Sum256(content)
+			m.Up = string(content)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrator := &Migrator{db: db}
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		migrator.migrations = append(migrator.migrations, *m)
+	}
+	sort.Slice(migrator.migrations, func(i, j int) bool {
+		return migrator.migrations[i].Version < migrator.migrations[j].Version
+	})
+
+	return migrator, nil
+}
+
+// Up applies up to steps pending migrations in version order; steps <= 0
+// applies all of them. It refuses to run when an applied migration was
+// modified or is unknown to this build.
+func (m *Migrator) Up(steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(func(conn *gorm.DB) error {
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if steps > 0 && len(done) >= steps {
+				break
+			}
+
+			if err := execScript(conn, migration.Up); err != nil {
+				return fmt.Errorf("migration %d_%s failed: %v", migration.Version, migration.Name, err)
+			}
+			record := schemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				Checksum:  migration.Checksum,
+				AppliedAt: time.Now(),
+			}
+			if err := conn.Table("schema_migrations").Create(&record).Error; err != nil {
+				return fmt.Errorf("failed to record migration %d: %v", migration.Version, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the latest steps applied migrations, newest first
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, errors.New("number of migrations to roll back must be positive")
+	}
+
+	var done []Migration
+	err := m.withLock(func(conn *gorm.DB) error {
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down script", migration.Version, migration.Name)
+			}
+
+			if err := execScript(conn, migration.Down); err != nil {
+				return fmt.Errorf("rollback of %d_%s failed: %v", migration.Version, migration.Name, err)
+			}
+			err := conn.Exec("DELETE FROM schema_migrations WHERE version = ?", migration.Version).Error
+			if err != nil {
+				return fmt.Errorf("failed to unrecord migration %d: %v", migration.Version, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status lists every known migration and any applied migration this build
+// does not know, in version order
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied := make(map[uint]schemaMigration)
+	if m.db.Migrator().HasTable("schema_migrations") {
+		var err error
+		if applied, err = m.applied(m.db); err != nil {
+			return nil, err
+		}
+	}
+
+	var statuses []MigrationStatus
+	known := make(map[uint]bool)
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if record, ok := applied[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.AppliedAt = &appliedAt
+			status.Modified = record.Checksum != migration.Checksum
+		}
+		statuses = append(statuses, status)
+	}
+	for version, record := range applied {
+		if !known[version] {
+			appliedAt := record.AppliedAt
+			statuses = append(statuses, MigrationStatus{
+				Version:   version,
+				Name:      record.Name,
+				AppliedAt: &appliedAt,
+				Missing:   true,
+			})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+	return statuses, nil
+}
+
+// withLock runs fn on a single connection holding the migration lock
+func (m *Migrator) withLock(fn func(conn *gorm.DB) error) error {
+	return m.db.Connection(func(conn *gorm.DB) error {
+		var acquired sql.NullInt64
+		err := conn.Raw("SELECT GET_LOCK(?, ?)", migrationLockName, int(migrationLockTimeout.Seconds())).
+			Scan(&acquired).Error
+		if err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %v", err)
+		}
+		if !acquired.Valid || acquired.Int64 != 1 {
+			return errors.New("another process is running migrations")
+		}
+		defer conn.Exec("SELECT RELEASE_LOCK(?)", migrationLockName)
+
+		err = conn.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INT UNSIGNED PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    checksum CHAR(64) NOT NULL,
+    applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`).Error
+		if err != nil {
+			return fmt.Errorf("failed to create schema_migrations: %v", err)
+		}
+
+		return fn(conn)
+	})
+}
+
+func (m *Migrator) applied(conn *gorm.DB) (map[uint]schemaMigration, error) {
+	var records []schemaMigration
+	if err := conn.Table("schema_migrations").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %v", err)
+	}
+
+	applied := make(map[uint]schemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// verify rejects a database whose applied migrations differ from this build
+func (m *Migrator) verify(applied map[uint]schemaMigration) error {
+	known := make(map[uint]bool, len(m.migrations))
+	var problems []string
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		if record, ok := applied[migration.Version]; ok && record.Checksum != migration.Checksum {
+			problems = append(problems, fmt.Sprintf("%d_%s was modified after it was applied", migration.Version, migration.Name))
+		}
+	}
+	for version, record := range applied {
+		if !known[version] {
+			problems = append(problems, fmt.Sprintf("%d_%s is applied but unknown to this build", version, record.Name))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("schema_migrations does not match: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// execScript runs each statement of a SQL script in turn. MySQL commits DDL
+// implicitly, so a failing script may leave earlier statements applied.
+func execScript(conn *gorm.DB, script string) error {
+	for i, statement := range splitStatements(script) {
+		if err := conn.Exec(statement).Error; err != nil {
+			return fmt.Errorf("statement %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// splitStatements splits a script on semicolons outside quotes and
+// comments, dropping comments and empty statements
+func splitStatements(script string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+		quote      byte
+	)
+	flush := func() {
+		if s := strings.TrimSpace(current.String()); s != "" {
+			statements = append(statements, s)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case quote != 0:
+			current.WriteByte(c)
+			if c == '\\' && quote != '`' && i+1 < len(script) {
+				i++
+				current.WriteByte(script[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			current.WriteByte(c)
+		case c == '-' && strings.HasPrefix(script[i:], "--"), c == '#':
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+			current.WriteByte('\n')
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end + 3
+			}
+			current.WriteByte(' ')
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+
+	return statements
+}
@@ -0,0 +1,46 @@
+// internal/database/seed.go
+package database
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// Seed runs every .sql script in files, in name order, inside one
+// transaction. It returns false without changing anything when the
+// database already has users.
+func Seed(db *gorm.DB, files fs.FS) (bool, error) {
+	var users int64
+	if err := db.Table("users").Count(&users).Error; err != nil {
+		return false, fmt.Errorf("failed to count users: %v", err)
+	}
+	if users > 0 {
+		return false, nil
+	}
+
+	names, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return false, err
+	}
+	sort.Strings(names)
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, name := range names {
+			content, err := fs.ReadFile(files, name)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %v", name, err)
+			}
+			if err := execScript(tx, string(content)); err != nil {
+				return fmt.Errorf("seed %s failed: %v", name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,11 @@
+-- internal/database/migrations/0001_initial_schema.down.sql
+DROP TABLE IF EXISTS system_logs;
+DROP TABLE IF EXISTS daily_analytics;
+DROP TABLE IF EXISTS tiktok_accounts;
+
+ALTER TABLE `groups`
+    DROP FOREIGN KEY fk_groups_created_by,
+    DROP FOREIGN KEY fk_groups_managed_by;
+
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS `groups`;
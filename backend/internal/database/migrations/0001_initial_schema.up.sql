@@ -1,42 +1,46 @@
-// database/migrations/0001_initial_schema.up.sql
+-- internal/database/migrations/0001_initial_schema.up.sql
+-- users and groups reference each other, so the group foreign keys are
+-- added once both tables exist
+CREATE TABLE IF NOT EXISTS `groups` (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    created_by INT UNSIGNED NOT NULL,
+    managed_by INT UNSIGNED,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
 CREATE TABLE IF NOT EXISTS users (
-    id INT AUTO_INCREMENT PRIMARY KEY,
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
     username VARCHAR(50) NOT NULL UNIQUE,
     password_hash VARCHAR(255) NOT NULL,
     role ENUM('super_admin', 'manager', 'operator') NOT NULL DEFAULT 'operator',
-    group_id INT,
-    created_by INT,
-    managed_by INT,
+    group_id INT UNSIGNED,
+    created_by INT UNSIGNED,
+    managed_by INT UNSIGNED,
     is_active BOOLEAN NOT NULL DEFAULT TRUE,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-    FOREIGN KEY (group_id) REFERENCES groups(id),
-    FOREIGN KEY (created_by) REFERENCES users(id),
-    FOREIGN KEY (managed_by) REFERENCES users(id)
+    CONSTRAINT fk_users_group FOREIGN KEY (group_id) REFERENCES `groups`(id),
+    CONSTRAINT fk_users_created_by FOREIGN KEY (created_by) REFERENCES users(id),
+    CONSTRAINT fk_users_managed_by FOREIGN KEY (managed_by) REFERENCES users(id)
 );
 
-CREATE TABLE IF NOT EXISTS groups (
-    id INT AUTO_INCREMENT PRIMARY KEY,
-    name VARCHAR(100) NOT NULL,
-    description TEXT,
-    created_by INT NOT NULL,
-    managed_by INT,
-    is_active BOOLEAN NOT NULL DEFAULT TRUE,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-    FOREIGN KEY (created_by) REFERENCES users(id),
-    FOREIGN KEY (managed_by) REFERENCES users(id)
-);
+ALTER TABLE `groups`
+    ADD CONSTRAINT fk_groups_created_by FOREIGN KEY (created_by) REFERENCES users(id),
+    ADD CONSTRAINT fk_groups_managed_by FOREIGN KEY (managed_by) REFERENCES users(id);
 
 CREATE TABLE IF NOT EXISTS tiktok_accounts (
-    id INT AUTO_INCREMENT PRIMARY KEY,
-    account_name VARCHAR(100) NOT NULL,
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    account_name VARCHAR(100) NOT NULL UNIQUE,
     nickname VARCHAR(100),
     uid VARCHAR(50),
     location VARCHAR(100),
     registration_date DATE,
-    created_by INT NOT NULL,
-    group_id INT NOT NULL,
+    created_by INT UNSIGNED NOT NULL,
+    group_id INT UNSIGNED NOT NULL,
     account_owner VARCHAR(100),
     contact_info VARCHAR(255),
     notes TEXT,
@@ -46,13 +50,14 @@ CREATE TABLE IF NOT EXISTS tiktok_accounts (
     is_active BOOLEAN NOT NULL DEFAULT TRUE,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_tiktok_accounts_uid (uid),
     FOREIGN KEY (created_by) REFERENCES users(id),
-    FOREIGN KEY (group_id) REFERENCES groups(id)
+    FOREIGN KEY (group_id) REFERENCES `groups`(id)
 );
 
 CREATE TABLE IF NOT EXISTS daily_analytics (
-    id INT AUTO_INCREMENT PRIMARY KEY,
-    tiktok_account_id INT NOT NULL,
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tiktok_account_id INT UNSIGNED NOT NULL,
     date DATE NOT NULL,
     follower_count INT NOT NULL DEFAULT 0,
     following_count INT NOT NULL DEFAULT 0,
@@ -65,16 +70,16 @@ CREATE TABLE IF NOT EXISTS daily_analytics (
 );
 
 CREATE TABLE IF NOT EXISTS system_logs (
-    id INT AUTO_INCREMENT PRIMARY KEY,
-    user_id INT,
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id INT UNSIGNED,
     action VARCHAR(100) NOT NULL,
     resource VARCHAR(100),
-    target_user_id INT,
-    target_group_id INT,
+    target_user_id INT UNSIGNED,
+    target_group_id INT UNSIGNED,
     details JSON,
     ip_address VARCHAR(45),
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (user_id) REFERENCES users(id),
     FOREIGN KEY (target_user_id) REFERENCES users(id),
-    FOREIGN KEY (target_group_id) REFERENCES groups(id)
-);
\ No newline at end of file
+    FOREIGN KEY (target_group_id) REFERENCES `groups`(id)
+);
@@ -0,0 +1,4 @@
+-- internal/database/migrations/0002_scheduler_and_jobs.down.sql
+DROP TABLE IF EXISTS job_items;
+DROP TABLE IF EXISTS jobs;
+DROP TABLE IF EXISTS scheduler_runs;
@@ -0,0 +1,44 @@
+-- internal/database/migrations/0002_scheduler_and_jobs.up.sql
+CREATE TABLE IF NOT EXISTS scheduler_runs (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP NULL,
+    status VARCHAR(20) NOT NULL,
+    total INT NOT NULL DEFAULT 0,
+    succeeded INT NOT NULL DEFAULT 0,
+    failed INT NOT NULL DEFAULT 0,
+    error TEXT,
+    INDEX idx_scheduler_runs_started_at (started_at)
+);
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    type VARCHAR(30) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    created_by INT UNSIGNED NOT NULL,
+    group_id INT UNSIGNED,
+    total INT NOT NULL DEFAULT 0,
+    succeeded INT NOT NULL DEFAULT 0,
+    failed INT NOT NULL DEFAULT 0,
+    cancelled INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP NULL,
+    finished_at TIMESTAMP NULL,
+    INDEX idx_jobs_status (status),
+    FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS job_items (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    job_id INT UNSIGNED NOT NULL,
+    tiktok_account_id INT UNSIGNED NOT NULL,
+    account_name VARCHAR(100),
+    status VARCHAR(20) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_job_items_job_id (job_id),
+    INDEX idx_job_items_status (status),
+    FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE
+);
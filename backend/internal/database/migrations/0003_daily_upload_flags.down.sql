@@ -0,0 +1,4 @@
+-- internal/database/migrations/0003_daily_upload_flags.down.sql
+ALTER TABLE daily_analytics
+    DROP COLUMN videos_deleted,
+    DROP COLUMN uploads_estimated;
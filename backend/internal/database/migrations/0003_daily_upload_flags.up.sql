@@ -0,0 +1,4 @@
+-- internal/database/migrations/0003_daily_upload_flags.up.sql
+ALTER TABLE daily_analytics
+    ADD COLUMN uploads_estimated BOOLEAN NOT NULL DEFAULT FALSE AFTER daily_uploads,
+    ADD COLUMN videos_deleted BOOLEAN NOT NULL DEFAULT FALSE AFTER uploads_estimated;
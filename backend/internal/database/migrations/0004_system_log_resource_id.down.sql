@@ -0,0 +1,10 @@
+-- internal/database/migrations/0004_system_log_resource_id.down.sql
+ALTER TABLE system_logs
+    DROP INDEX idx_system_logs_resource,
+    DROP INDEX idx_system_logs_created_at,
+    DROP COLUMN resource_id;
+
+ALTER TABLE system_logs
+    ADD CONSTRAINT system_logs_ibfk_1 FOREIGN KEY (user_id) REFERENCES users(id),
+    ADD CONSTRAINT system_logs_ibfk_2 FOREIGN KEY (target_user_id) REFERENCES users(id),
+    ADD CONSTRAINT system_logs_ibfk_3 FOREIGN KEY (target_group_id) REFERENCES `groups`(id);
@@ -0,0 +1,13 @@
+-- internal/database/migrations/0004_system_log_resource_id.up.sql
+-- Audit entries must outlive the users and groups they mention, so the
+-- foreign keys from 0001 are dropped rather than cascaded. Their indexes
+-- on user_id, target_user_id and target_group_id stay for filtering.
+ALTER TABLE system_logs
+    DROP FOREIGN KEY system_logs_ibfk_1,
+    DROP FOREIGN KEY system_logs_ibfk_2,
+    DROP FOREIGN KEY system_logs_ibfk_3;
+
+ALTER TABLE system_logs
+    ADD COLUMN resource_id INT UNSIGNED AFTER resource,
+    ADD INDEX idx_system_logs_created_at (created_at),
+    ADD INDEX idx_system_logs_resource (resource, resource_id);
@@ -0,0 +1,2 @@
+-- internal/database/migrations/0005_refresh_tokens.down.sql
+DROP TABLE IF EXISTS refresh_tokens;
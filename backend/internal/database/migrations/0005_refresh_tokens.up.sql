@@ -0,0 +1,19 @@
+-- internal/database/migrations/0005_refresh_tokens.up.sql
+-- Refresh tokens are stored as SHA-256 hashes. Every token issued from one
+-- login shares a family_id, which access tokens carry as their session ID.
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id INT UNSIGNED NOT NULL,
+    family_id CHAR(32) NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL,
+    revoked_at TIMESTAMP NULL,
+    ip_address VARCHAR(45),
+    user_agent VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_refresh_tokens_token_hash (token_hash),
+    INDEX idx_refresh_tokens_family_id (family_id),
+    INDEX idx_refresh_tokens_user_id (user_id),
+    CONSTRAINT fk_refresh_tokens_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
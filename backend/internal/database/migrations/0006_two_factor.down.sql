@@ -0,0 +1,3 @@
+-- internal/database/migrations/0006_two_factor.down.sql
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS two_factor_secrets;
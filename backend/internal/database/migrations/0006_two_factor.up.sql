@@ -0,0 +1,19 @@
+-- internal/database/migrations/0006_two_factor.up.sql
+CREATE TABLE IF NOT EXISTS two_factor_secrets (
+    user_id INT UNSIGNED PRIMARY KEY,
+    secret VARCHAR(255) NOT NULL,
+    confirmed_at TIMESTAMP NULL,
+    last_used_step BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_two_factor_secrets_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id INT UNSIGNED NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_recovery_codes_user_id (user_id),
+    CONSTRAINT fk_recovery_codes_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
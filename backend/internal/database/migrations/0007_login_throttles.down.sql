@@ -0,0 +1,2 @@
+-- internal/database/migrations/0007_login_throttles.down.sql
+DROP TABLE IF EXISTS login_throttles;
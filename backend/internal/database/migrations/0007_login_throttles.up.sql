@@ -0,0 +1,11 @@
+-- internal/database/migrations/0007_login_throttles.up.sql
+-- Failed login counters, keyed by lowercased username or by client IP
+CREATE TABLE IF NOT EXISTS login_throttles (
+    scope VARCHAR(16) NOT NULL,
+    throttle_key VARCHAR(255) NOT NULL,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_until TIMESTAMP NULL,
+    PRIMARY KEY (scope, throttle_key),
+    INDEX idx_login_throttles_locked_until (locked_until)
+);
@@ -0,0 +1,2 @@
+-- internal/database/migrations/0008_api_keys.down.sql
+DROP TABLE IF EXISTS api_keys;
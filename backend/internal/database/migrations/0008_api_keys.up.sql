@@ -0,0 +1,20 @@
+-- internal/database/migrations/0008_api_keys.up.sql
+-- API keys are stored as SHA-256 hashes; prefix keeps the start of the key
+-- so owners can recognize it
+CREATE TABLE IF NOT EXISTS api_keys (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id INT UNSIGNED NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL,
+    scopes JSON,
+    expires_at TIMESTAMP NULL,
+    last_used_at TIMESTAMP NULL,
+    last_used_ip VARCHAR(45),
+    revoked_at TIMESTAMP NULL,
+    created_by INT UNSIGNED NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_api_keys_key_hash (key_hash),
+    INDEX idx_api_keys_user_id (user_id),
+    CONSTRAINT fk_api_keys_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,5 @@
+-- internal/database/migrations/0009_roles.down.sql
+-- Fails while users still have custom roles; reassign them first
+ALTER TABLE users DROP FOREIGN KEY fk_users_role;
+ALTER TABLE users MODIFY role ENUM('super_admin', 'manager', 'operator') NOT NULL DEFAULT 'operator';
+DROP TABLE IF EXISTS roles;
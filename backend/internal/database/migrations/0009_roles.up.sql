@@ -0,0 +1,79 @@
+-- internal/database/migrations/0009_roles.up.sql
+-- Roles become rows mapping permissions to scopes (own_group,
+-- managed_groups or all), so super admins can define new roles. The three
+-- original roles are kept as builtin rows with their previous access.
+CREATE TABLE IF NOT EXISTS roles (
+    name VARCHAR(50) PRIMARY KEY,
+    description VARCHAR(255),
+    builtin BOOLEAN NOT NULL DEFAULT FALSE,
+    permissions JSON NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+INSERT INTO roles (name, description, builtin, permissions) VALUES
+    ('super_admin', 'Full access to everything', TRUE,
+     '{
+        "account.read": "all",
+        "account.create": "all",
+        "account.update": "all",
+        "account.delete": "all",
+        "account.import": "all",
+        "account.export": "all",
+        "account.transfer": "all",
+        "account.refresh": "all",
+        "analytics.read": "all",
+        "group.read": "all",
+        "group.create": "all",
+        "group.update": "all",
+        "group.delete": "all",
+        "group.assign_manager": "all",
+        "user.read": "all",
+        "user.create": "all",
+        "user.update": "all",
+        "user.delete": "all",
+        "user.assign_group": "all",
+        "user.assign_manager": "all",
+        "user.security": "all",
+        "job.read": "all",
+        "audit.read": "all",
+        "lockout.manage": "all",
+        "tiktok.status": "all",
+        "role.manage": "all"
+     }'),
+    ('manager', 'Manages the groups assigned to them and their operators', TRUE,
+     '{
+        "account.read": "managed_groups",
+        "account.create": "managed_groups",
+        "account.update": "managed_groups",
+        "account.delete": "managed_groups",
+        "account.import": "managed_groups",
+        "account.export": "managed_groups",
+        "account.transfer": "managed_groups",
+        "account.refresh": "managed_groups",
+        "analytics.read": "managed_groups",
+        "group.read": "managed_groups",
+        "group.create": "managed_groups",
+        "group.update": "managed_groups",
+        "user.read": "managed_groups",
+        "user.create": "managed_groups",
+        "user.update": "managed_groups",
+        "user.delete": "managed_groups",
+        "user.assign_group": "managed_groups",
+        "user.security": "managed_groups",
+        "job.read": "managed_groups",
+        "audit.read": "managed_groups",
+        "tiktok.status": "all"
+     }'),
+    ('operator', 'Works on the accounts of their own group', TRUE,
+     '{
+        "account.read": "own_group",
+        "account.create": "own_group",
+        "account.update": "own_group",
+        "account.export": "own_group",
+        "analytics.read": "own_group"
+     }');
+
+ALTER TABLE users
+    MODIFY role VARCHAR(50) NOT NULL DEFAULT 'operator',
+    ADD CONSTRAINT fk_users_role FOREIGN KEY (role) REFERENCES roles(name);
@@ -0,0 +1,3 @@
+-- internal/database/migrations/0010_group_memberships.down.sql
+-- Users keep only their primary group and groups only their lead
+DROP TABLE IF EXISTS group_memberships;
@@ -0,0 +1,22 @@
+-- internal/database/migrations/0010_group_memberships.up.sql
+-- Users can belong to several groups, each with a role in it. The existing
+-- primary groups become member rows and group managers become leads;
+-- users.group_id and groups.managed_by are kept as the primary group and
+-- the lead.
+CREATE TABLE IF NOT EXISTS group_memberships (
+    user_id INT UNSIGNED NOT NULL,
+    group_id INT UNSIGNED NOT NULL,
+    role ENUM('member', 'lead', 'co_manager') NOT NULL DEFAULT 'member',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, group_id),
+    INDEX idx_group_memberships_group_id (group_id),
+    CONSTRAINT fk_group_memberships_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_group_memberships_group_id FOREIGN KEY (group_id) REFERENCES `groups`(id) ON DELETE CASCADE
+);
+
+INSERT INTO group_memberships (user_id, group_id, role)
+SELECT id, group_id, 'member' FROM users WHERE group_id IS NOT NULL;
+
+INSERT INTO group_memberships (user_id, group_id, role)
+SELECT managed_by, id, 'lead' FROM `groups` WHERE managed_by IS NOT NULL
+ON DUPLICATE KEY UPDATE role = 'lead';
@@ -0,0 +1,4 @@
+-- internal/database/migrations/0011_group_hierarchy.down.sql
+-- Every group becomes a top-level group again
+ALTER TABLE `groups` DROP FOREIGN KEY fk_groups_parent_id;
+ALTER TABLE `groups` DROP COLUMN parent_id;
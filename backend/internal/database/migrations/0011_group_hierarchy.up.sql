@@ -0,0 +1,7 @@
+-- internal/database/migrations/0011_group_hierarchy.up.sql
+-- Groups can be nested under a parent group. Existing groups stay at the
+-- top level.
+ALTER TABLE `groups`
+    ADD COLUMN parent_id INT UNSIGNED NULL AFTER managed_by,
+    ADD INDEX idx_groups_parent_id (parent_id),
+    ADD CONSTRAINT fk_groups_parent_id FOREIGN KEY (parent_id) REFERENCES `groups`(id);
@@ -0,0 +1,6 @@
+-- internal/database/migrations/0012_group_policies.down.sql
+-- Groups are unrestricted again
+UPDATE roles SET permissions = JSON_REMOVE(permissions, '$."group.policy"')
+WHERE JSON_CONTAINS_PATH(permissions, 'one', '$."group.policy"');
+
+DROP TABLE IF EXISTS group_policies;
@@ -0,0 +1,18 @@
+-- internal/database/migrations/0012_group_policies.up.sql
+-- Per-group quotas and allowed account attributes. Groups without a row
+-- are unrestricted. Only super admins may set them.
+CREATE TABLE IF NOT EXISTS group_policies (
+    group_id INT UNSIGNED PRIMARY KEY,
+    max_accounts INT NULL,
+    max_operators INT NULL,
+    allowed_locations JSON NULL,
+    allowed_tags JSON NULL,
+    operators_can_create_accounts BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_by INT UNSIGNED NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_group_policies_group_id FOREIGN KEY (group_id) REFERENCES `groups`(id) ON DELETE CASCADE,
+    CONSTRAINT fk_group_policies_updated_by FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+UPDATE roles SET permissions = JSON_SET(permissions, '$."group.policy"', 'all')
+WHERE name = 'super_admin';
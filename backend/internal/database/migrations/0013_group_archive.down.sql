@@ -0,0 +1,3 @@
+-- internal/database/migrations/0013_group_archive.down.sql
+-- Archived groups become ordinary groups again
+ALTER TABLE `groups` DROP COLUMN archived_at;
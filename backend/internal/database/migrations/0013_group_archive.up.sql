@@ -0,0 +1,5 @@
+-- internal/database/migrations/0013_group_archive.up.sql
+-- Archived groups are read-only and hidden from default lists
+ALTER TABLE `groups`
+    ADD COLUMN archived_at TIMESTAMP NULL AFTER is_active,
+    ADD INDEX idx_groups_archived_at (archived_at);
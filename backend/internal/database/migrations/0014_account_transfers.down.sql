@@ -0,0 +1,3 @@
+-- internal/database/migrations/0014_account_transfers.down.sql
+DROP TABLE IF EXISTS account_group_history;
+DROP TABLE IF EXISTS account_transfers;
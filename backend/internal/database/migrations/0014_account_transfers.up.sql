@@ -0,0 +1,37 @@
+-- internal/database/migrations/0014_account_transfers.up.sql
+-- Account transfers that may wait for approval, and the groups each
+-- account belonged to over time. History rows have no foreign key on
+-- group_id so they outlive merged and deleted groups. Existing accounts
+-- start their history in their current group at creation.
+CREATE TABLE IF NOT EXISTS account_transfers (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    account_id INT UNSIGNED NOT NULL,
+    from_group_id INT UNSIGNED NOT NULL,
+    to_group_id INT UNSIGNED NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    requested_by INT UNSIGNED NOT NULL,
+    note VARCHAR(500),
+    decided_by INT UNSIGNED NULL,
+    decided_at TIMESTAMP NULL,
+    decision_note VARCHAR(500),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_account_transfers_account_id (account_id),
+    INDEX idx_account_transfers_status (status),
+    CONSTRAINT fk_account_transfers_account_id FOREIGN KEY (account_id) REFERENCES tiktok_accounts(id) ON DELETE CASCADE,
+    CONSTRAINT fk_account_transfers_requested_by FOREIGN KEY (requested_by) REFERENCES users(id),
+    CONSTRAINT fk_account_transfers_decided_by FOREIGN KEY (decided_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS account_group_history (
+    id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    account_id INT UNSIGNED NOT NULL,
+    group_id INT UNSIGNED NOT NULL,
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ended_at TIMESTAMP NULL,
+    INDEX idx_account_group_history_account_id (account_id, started_at),
+    INDEX idx_account_group_history_group_id (group_id, started_at),
+    CONSTRAINT fk_account_group_history_account_id FOREIGN KEY (account_id) REFERENCES tiktok_accounts(id) ON DELETE CASCADE
+);
+
+INSERT INTO account_group_history (account_id, group_id, started_at)
+SELECT id, group_id, created_at FROM tiktok_accounts;
@@ -0,0 +1,10 @@
+// internal/database/migrations/migrations.go
+package migrations
+
+import "embed"
+
+// FS holds the versioned schema migrations, named NNNN_name.up.sql with an
+// optional NNNN_name.down.sql
+//
+//go:embed *.sql
+var FS embed.FS
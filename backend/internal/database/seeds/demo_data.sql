@@ -0,0 +1,40 @@
+-- internal/database/seeds/demo_data.sql
+-- Demo users and groups for development. Applied by "server seed" on an
+-- empty database; every account's password is "changeme123".
+-- Insert default Super Manager Admin
+INSERT INTO users (username, password_hash, role, is_active, created_at) 
+VALUES ('superadmin', '$2a$10$.d76pmVuj3Ne0JPx.pq4V.0sgMOJBHPoLSi/dSdQ0YSDOtxv5gTRe', 'super_admin', TRUE, NOW());
+
+-- Create default groups
+INSERT INTO `groups` (name, description, created_by, created_at) 
+VALUES 
+    ('Group A', 'Default Group A for operators', 1, NOW()),
+    ('Group B', 'Default Group B for operators', 1, NOW()),
+    ('Group C', 'Default Group C for operators', 1, NOW());
+
+-- Create some test managers
+INSERT INTO users (username, password_hash, role, managed_by, is_active, created_by, created_at) 
+VALUES 
+    ('manager1', '$2a$10$.d76pmVuj3Ne0JPx.pq4V.0sgMOJBHPoLSi/dSdQ0YSDOtxv5gTRe', 'manager', 1, TRUE, 1, NOW()),
+    ('manager2', '$2a$10$.d76pmVuj3Ne0JPx.pq4V.0sgMOJBHPoLSi/dSdQ0YSDOtxv5gTRe', 'manager', 1, TRUE, 1, NOW());
+
+-- Assign managers to groups
+UPDATE `groups` SET managed_by = 2 WHERE id = 1;
+UPDATE `groups` SET managed_by = 3 WHERE id = 2;
+
+-- Create some test operators
+INSERT INTO users (username, password_hash, role, group_id, managed_by, is_active, created_by, created_at) 
+VALUES 
+    ('operator1', '$2a$10$.d76pmVuj3Ne0JPx.pq4V.0sgMOJBHPoLSi/dSdQ0YSDOtxv5gTRe', 'operator', 1, 2, TRUE, 2, NOW()),
+    ('operator2', '$2a$10$.d76pmVuj3Ne0JPx.pq4V.0sgMOJBHPoLSi/dSdQ0YSDOtxv5gTRe', 'operator', 1, 2, TRUE, 2, NOW()),
+    ('operator3', '$2a$10$.d76pmVuj3Ne0JPx.pq4V.0sgMOJBHPoLSi/dSdQ0YSDOtxv5gTRe', 'operator', 2, 3, TRUE, 3, NOW());
+
+-- Give managers and operators access through group memberships, matching
+-- groups.managed_by and users.group_id above
+INSERT INTO group_memberships (user_id, group_id, role, created_at)
+VALUES
+    (2, 1, 'lead', NOW()),
+    (3, 2, 'lead', NOW()),
+    (4, 1, 'member', NOW()),
+    (5, 1, 'member', NOW()),
+    (6, 2, 'member', NOW());
@@ -0,0 +1,9 @@
+// internal/database/seeds/seeds.go
+package seeds
+
+import "embed"
+
+// FS holds optional seed data applied by "server seed"
+//
+//go:embed *.sql
+var FS embed.FS
@@ -2,11 +2,14 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"github.com/katuhangugi/tiktok-account-system/internal/services"
 	"github.com/katuhangugi/tiktok-account-system/internal/utils"
 )
 
@@ -24,7 +27,7 @@ func (h *Handler) ListAccounts(c *gin.Context) {
 		groupID = uint(id)
 	}
 
-	accounts, err := h.account.ListAccounts(userID, groupID)
+	accounts, err := h.account.ListAccounts(userID, groupID, includeSubgroups(c))
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -34,17 +37,15 @@ func (h *Handler) ListAccounts(c *gin.Context) {
 }
 
 func (h *Handler) CreateAccount(c *gin.Context) {
-	userID := c.MustGet("user_id").(uint)
-
 	var req models.TikTokAccountCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ValidationErrorResponse(c, utils.GetValidationErrors(err))
 		return
 	}
 
-	account, err := h.account.CreateAccount(userID, &req)
+	account, err := h.account.CreateAccount(actor(c), &req)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		groupPolicyErrorResponse(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -69,8 +70,6 @@ func (h *Handler) GetAccount(c *gin.Context) {
 }
 
 func (h *Handler) UpdateAccount(c *gin.Context) {
-	userID := c.MustGet("user_id").(uint)
-
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -84,9 +83,9 @@ func (h *Handler) UpdateAccount(c *gin.Context) {
 		return
 	}
 
-	account, err := h.account.UpdateAccount(userID, uint(id), &req)
+	account, err := h.account.UpdateAccount(actor(c), uint(id), &req)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		groupPolicyErrorResponse(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -94,8 +93,6 @@ func (h *Handler) UpdateAccount(c *gin.Context) {
 }
 
 func (h *Handler) DeleteAccount(c *gin.Context) {
-	userID := c.MustGet("user_id").(uint)
-
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -103,8 +100,8 @@ func (h *Handler) DeleteAccount(c *gin.Context) {
 		return
 	}
 
-	if err := h.account.DeleteAccount(userID, uint(id)); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	if err := h.account.DeleteAccount(actor(c), uint(id)); err != nil {
+		groupPolicyErrorResponse(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -112,7 +109,7 @@ func (h *Handler) DeleteAccount(c *gin.Context) {
 }
 
 func (h *Handler) ImportAccounts(c *gin.Context) {
-	userID := c.MustGet("user_id").(uint)
+	importer := actor(c)
 
 	var req models.ImportAccountsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -120,18 +117,37 @@ func (h *Handler) ImportAccounts(c *gin.Context) {
 		return
 	}
 
-	// Process imported accounts
-	var createdAccounts []models.TikTokAccountResponse
-	for _, accountReq := range req.Accounts {
-		account, err := h.account.CreateAccount(userID, &accountReq)
+	// Failed accounts are reported but do not stop the others
+	result := models.ImportAccountsResult{
+		Created: []models.TikTokAccountResponse{},
+		Failed:  []models.ImportAccountFailure{},
+	}
+	for i, accountReq := range req.Accounts {
+		account, err := h.account.CreateAccount(importer, &accountReq)
 		if err != nil {
-			// Skip failed accounts but continue with others
+			failure := models.ImportAccountFailure{Index: i, AccountName: accountReq.AccountName, Error: err.Error()}
+			var violation *services.GroupPolicyError
+			if errors.As(err, &violation) {
+				failure.Violation = violation
+			}
+			result.Failed = append(result.Failed, failure)
 			continue
 		}
-		createdAccounts = append(createdAccounts, *account)
+		result.Created = append(result.Created, *account)
 	}
 
-	utils.SuccessResponse(c, http.StatusCreated, "Accounts imported successfully", createdAccounts)
+	switch {
+	case len(result.Failed) == 0:
+		utils.SuccessResponse(c, http.StatusCreated, "Accounts imported successfully", result)
+	case len(result.Created) > 0:
+		utils.SuccessResponse(c, http.StatusMultiStatus, "Accounts partially imported", result)
+	default:
+		c.JSON(http.StatusUnprocessableEntity, utils.Response{
+			Success: false,
+			Error:   "No accounts were imported",
+			Data:    result,
+		})
+	}
 }
 
 func (h *Handler) ExportAccounts(c *gin.Context) {
@@ -148,7 +164,7 @@ func (h *Handler) ExportAccounts(c *gin.Context) {
 		groupID = uint(id)
 	}
 
-	accounts, err := h.account.ListAccounts(userID, groupID)
+	accounts, err := h.account.ListAccounts(userID, groupID, includeSubgroups(c))
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -189,7 +205,7 @@ func (h *Handler) GetAccountsByGroup(c *gin.Context) {
 		return
 	}
 
-	accounts, err := h.account.ListAccounts(userID, uint(id))
+	accounts, err := h.account.ListAccounts(userID, uint(id), includeSubgroups(c))
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -199,18 +215,94 @@ func (h *Handler) GetAccountsByGroup(c *gin.Context) {
 }
 
 func (h *Handler) TransferAccountToGroup(c *gin.Context) {
-	userID := c.MustGet("user_id").(uint)
-
 	var req models.TransferAccountRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ValidationErrorResponse(c, utils.GetValidationErrors(err))
 		return
 	}
 
-	if err := h.account.TransferToGroup(userID, req.AccountID, req.GroupID); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	transfer, err := h.account.TransferToGroup(actor(c), &req)
+	if err != nil {
+		groupPolicyErrorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if transfer.Status == models.TransferPending {
+		utils.SuccessResponse(c, http.StatusAccepted, "Transfer awaiting approval", transfer)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Account transferred successfully", transfer)
+}
+
+func (h *Handler) ListAccountTransfers(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	status := models.TransferStatus(c.Query("status"))
+	switch status {
+	case "", models.TransferPending, models.TransferApproved, models.TransferRejected, models.TransferCancelled:
+	default:
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid transfer status")
+		return
+	}
+
+	transfers, err := h.account.ListTransfers(userID, status)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "", transfers)
+}
+
+func (h *Handler) ApproveAccountTransfer(c *gin.Context) {
+	h.decideAccountTransfer(c, h.account.ApproveTransfer, "Account transferred successfully")
+}
+
+func (h *Handler) RejectAccountTransfer(c *gin.Context) {
+	h.decideAccountTransfer(c, h.account.RejectTransfer, "Transfer rejected")
+}
+
+func (h *Handler) CancelAccountTransfer(c *gin.Context) {
+	h.decideAccountTransfer(c, h.account.CancelTransfer, "Transfer cancelled")
+}
+
+// decideAccountTransfer handles a decision on the pending transfer in the
+// id parameter. The request body, with an optional note, may be empty.
+func (h *Handler) decideAccountTransfer(c *gin.Context,
+	decide func(services.Actor, uint, *models.TransferDecisionRequest) (*models.AccountTransfer, error), message string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid transfer ID")
+		return
+	}
+
+	var req models.TransferDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.ValidationErrorResponse(c, utils.GetValidationErrors(err))
+		return
+	}
+
+	transfer, err := decide(actor(c), uint(id), &req)
+	if err != nil {
+		groupPolicyErrorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, message, transfer)
+}
+
+func (h *Handler) GetAccountGroupHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid account ID")
+		return
+	}
+
+	history, err := h.account.GetGroupHistory(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Account transferred successfully", nil)
+	utils.SuccessResponse(c, http.StatusOK, "", history)
 }
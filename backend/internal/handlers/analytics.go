@@ -6,13 +6,20 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
 	"github.com/katuhangugi/tiktok-account-system/internal/utils"
 )
 
 func (h *Handler) GetDashboardData(c *gin.Context) {
 	userID := c.MustGet("user_id").(uint)
 
-	data, err := h.account.GetDashboardData(userID)
+	groupID, err := dashboardGroupID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	data, err := h.account.GetDashboardData(userID, groupID, includeSubgroups(c))
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -22,8 +29,6 @@ func (h *Handler) GetDashboardData(c *gin.Context) {
 }
 
 func (h *Handler) GetAccountTrends(c *gin.Context) {
-	userID := c.MustGet("user_id").(uint)
-
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -38,34 +43,7 @@ func (h *Handler) GetAccountTrends(c *gin.Context) {
 		return
 	}
 
-	// Check if user has access to this account
-	account, err := h.account.GetAccount(uint(id))
-	if err != nil {
-		utils.ErrorResponse(c, http.StatusNotFound, "Account not found")
-		return
-	}
-
-	// Verify access
-	user, err := h.auth.GetCurrentUser(userID)
-	if err != nil {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "User not found")
-		return
-	}
-
-	if user.Role == "operator" && (user.GroupID == nil || *user.GroupID != account.GroupID) {
-		utils.ErrorResponse(c, http.StatusForbidden, "No access to this account")
-		return
-	}
-
-	if user.Role == "manager" {
-		// Check if manager manages this group
-		group, err := h.group.GetGroup(account.GroupID)
-		if err != nil || group.ManagedBy == nil || *group.ManagedBy != user.ID {
-			utils.ErrorResponse(c, http.StatusForbidden, "No access to this account")
-			return
-		}
-	}
-
+	// Access to the account is checked by middleware.AccountAccess
 	trends, err := h.account.GetAccountTrends(uint(id), days)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
@@ -76,8 +54,6 @@ func (h *Handler) GetAccountTrends(c *gin.Context) {
 }
 
 func (h *Handler) CompareAccounts(c *gin.Context) {
-	userID := c.MustGet("user_id").(uint)
-
 	var req models.CompareAccountsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ValidationErrorResponse(c, utils.GetValidationErrors(err))
@@ -85,6 +61,7 @@ func (h *Handler) CompareAccounts(c *gin.Context) {
 	}
 
 	// Verify access to all accounts
+	user := requestUser(c)
 	for _, accountID := range req.AccountIDs {
 		account, err := h.account.GetAccount(accountID)
 		if err != nil {
@@ -92,26 +69,10 @@ func (h *Handler) CompareAccounts(c *gin.Context) {
 			return
 		}
 
-		// Verify access
-		user, err := h.auth.GetCurrentUser(userID)
-		if err != nil {
-			utils.ErrorResponse(c, http.StatusUnauthorized, "User not found")
-			return
-		}
-
-		if user.Role == "operator" && (user.GroupID == nil || *user.GroupID != account.GroupID) {
+		if err := h.policy.Authorize(user, models.PermAnalyticsRead, models.GroupResource(account.GroupID)); err != nil {
 			utils.ErrorResponse(c, http.StatusForbidden, "No access to one or more accounts")
 			return
 		}
-
-		if user.Role == "manager" {
-			// Check if manager manages this group
-			group, err := h.group.GetGroup(account.GroupID)
-			if err != nil || group.ManagedBy == nil || *group.ManagedBy != user.ID {
-				utils.ErrorResponse(c, http.StatusForbidden, "No access to one or more accounts")
-				return
-			}
-		}
 	}
 
 	comparison, err := h.analytics.GetComparisonData(req.AccountIDs, req.Days)
@@ -124,8 +85,6 @@ func (h *Handler) CompareAccounts(c *gin.Context) {
 }
 
 func (h *Handler) RefreshAccountData(c *gin.Context) {
-	userID := c.MustGet("user_id").(uint)
-
 	var req models.RefreshAccountRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ValidationErrorResponse(c, utils.GetValidationErrors(err))
@@ -139,27 +98,11 @@ func (h *Handler) RefreshAccountData(c *gin.Context) {
 		return
 	}
 
-	// Verify access
-	user, err := h.auth.GetCurrentUser(userID)
-	if err != nil {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "User not found")
-		return
-	}
-
-	if user.Role == "operator" && (user.GroupID == nil || *user.GroupID != account.GroupID) {
+	if err := h.policy.Authorize(requestUser(c), models.PermAccountRefresh, models.GroupResource(account.GroupID)); err != nil {
 		utils.ErrorResponse(c, http.StatusForbidden, "No access to this account")
 		return
 	}
 
-	if user.Role == "manager" {
-		// Check if manager manages this group
-		group, err := h.group.GetGroup(account.GroupID)
-		if err != nil || group.ManagedBy == nil || *group.ManagedBy != user.ID {
-			utils.ErrorResponse(c, http.StatusForbidden, "No access to this account")
-			return
-		}
-	}
-
 	if err := h.tikTok.RefreshAccountData(req.AccountID); err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -169,8 +112,6 @@ func (h *Handler) RefreshAccountData(c *gin.Context) {
 }
 
 func (h *Handler) GetGroupAnalytics(c *gin.Context) {
-	userID := c.MustGet("user_id").(uint)
-
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -185,31 +126,29 @@ func (h *Handler) GetGroupAnalytics(c *gin.Context) {
 		return
 	}
 
-	// Verify access to the group
-	group, err := h.group.GetGroup(uint(id))
+	attribution, err := groupAttribution(c)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusNotFound, "Group not found")
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid attribution parameter")
 		return
 	}
 
-	// Verify access
-	user, err := h.auth.GetCurrentUser(userID)
+	// Access to the group is checked by middleware.GroupAccess
+	group, err := h.group.GetGroup(uint(id))
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "User not found")
-		return
-	}
-
-	if user.Role == "operator" && (user.GroupID == nil || *user.GroupID != group.ID) {
-		utils.ErrorResponse(c, http.StatusForbidden, "No access to this group")
+		utils.ErrorResponse(c, http.StatusNotFound, "Group not found")
 		return
 	}
 
-	if user.Role == "manager" && (group.ManagedBy == nil || *group.ManagedBy != user.ID) {
-		utils.ErrorResponse(c, http.StatusForbidden, "No access to this group")
-		return
+	groupIDs := []uint{group.ID}
+	if includeSubgroups(c) {
+		groupIDs, err = h.policy.Subtree(requestUser(c), models.PermAnalyticsRead, group.ID)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusForbidden, err.Error())
+			return
+		}
 	}
 
-	analytics, err := h.analytics.GetGroupTrends(uint(id), days)
+	analytics, err := h.analytics.GetGroupTrends(groupIDs, days, attribution)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -221,7 +160,13 @@ func (h *Handler) GetGroupAnalytics(c *gin.Context) {
 func (h *Handler) GetSummaryAnalytics(c *gin.Context) {
 	userID := c.MustGet("user_id").(uint)
 
-	data, err := h.account.GetDashboardData(userID)
+	groupID, err := dashboardGroupID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	data, err := h.account.GetDashboardData(userID, groupID, includeSubgroups(c))
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -229,3 +174,13 @@ func (h *Handler) GetSummaryAnalytics(c *gin.Context) {
 
 	utils.SuccessResponse(c, http.StatusOK, "", data)
 }
+
+// dashboardGroupID is the optional group_id the dashboard is limited to, or 0
+func dashboardGroupID(c *gin.Context) (uint, error) {
+	groupIDStr := c.Query("group_id")
+	if groupIDStr == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(groupIDStr, 10, 32)
+	return uint(id), err
+}
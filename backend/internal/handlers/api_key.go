@@ -0,0 +1,97 @@
+// internal/handlers/api_key.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"github.com/katuhangugi/tiktok-account-system/internal/utils"
+)
+
+// ListAPIKeys returns the caller's API keys
+func (h *Handler) ListAPIKeys(c *gin.Context) {
+	h.listAPIKeys(c, actor(c).UserID)
+}
+
+// CreateAPIKey issues an API key owned by the caller
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	h.createAPIKey(c, actor(c).UserID)
+}
+
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	h.revokeAPIKey(c, actor(c).UserID, c.Param("id"))
+}
+
+// ListUserAPIKeys returns the API keys of a user the caller controls
+func (h *Handler) ListUserAPIKeys(c *gin.Context) {
+	if userID, ok := userIDParam(c); ok {
+		h.listAPIKeys(c, userID)
+	}
+}
+
+// CreateUserAPIKey issues a key for a user the caller controls, such as a
+// service user used by scripts
+func (h *Handler) CreateUserAPIKey(c *gin.Context) {
+	if userID, ok := userIDParam(c); ok {
+		h.createAPIKey(c, userID)
+	}
+}
+
+func (h *Handler) RevokeUserAPIKey(c *gin.Context) {
+	if userID, ok := userIDParam(c); ok {
+		h.revokeAPIKey(c, userID, c.Param("key_id"))
+	}
+}
+
+func (h *Handler) listAPIKeys(c *gin.Context, ownerID uint) {
+	keys, err := h.apiKeys.ListKeys(actor(c), ownerID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusForbidden, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "", keys)
+}
+
+func (h *Handler) createAPIKey(c *gin.Context, ownerID uint) {
+	var req models.APIKeyCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, utils.GetValidationErrors(err))
+		return
+	}
+
+	key, err := h.apiKeys.CreateKey(actor(c), ownerID, &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "API key created; store it now, it is not shown again", key)
+}
+
+func (h *Handler) revokeAPIKey(c *gin.Context, ownerID uint, keyIDStr string) {
+	keyID, err := strconv.ParseUint(keyIDStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	if err := h.apiKeys.RevokeKey(actor(c), ownerID, uint(keyID)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "API key revoked", nil)
+}
+
+// userIDParam parses the :id user parameter, answering 400 when invalid
+func userIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,73 @@
+// internal/handlers/audit.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"github.com/katuhangugi/tiktok-account-system/internal/utils"
+)
+
+// ListAuditLogs returns the audit trail, filtered by user_id, resource,
+// action and a from/to time range, with limit/offset paging
+func (h *Handler) ListAuditLogs(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var filter models.AuditLogFilter
+	filter.Resource = c.Query("resource")
+	filter.Action = c.Query("action")
+
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		id, err := strconv.ParseUint(userIDStr, 10, 32)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+			return
+		}
+		filterUserID := uint(id)
+		filter.UserID = &filterUserID
+	}
+
+	var err error
+	if filter.From, err = parseAuditTime(c.Query("from")); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid from parameter")
+		return
+	}
+	if filter.To, err = parseAuditTime(c.Query("to")); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid to parameter")
+		return
+	}
+
+	if filter.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "0")); err != nil || filter.Limit < 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid limit parameter")
+		return
+	}
+	if filter.Offset, err = strconv.Atoi(c.DefaultQuery("offset", "0")); err != nil || filter.Offset < 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid offset parameter")
+		return
+	}
+
+	page, err := h.audit.ListLogs(userID, filter)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusForbidden, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "", page)
+}
+
+// parseAuditTime accepts RFC 3339 timestamps or plain dates; empty means unset
+func parseAuditTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		if t, err = time.Parse("2006-01-02", value); err != nil {
+			return nil, err
+		}
+	}
+	return &t, nil
+}
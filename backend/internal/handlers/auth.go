@@ -2,10 +2,13 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"github.com/katuhangugi/tiktok-account-system/internal/services"
 	"github.com/katuhangugi/tiktok-account-system/internal/utils"
 )
 
@@ -16,24 +19,40 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
-	accessToken, refreshToken, err := h.auth.Login(req.Username, req.Password)
+	result, err := h.auth.Login(req.Username, req.Password, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		loginErrorResponse(c, err)
+		return
+	}
+
+	if result.TwoFactorRequired {
+		utils.SuccessResponse(c, http.StatusOK, "Two-factor authentication required", result)
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Login successful", gin.H{
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
-	})
+	utils.SuccessResponse(c, http.StatusOK, "Login successful", result)
 }
 
+// Logout revokes the current session
 func (h *Handler) Logout(c *gin.Context) {
-	// In a JWT system, logout is handled client-side by discarding the token
-	// We might want to add the token to a blacklist here if needed
+	if err := h.auth.Logout(actor(c), c.GetString("session_id")); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
 	utils.SuccessResponse(c, http.StatusOK, "Logout successful", nil)
 }
 
+// LogoutAll revokes every session of the current user
+func (h *Handler) LogoutAll(c *gin.Context) {
+	if err := h.auth.LogoutAll(actor(c)); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Logged out of all sessions", nil)
+}
+
 func (h *Handler) GetCurrentUser(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -57,13 +76,45 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	newAccessToken, err := h.auth.RefreshToken(req.RefreshToken)
+	tokens, err := h.auth.RefreshToken(req.RefreshToken, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Token refreshed", gin.H{
-		"access_token": newAccessToken,
-	})
+	utils.SuccessResponse(c, http.StatusOK, "Token refreshed", tokens)
+}
+
+func (h *Handler) ListSessions(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	sessions, err := h.auth.ListSessions(userID, c.GetString("session_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "", sessions)
+}
+
+func (h *Handler) RevokeSession(c *gin.Context) {
+	if err := h.auth.RevokeSession(actor(c), c.Param("id")); err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Session revoked", nil)
+}
+
+// loginErrorResponse answers throttled logins with 429 and a Retry-After
+// header, and every other failure with 401
+func loginErrorResponse(c *gin.Context, err error) {
+	var throttled *services.LoginThrottledError
+	if errors.As(err, &throttled) {
+		c.Header("Retry-After", strconv.Itoa(int(throttled.RetryAfter.Seconds())))
+		utils.ErrorResponse(c, http.StatusTooManyRequests, err.Error())
+		return
+	}
+
+	utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
 }
@@ -0,0 +1,365 @@
+// internal/handlers/group.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"github.com/katuhangugi/tiktok-account-system/internal/utils"
+)
+
+func (h *Handler) ListGroups(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	includeArchived, _ := strconv.ParseBool(c.Query("include_archived"))
+
+	groups, err := h.group.ListGroups(userID, includeArchived)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "", groups)
+}
+
+func (h *Handler) CreateGroup(c *gin.Context) {
+	var req models.GroupCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, utils.GetValidationErrors(err))
+		return
+	}
+
+	group, err := h.group.CreateGroup(actor(c), &req)
+	if err != nil {
+		groupPolicyErrorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Group created successfully", group)
+}
+
+func (h *Handler) GetGroup(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	group, err := h.group.GetGroup(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Group not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "", group)
+}
+
+func (h *Handler) UpdateGroup(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	var req models.GroupUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, utils.GetValidationErrors(err))
+		return
+	}
+
+	group, err := h.group.UpdateGroup(actor(c), uint(id), &req)
+	if err != nil {
+		groupPolicyErrorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Group updated successfully", group)
+}
+
+// MoveGroup moves a group and its subgroups under another group
+func (h *Handler) MoveGroup(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	var req models.GroupMoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, utils.GetValidationErrors(err))
+		return
+	}
+
+	group, err := h.group.MoveGroup(actor(c), uint(id), req.ParentID)
+	if err != nil {
+		groupPolicyErrorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Group moved successfully", group)
+}
+
+// GetGroupPolicy returns a group's quotas and its usage against them
+func (h *Handler) GetGroupPolicy(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	policy, err := h.groupPolicies.GetPolicy(userID, uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "", policy)
+}
+
+func (h *Handler) UpdateGroupPolicy(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	var req models.GroupPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, utils.GetValidationErrors(err))
+		return
+	}
+
+	policy, err := h.groupPolicies.UpdatePolicy(actor(c), uint(id), &req)
+	if err != nil {
+		groupPolicyErrorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Group policy updated successfully", policy)
+}
+
+func (h *Handler) DeleteGroup(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	// Groups that are not empty need a group to reassign their contents to
+	var reassignTo *uint
+	if reassignStr := c.Query("reassign_to"); reassignStr != "" {
+		targetID, err := strconv.ParseUint(reassignStr, 10, 32)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid reassignment group ID")
+			return
+		}
+		target := uint(targetID)
+		reassignTo = &target
+	}
+
+	if err := h.group.DeleteGroup(actor(c), uint(id), reassignTo); err != nil {
+		groupPolicyErrorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Group deleted successfully", nil)
+}
+
+func (h *Handler) ArchiveGroup(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	group, err := h.group.ArchiveGroup(actor(c), uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Group archived successfully", group)
+}
+
+func (h *Handler) UnarchiveGroup(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	group, err := h.group.UnarchiveGroup(actor(c), uint(id))
+	if err != nil {
+		groupPolicyErrorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Group unarchived successfully", group)
+}
+
+// MergeGroup moves a group's contents into another group, or with dry_run
+// previews what would move
+func (h *Handler) MergeGroup(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	var req models.GroupMergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, utils.GetValidationErrors(err))
+		return
+	}
+
+	preview, err := h.group.MergeGroup(actor(c), uint(id), &req)
+	if err != nil {
+		groupPolicyErrorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	message := "Group merged successfully"
+	if req.DryRun {
+		message = ""
+	}
+	utils.SuccessResponse(c, http.StatusOK, message, preview)
+}
+
+func (h *Handler) GetManagedGroups(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	groups, err := h.group.GetManagedGroups(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "", groups)
+}
+
+func (h *Handler) GetGroupUsers(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	users, err := h.group.GetGroupUsers(userID, uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "", users)
+}
+
+func (h *Handler) GetGroupStats(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	attribution, err := groupAttribution(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid attribution parameter")
+		return
+	}
+
+	stats, err := h.group.GetGroupStats(userID, uint(id), includeSubgroups(c), attribution)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "", stats)
+}
+
+// ListGroupMembers returns the users of a group with their role in it
+func (h *Handler) ListGroupMembers(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid group ID")
+		return
+	}
+
+	members, err := h.group.ListMembers(userID, uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "", members)
+}
+
+// SetGroupMember adds a user to a group or changes their role in it
+func (h *Handler) SetGroupMember(c *gin.Context) {
+	groupID, userID, ok := memberParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.MembershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, utils.GetValidationErrors(err))
+		return
+	}
+
+	if err := h.group.SetMembership(actor(c), groupID, userID, req.Role); err != nil {
+		groupPolicyErrorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Group membership updated successfully", nil)
+}
+
+func (h *Handler) RemoveGroupMember(c *gin.Context) {
+	groupID, userID, ok := memberParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.group.RemoveMembership(actor(c), groupID, userID); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "User removed from group successfully", nil)
+}
+
+// memberParams parses the group and user IDs of a membership route
+func memberParams(c *gin.Context) (uint, uint, bool) {
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid group ID")
+		return 0, 0, false
+	}
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return 0, 0, false
+	}
+	return uint(groupID), uint(userID), true
+}
@@ -2,47 +2,169 @@
 package handlers
 
 import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
 	"github.com/katuhangugi/tiktok-account-system/internal/config"
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
 	"github.com/katuhangugi/tiktok-account-system/internal/repositories"
 	"github.com/katuhangugi/tiktok-account-system/internal/services"
+	"github.com/katuhangugi/tiktok-account-system/internal/utils"
+	"github.com/katuhangugi/tiktok-account-system/pkg/logger"
 	"gorm.io/gorm"
 )
 
 type Handler struct {
-	db        *gorm.DB
-	cfg       *config.Config
-	auth      *services.AuthService
-	user      *services.UserService
-	group     *services.GroupService
-	account   *services.AccountService
-	analytics *services.AnalyticsService
-	tikTok    *services.TikTokService
+	db            *gorm.DB
+	cfg           *config.Config
+	auth          *services.AuthService
+	user          *services.UserService
+	group         *services.GroupService
+	account       *services.AccountService
+	analytics     *services.AnalyticsService
+	tikTok        *services.TikTokService
+	jobs          *services.JobService
+	audit         *services.AuditService
+	twoFactor     *services.TwoFactorService
+	throttle      *services.LoginThrottleService
+	apiKeys       *services.APIKeyService
+	policy        *services.PolicyService
+	roles         *services.RoleService
+	groupPolicies *services.GroupPolicyService
 }
 
-func NewHandler(db *gorm.DB, cfg *config.Config) *Handler {
+func NewHandler(db *gorm.DB, cfg *config.Config, log *logger.Logger, tikTokClient repositories.TikTokClientInterface) *Handler {
 	// Initialize repositories
 	userRepo := repositories.NewUserRepository(db)
 	groupRepo := repositories.NewGroupRepository(db)
 	accountRepo := repositories.NewAccountRepository(db)
 	analyticsRepo := repositories.NewAnalyticsRepository(db)
 	tikTokRepo := repositories.NewTikTokRepository(cfg)
+	jobRepo := repositories.NewJobRepository(db)
+	auditRepo := repositories.NewAuditRepository(db)
+	refreshTokenRepo := repositories.NewRefreshTokenRepository(db)
+	twoFactorRepo := repositories.NewTwoFactorRepository(db)
+	loginThrottleRepo := repositories.NewLoginThrottleRepository(db)
+	apiKeyRepo := repositories.NewAPIKeyRepository(db)
+	roleRepo := repositories.NewRoleRepository(db)
+	membershipRepo := repositories.NewMembershipRepository(db)
+	groupPolicyRepo := repositories.NewGroupPolicyRepository(db)
+	transferRepo := repositories.NewAccountTransferRepository(db)
 
 	// Initialize services
-	authService := services.NewAuthService(userRepo, cfg)
-	userService := services.NewUserService(userRepo, groupRepo)
-	groupService := services.NewGroupService(groupRepo, userRepo)
-	accountService := services.NewAccountService(accountRepo, userRepo, groupRepo, tikTokRepo)
+	policyService := services.NewPolicyService(roleRepo, membershipRepo, groupRepo)
+	auditService := services.NewAuditService(auditRepo, userRepo, policyService, log)
+	roleService := services.NewRoleService(roleRepo, userRepo, policyService, auditService)
+	groupPolicyService := services.NewGroupPolicyService(groupPolicyRepo, groupRepo, userRepo, policyService, auditService)
+	twoFactorService := services.NewTwoFactorService(twoFactorRepo, userRepo, cfg, auditService)
+	loginThrottleService := services.NewLoginThrottleService(loginThrottleRepo, userRepo, policyService, cfg, auditService)
+	authService := services.NewAuthService(userRepo, refreshTokenRepo, twoFactorService, loginThrottleService, cfg, auditService)
+	userService := services.NewUserService(userRepo, groupRepo, refreshTokenRepo, twoFactorRepo, loginThrottleRepo,
+		policyService, groupPolicyService, auditService)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, userRepo, userService, policyService, auditService)
+	groupService := services.NewGroupService(groupRepo, userRepo, membershipRepo, policyService, groupPolicyService,
+		auditService)
+	accountService := services.NewAccountService(accountRepo, transferRepo, userRepo, groupRepo, tikTokRepo,
+		policyService, groupPolicyService, auditService)
 	analyticsService := services.NewAnalyticsService(analyticsRepo, accountRepo)
-	tikTokService := services.NewTikTokService(accountRepo, analyticsRepo, tikTokRepo)
+	tikTokService := services.NewTikTokService(accountRepo, analyticsRepo, tikTokClient, log)
+	jobService := services.NewJobService(jobRepo, accountRepo, userRepo, groupRepo, policyService)
 
 	return &Handler{
-		db:        db,
-		cfg:       cfg,
-		auth:      authService,
-		user:      userService,
-		group:     groupService,
-		account:   accountService,
-		analytics: analyticsService,
-		tikTok:    tikTokService,
+		db:            db,
+		cfg:           cfg,
+		auth:          authService,
+		user:          userService,
+		group:         groupService,
+		account:       accountService,
+		analytics:     analyticsService,
+		tikTok:        tikTokService,
+		jobs:          jobService,
+		audit:         auditService,
+		twoFactor:     twoFactorService,
+		throttle:      loginThrottleService,
+		apiKeys:       apiKeyService,
+		policy:        policyService,
+		roles:         roleService,
+		groupPolicies: groupPolicyService,
 	}
 }
+
+// Authenticator is used by middleware.AuthRequired to check access tokens
+func (h *Handler) Authenticator() *services.AuthService {
+	return h.auth
+}
+
+// APIKeyAuthenticator is used by middleware.AuthOrAPIKeyRequired to check
+// API keys
+func (h *Handler) APIKeyAuthenticator() *services.APIKeyService {
+	return h.apiKeys
+}
+
+// Authorizer is used by middleware.PermissionRequired to check permissions
+func (h *Handler) Authorizer() *services.PolicyService {
+	return h.policy
+}
+
+// AccountGroupResolver is used by middleware.AccountAccess to find the
+// group of an account
+func (h *Handler) AccountGroupResolver() *services.AccountService {
+	return h.account
+}
+
+// actor identifies the authenticated caller for the audit trail
+func actor(c *gin.Context) services.Actor {
+	return services.Actor{
+		UserID:   c.MustGet("user_id").(uint),
+		IP:       c.ClientIP(),
+		APIKeyID: c.GetUint("api_key_id"),
+	}
+}
+
+// requestUser is the authenticated caller as set by the auth middleware, for
+// permission checks
+func requestUser(c *gin.Context) *models.User {
+	user := &models.User{
+		ID:   c.MustGet("user_id").(uint),
+		Role: models.Role(c.GetString("user_role")),
+	}
+	if groupID, ok := c.Get("user_group_id"); ok {
+		user.GroupID, _ = groupID.(*uint)
+	}
+	return user
+}
+
+// includeSubgroups reports whether the request asks to roll up the subgroups
+// of the requested group
+func includeSubgroups(c *gin.Context) bool {
+	subtree, _ := strconv.ParseBool(c.Query("include_subgroups"))
+	return subtree
+}
+
+// groupAttribution returns how the request asks group analytics to credit
+// snapshots: by ownership history unless attribution=current
+func groupAttribution(c *gin.Context) (models.Attribution, error) {
+	switch value := models.Attribution(c.DefaultQuery("attribution", string(models.AttributionHistory))); value {
+	case models.AttributionHistory, models.AttributionCurrent:
+		return value, nil
+	}
+	return "", errors.New("invalid attribution parameter")
+}
+
+// groupPolicyErrorResponse answers group policy violations with 422 and the
+// broken rule in data, and every other error with status
+func groupPolicyErrorResponse(c *gin.Context, status int, err error) {
+	var violation *services.GroupPolicyError
+	if errors.As(err, &violation) {
+		c.JSON(http.StatusUnprocessableEntity, utils.Response{
+			Success: false,
+			Error:   violation.Message,
+			Data:    violation,
+		})
+		return
+	}
+
+	utils.ErrorResponse(c, status, err.Error())
+}
@@ -0,0 +1,67 @@
+// internal/handlers/job.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"github.com/katuhangugi/tiktok-account-system/internal/utils"
+)
+
+func (h *Handler) SubmitRefreshJob(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var req models.RefreshJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, utils.GetValidationErrors(err))
+		return
+	}
+
+	job, err := h.jobs.SubmitRefresh(userID, &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusAccepted, "Refresh job queued", job)
+}
+
+func (h *Handler) GetJob(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	job, err := h.jobs.GetJob(userID, uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "", job)
+}
+
+func (h *Handler) CancelJob(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	job, err := h.jobs.CancelJob(userID, uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Job cancelled", job)
+}
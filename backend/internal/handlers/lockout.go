@@ -0,0 +1,48 @@
+// internal/handlers/lockout.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/katuhangugi/tiktok-account-system/internal/utils"
+)
+
+// ListLockouts returns the usernames and client IPs currently locked out
+func (h *Handler) ListLockouts(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	lockouts, err := h.throttle.ListLockouts(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusForbidden, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "", lockouts)
+}
+
+func (h *Handler) UnlockIP(c *gin.Context) {
+	if err := h.throttle.UnlockIP(actor(c), c.Param("ip")); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "IP address unlocked", nil)
+}
+
+func (h *Handler) UnlockUser(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := h.user.UnlockUser(actor(c), uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "User unlocked", nil)
+}
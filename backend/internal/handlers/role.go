@@ -0,0 +1,65 @@
+// internal/handlers/role.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"github.com/katuhangugi/tiktok-account-system/internal/utils"
+)
+
+// ListRoles returns the defined roles and every permission they can grant
+func (h *Handler) ListRoles(c *gin.Context) {
+	roles, err := h.roles.ListRoles()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "", gin.H{
+		"roles":       roles,
+		"permissions": models.AllPermissions,
+	})
+}
+
+func (h *Handler) CreateRole(c *gin.Context) {
+	var req models.RoleCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, utils.GetValidationErrors(err))
+		return
+	}
+
+	role, err := h.roles.CreateRole(actor(c), &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Role created successfully", role)
+}
+
+func (h *Handler) UpdateRole(c *gin.Context) {
+	var req models.RoleUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, utils.GetValidationErrors(err))
+		return
+	}
+
+	role, err := h.roles.UpdateRole(actor(c), c.Param("name"), &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Role updated successfully", role)
+}
+
+func (h *Handler) DeleteRole(c *gin.Context) {
+	if err := h.roles.DeleteRole(actor(c), c.Param("name")); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Role deleted successfully", nil)
+}
@@ -11,24 +11,20 @@ import (
 
 type TikTokHandler struct {
 	account *services.AccountService
-	auth    *services.AuthService
-	group   *services.GroupService
+	policy  *services.PolicyService
 	tikTok  *services.TikTokService
 }
 
-func NewTikTokHandler(account *services.AccountService, auth *services.AuthService,
-	group *services.GroupService, tikTok *services.TikTokService) *TikTokHandler {
+func NewTikTokHandler(account *services.AccountService, policy *services.PolicyService,
+	tikTok *services.TikTokService) *TikTokHandler {
 	return &TikTokHandler{
 		account: account,
-		auth:    auth,
-		group:   group,
+		policy:  policy,
 		tikTok:  tikTok,
 	}
 }
 
 func (h *TikTokHandler) FetchAccountData(c *gin.Context) {
-	userID := c.MustGet("user_id").(uint)
-
 	var req models.FetchAccountRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ValidationErrorResponse(c, utils.GetValidationErrors(err))
@@ -41,27 +37,11 @@ func (h *TikTokHandler) FetchAccountData(c *gin.Context) {
 		return
 	}
 
-	// Verify access
-	user, err := h.auth.GetCurrentUser(userID)
-	if err != nil {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "User not found")
-		return
-	}
-
-	if user.Role == models.RoleOperator && (user.GroupID == nil || *user.GroupID != account.GroupID) {
+	if err := h.policy.Authorize(requestUser(c), models.PermAccountRefresh, models.GroupResource(account.GroupID)); err != nil {
 		utils.ErrorResponse(c, http.StatusForbidden, "No access to this account")
 		return
 	}
 
-	if user.Role == models.RoleManager {
-		// Check if manager manages this group
-		group, err := h.group.GetGroup(account.GroupID)
-		if err != nil || group.ManagedBy == nil || *group.ManagedBy != user.ID {
-			utils.ErrorResponse(c, http.StatusForbidden, "No access to this account")
-			return
-		}
-	}
-
 	if err := h.tikTok.FetchAccountData(account); err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
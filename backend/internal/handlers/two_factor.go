@@ -0,0 +1,115 @@
+// internal/handlers/two_factor.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"github.com/katuhangugi/tiktok-account-system/internal/utils"
+)
+
+// VerifyTwoFactor is the second login step
+func (h *Handler) VerifyTwoFactor(c *gin.Context) {
+	var req models.TwoFactorVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, utils.GetValidationErrors(err))
+		return
+	}
+
+	tokens, err := h.auth.VerifyTwoFactor(req.ChallengeToken, req.Code, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		loginErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Login successful", tokens)
+}
+
+// EnrollTwoFactorAtLogin lets a user whose role requires two-factor
+// authentication enroll with the challenge from the password step
+func (h *Handler) EnrollTwoFactorAtLogin(c *gin.Context) {
+	var req models.TwoFactorChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, utils.GetValidationErrors(err))
+		return
+	}
+
+	enrollment, err := h.auth.EnrollWithChallenge(req.ChallengeToken, c.ClientIP())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Verify a code to finish enrollment", enrollment)
+}
+
+func (h *Handler) GetTwoFactorStatus(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	status, err := h.twoFactor.Status(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "", status)
+}
+
+func (h *Handler) EnrollTwoFactor(c *gin.Context) {
+	enrollment, err := h.twoFactor.Enroll(actor(c))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Verify a code to finish enrollment", enrollment)
+}
+
+func (h *Handler) ConfirmTwoFactor(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, utils.GetValidationErrors(err))
+		return
+	}
+
+	if err := h.twoFactor.Confirm(actor(c), req.Code); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Two-factor authentication enabled", nil)
+}
+
+func (h *Handler) DisableTwoFactor(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, utils.GetValidationErrors(err))
+		return
+	}
+
+	if err := h.twoFactor.Disable(actor(c), req.Code); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Two-factor authentication disabled", nil)
+}
+
+// ResetUserTwoFactor clears the two-factor setup of a user who lost their device
+func (h *Handler) ResetUserTwoFactor(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := h.user.ResetTwoFactor(actor(c), uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Two-factor authentication reset", nil)
+}
@@ -35,9 +35,9 @@ func (h *Handler) ListUsers(c *gin.Context) {
 		managerID = uint(id)
 	}
 
-	users, err := h.user.ListUsers(role, groupID, managerID)
+	users, err := h.user.ListUsers(userID, role, groupID, managerID)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		utils.ErrorResponse(c, http.StatusForbidden, err.Error())
 		return
 	}
 
@@ -45,17 +45,15 @@ func (h *Handler) ListUsers(c *gin.Context) {
 }
 
 func (h *Handler) CreateUser(c *gin.Context) {
-	userID := c.MustGet("user_id").(uint)
-
 	var req models.UserCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ValidationErrorResponse(c, utils.GetValidationErrors(err))
 		return
 	}
 
-	user, err := h.user.CreateUser(userID, &req)
+	user, err := h.user.CreateUser(actor(c), &req)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		groupPolicyErrorResponse(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -70,7 +68,7 @@ func (h *Handler) GetUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.user.GetUser(uint(id))
+	user, err := h.user.GetUser(c.MustGet("user_id").(uint), uint(id))
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusNotFound, "User not found")
 		return
@@ -80,8 +78,6 @@ func (h *Handler) GetUser(c *gin.Context) {
 }
 
 func (h *Handler) UpdateUser(c *gin.Context) {
-	userID := c.MustGet("user_id").(uint)
-
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -95,9 +91,9 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.user.UpdateUser(userID, uint(id), &req)
+	user, err := h.user.UpdateUser(actor(c), uint(id), &req)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		groupPolicyErrorResponse(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -105,8 +101,6 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 }
 
 func (h *Handler) DeleteUser(c *gin.Context) {
-	userID := c.MustGet("user_id").(uint)
-
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -114,7 +108,7 @@ func (h *Handler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.user.DeleteUser(userID, uint(id)); err != nil {
+	if err := h.user.DeleteUser(actor(c), uint(id)); err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -124,14 +118,14 @@ func (h *Handler) DeleteUser(c *gin.Context) {
 
 func (h *Handler) GetUsersByRole(c *gin.Context) {
 	role := c.Param("role")
-	if role != "super_admin" && role != "manager" && role != "operator" {
+	if !h.policy.RoleExists(models.Role(role)) {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid role")
 		return
 	}
 
-	users, err := h.user.ListUsers(role, 0, 0)
+	users, err := h.user.ListUsers(c.MustGet("user_id").(uint), role, 0, 0)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		utils.ErrorResponse(c, http.StatusForbidden, err.Error())
 		return
 	}
 
@@ -139,16 +133,14 @@ func (h *Handler) GetUsersByRole(c *gin.Context) {
 }
 
 func (h *Handler) AssignUserToGroup(c *gin.Context) {
-	userID := c.MustGet("user_id").(uint)
-
 	var req models.AssignGroupRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ValidationErrorResponse(c, utils.GetValidationErrors(err))
 		return
 	}
 
-	if err := h.user.AssignToGroup(userID, req.UserID, req.GroupID); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	if err := h.user.AssignToGroup(actor(c), req.UserID, req.GroupID); err != nil {
+		groupPolicyErrorResponse(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -156,18 +148,53 @@ func (h *Handler) AssignUserToGroup(c *gin.Context) {
 }
 
 func (h *Handler) AssignManagerToGroup(c *gin.Context) {
-	userID := c.MustGet("user_id").(uint)
-
 	var req models.AssignManagerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ValidationErrorResponse(c, utils.GetValidationErrors(err))
 		return
 	}
 
-	if err := h.user.AssignManager(userID, req.UserID, req.ManagerID); err != nil {
+	if err := h.user.AssignManager(actor(c), req.UserID, req.ManagerID); err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	utils.SuccessResponse(c, http.StatusOK, "Manager assigned successfully", nil)
 }
+
+func (h *Handler) ListUserSessions(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	sessions, err := h.user.ListUserSessions(userID, uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusForbidden, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "", sessions)
+}
+
+// RevokeUserSessions ends all sessions of the user, or only :session_id
+// when the route has one
+func (h *Handler) RevokeUserSessions(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := h.user.RevokeUserSessions(actor(c), uint(id), c.Param("session_id")); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sessions revoked", nil)
+}
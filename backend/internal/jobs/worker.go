@@ -0,0 +1,158 @@
+// internal/jobs/worker.go
+package jobs
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/katuhangugi/tiktok-account-system/internal/config"
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"github.com/katuhangugi/tiktok-account-system/internal/repositories"
+	"github.com/katuhangugi/tiktok-account-system/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// AccountFetcher fetches and stores a snapshot for a single account
+type AccountFetcher interface {
+	FetchAccountData(account *models.TikTokAccount) error
+}
+
+// Pool processes pending job items stored in the jobs table
+type Pool struct {
+	cfg         config.JobsConfig
+	jobRepo     *repositories.JobRepository
+	accountRepo *repositories.AccountRepository
+	fetcher     AccountFetcher
+	log         *logger.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPool creates a worker pool; call Start to begin processing
+func NewPool(cfg config.JobsConfig, jobRepo *repositories.JobRepository,
+	accountRepo *repositories.AccountRepository, fetcher AccountFetcher, log *logger.Logger) *Pool {
+	return &Pool{
+		cfg:         cfg,
+		jobRepo:     jobRepo,
+		accountRepo: accountRepo,
+		fetcher:     fetcher,
+		log:         log,
+	}
+}
+
+// Start requeues items abandoned by a previous process and launches the workers
+func (p *Pool) Start() {
+	if n, err := p.jobRepo.RequeueRunning(); err != nil {
+		p.log.Errorf("Jobs: failed to requeue abandoned items: %v", err)
+	} else if n > 0 {
+		p.log.Warnf("Jobs: requeued %d items abandoned by a previous run", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	for i := 0; i < p.cfg.Workers; i++ {
+		p.wg.Add(1)
+		go p.work(ctx)
+	}
+}
+
+// Stop signals the workers and waits for in-flight items, giving up when ctx expires
+func (p *Pool) Stop(ctx context.Context) error {
+	if p.cancel == nil {
+		return nil
+	}
+	p.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) work(ctx context.Context) {
+	defer p.wg.Done()
+
+	for ctx.Err() == nil {
+		item, err := p.jobRepo.ClaimNextItem()
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				p.log.Errorf("Jobs: failed to claim item: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(p.cfg.PollInterval):
+			}
+			continue
+		}
+
+		p.process(item)
+	}
+}
+
+func (p *Pool) process(item *models.JobItem) {
+	err := p.refresh(item)
+	if err == nil {
+		item.Status = models.JobItemSucceeded
+		item.LastError = ""
+		item.NextAttemptAt = nil
+	} else {
+		item.LastError = err.Error()
+		if item.Attempts < p.cfg.MaxAttempts {
+			next := time.Now().Add(p.backoff(item.Attempts))
+			item.Status = models.JobItemPending
+			item.NextAttemptAt = &next
+			p.log.Warnf("Jobs: item %d (%s) attempt %d failed, retrying at %s: %v",
+				item.ID, item.AccountName, item.Attempts, next.Format(time.RFC3339), err)
+		} else {
+			item.Status = models.JobItemFailed
+			p.log.Warnf("Jobs: item %d (%s) failed after %d attempts: %v",
+				item.ID, item.AccountName, item.Attempts, err)
+		}
+	}
+
+	if err := p.jobRepo.UpdateItem(item); err != nil {
+		p.log.Errorf("Jobs: failed to save item %d: %v", item.ID, err)
+		return
+	}
+
+	if err := p.jobRepo.RefreshProgress(item.JobID); err != nil {
+		p.log.Errorf("Jobs: failed to update progress of job %d: %v", item.JobID, err)
+	}
+}
+
+func (p *Pool) refresh(item *models.JobItem) error {
+	account, err := p.accountRepo.FindByID(item.TikTokAccountID)
+	if err != nil {
+		return errors.New("account not found")
+	}
+	return p.fetcher.FetchAccountData(account)
+}
+
+// backoff returns an exponential delay for the given attempt, capped at
+// BackoffMax, with up to half of it randomised
+func (p *Pool) backoff(attempt int) time.Duration {
+	delay := p.cfg.BackoffBase
+	for i := 1; i < attempt && delay < p.cfg.BackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > p.cfg.BackoffMax {
+		delay = p.cfg.BackoffMax
+	}
+	if half := int64(delay / 2); half > 0 {
+		delay = delay/2 + time.Duration(rand.Int63n(half))
+	}
+	return delay
+}
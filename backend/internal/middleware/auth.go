@@ -3,110 +3,229 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
 	"github.com/katuhangugi/tiktok-account-system/internal/utils"
 )
 
-func AuthRequired() gin.HandlerFunc {
+// Authenticator resolves an access token to its user, rejecting tokens of
+// deactivated users and revoked sessions
+type Authenticator interface {
+	Authenticate(accessToken string) (*models.User, *utils.Claims, error)
+}
+
+// APIKeyAuthenticator resolves an API key to its owner
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(key, ip string) (*models.User, *models.APIKey, error)
+}
+
+// Authorizer decides whether a user may perform an action
+type Authorizer interface {
+	Authorize(user *models.User, action string, resource models.Resource) error
+}
+
+// AccountGroupResolver finds the group an account belongs to
+type AccountGroupResolver interface {
+	AccountGroupID(accountID uint) (uint, error)
+}
+
+// AuthRequired accepts session access tokens only
+func AuthRequired(auth Authenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
-			c.Abort()
+		tokenString, ok := bearerToken(c)
+		if !ok {
 			return
 		}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == authHeader {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Bearer token not found"})
+		if strings.HasPrefix(tokenString, models.APIKeyPrefix) || c.GetHeader("X-API-Key") != "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "API keys are not accepted for this endpoint"})
 			c.Abort()
 			return
 		}
 
-		claims, err := utils.ValidateToken(tokenString)
+		authenticateSession(c, auth, tokenString)
+	}
+}
+
+// AuthOrAPIKeyRequired accepts session access tokens and API keys, given
+// as "Authorization: Bearer tas_..." or in the X-API-Key header. API keys
+// need scope, the one the route names; the owner's permissions are checked
+// as for any request.
+func AuthOrAPIKeyRequired(auth Authenticator, keys APIKeyAuthenticator, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("X-API-Key")
+		if key == "" {
+			tokenString, ok := bearerToken(c)
+			if !ok {
+				return
+			}
+			if !strings.HasPrefix(tokenString, models.APIKeyPrefix) {
+				authenticateSession(c, auth, tokenString)
+				return
+			}
+			key = tokenString
+		}
+
+		user, apiKey, err := keys.AuthenticateAPIKey(key, c.ClientIP())
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+			c.Abort()
+			return
+		}
+
+		if !apiKey.HasScope(scope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "API key lacks scope " + scope})
 			c.Abort()
 			return
 		}
 
-		// Set user information in context
-		c.Set("user_id", claims.UserID)
-		c.Set("user_role", claims.Role)
-		c.Set("user_group_id", claims.GroupID)
+		c.Set("user_id", user.ID)
+		c.Set("user_role", string(user.Role))
+		c.Set("user_group_id", user.GroupID)
+		c.Set("api_key_id", apiKey.ID)
 
 		c.Next()
 	}
 }
 
-func RoleRequired(roles ...string) gin.HandlerFunc {
+// bearerToken returns the bearer token of the request, aborting it when
+// there is none
+func bearerToken(c *gin.Context) (string, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
+		c.Abort()
+		return "", false
+	}
+
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	if tokenString == authHeader {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Bearer token not found"})
+		c.Abort()
+		return "", false
+	}
+
+	return tokenString, true
+}
+
+func authenticateSession(c *gin.Context, auth Authenticator, tokenString string) {
+	user, claims, err := auth.Authenticate(tokenString)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		c.Abort()
+		return
+	}
+
+	// Set user information in context, using the current role and group
+	// rather than the ones the token was issued with
+	c.Set("user_id", user.ID)
+	c.Set("user_role", string(user.Role))
+	c.Set("user_group_id", user.GroupID)
+	c.Set("session_id", claims.SessionID)
+
+	c.Next()
+}
+
+// PermissionRequired lets the request through when the user holds action
+// at any scope. Handlers and services still check the scope against the
+// resources involved.
+func PermissionRequired(auth Authorizer, action string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userRole, exists := c.Get("user_role")
-		if !exists {
-			c.JSON(http.StatusForbidden, gin.H{"error": "User role not found"})
-			c.Abort()
+		user, ok := contextUser(c)
+		if !ok {
 			return
 		}
 
-		roleStr, ok := userRole.(string)
-		if !ok {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid user role type"})
+		if err := auth.Authorize(user, action, models.Resource{}); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
 			c.Abort()
 			return
 		}
 
-		hasRole := false
-		for _, role := range roles {
-			if roleStr == role {
-				hasRole = true
-				break
-			}
+		c.Next()
+	}
+}
+
+// GroupAccess guards routes whose :id is a group: the user must hold
+// action in that group, which for managers covers the groups they manage
+func GroupAccess(auth Authorizer, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := contextUser(c)
+		if !ok {
+			return
 		}
 
-		if !hasRole {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		groupID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
 			c.Abort()
 			return
 		}
 
-		c.Next()
+		checkGroupAccess(c, auth, user, action, uint(groupID), "No access to this group")
 	}
 }
 
-func GroupAccess() gin.HandlerFunc {
+// AccountAccess guards routes whose :id is a TikTok account: the user must
+// hold action in the account's group
+func AccountAccess(auth Authorizer, accounts AccountGroupResolver, action string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userRole, _ := c.Get("user_role")
-		userGroupID, _ := c.Get("user_group_id")
-		requestedGroupID := c.Param("group_id")
-
-		if userRole == "super_admin" {
-			c.Next()
+		user, ok := contextUser(c)
+		if !ok {
 			return
 		}
 
-		// For managers, check if they manage the requested group
-		if userRole == "manager" {
-			// Implement logic to check if manager has access to this group
-			// This would typically involve a database query
-			hasAccess := false // Replace with actual check
-			if !hasAccess {
-				c.JSON(http.StatusForbidden, gin.H{"error": "No access to this group"})
-				c.Abort()
-				return
-			}
+		accountID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+			c.Abort()
+			return
 		}
 
-		// For operators, check if the requested group is their own
-		if userRole == "operator" {
-			if requestedGroupID != userGroupID {
-				c.JSON(http.StatusForbidden, gin.H{"error": "No access to this group"})
-				c.Abort()
-				return
-			}
+		groupID, err := accounts.AccountGroupID(uint(accountID))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+			c.Abort()
+			return
 		}
 
-		c.Next()
+		checkGroupAccess(c, auth, user, action, groupID, "No access to this account")
+	}
+}
+
+func checkGroupAccess(c *gin.Context, auth Authorizer, user *models.User, action string, groupID uint, denied string) {
+	if err := auth.Authorize(user, action, models.GroupResource(groupID)); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": denied})
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
+
+// contextUser is the authenticated user as set by the auth middleware,
+// aborting the request when there is none
+func contextUser(c *gin.Context) (*models.User, bool) {
+	userRole, exists := c.Get("user_role")
+	if !exists {
+		c.JSON(http.StatusForbidden, gin.H{"error": "User role not found"})
+		c.Abort()
+		return nil, false
+	}
+
+	roleStr, ok := userRole.(string)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid user role type"})
+		c.Abort()
+		return nil, false
+	}
+
+	user := &models.User{ID: c.GetUint("user_id"), Role: models.Role(roleStr)}
+	if groupID, ok := c.Get("user_group_id"); ok {
+		user.GroupID, _ = groupID.(*uint)
 	}
+	return user, true
 }
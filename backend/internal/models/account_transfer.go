@@ -0,0 +1,65 @@
+// internal/models/account_transfer.go
+package models
+
+import "time"
+
+// TransferStatus tracks an account transfer through approval
+type TransferStatus string
+
+const (
+	// TransferPending waits for a manager of the destination group
+	TransferPending   TransferStatus = "pending"
+	TransferApproved  TransferStatus = "approved"
+	TransferRejected  TransferStatus = "rejected"
+	TransferCancelled TransferStatus = "cancelled"
+)
+
+// AccountTransfer is a move of an account to another group. Transfers by
+// users who may transfer into the destination are approved immediately;
+// others wait for a manager of the destination.
+type AccountTransfer struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	AccountID   uint           `json:"account_id" gorm:"not null;index"`
+	Account     *TikTokAccount `json:"account,omitempty" gorm:"foreignKey:AccountID"`
+	FromGroupID uint           `json:"from_group_id" gorm:"not null"`
+	ToGroupID   uint           `json:"to_group_id" gorm:"not null"`
+	Status      TransferStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	RequestedBy uint           `json:"requested_by" gorm:"not null"`
+	Note        string         `json:"note"`
+	DecidedBy   *uint          `json:"decided_by"`
+	DecidedAt   *time.Time     `json:"decided_at"`
+	// DecisionNote is the approver's reason for approving or rejecting
+	DecisionNote string    `json:"decision_note"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TransferAccountRequest moves an account to another group, or asks for
+// the move when the user may not transfer into that group
+type TransferAccountRequest struct {
+	AccountID uint `json:"account_id" binding:"required"`
+	GroupID   uint `json:"group_id" binding:"required"`
+	// RequestApproval asks a manager of the destination group to approve
+	// the transfer when the user may not transfer into it themselves
+	RequestApproval bool   `json:"request_approval"`
+	Note            string `json:"note" binding:"max=500"`
+}
+
+// TransferDecisionRequest approves, rejects or cancels a pending transfer
+type TransferDecisionRequest struct {
+	Note string `json:"note" binding:"max=500"`
+}
+
+// AccountGroupHistory is a period during which an account belonged to a
+// group. EndedAt is nil for the current group. Rows outlive merged and
+// deleted groups so past analytics stay attributed.
+type AccountGroupHistory struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	AccountID uint       `json:"account_id" gorm:"not null;index"`
+	GroupID   uint       `json:"group_id" gorm:"not null;index"`
+	StartedAt time.Time  `json:"started_at" gorm:"not null"`
+	EndedAt   *time.Time `json:"ended_at"`
+}
+
+func (AccountGroupHistory) TableName() string {
+	return "account_group_history"
+}
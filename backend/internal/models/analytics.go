@@ -15,6 +15,12 @@ type DailyAnalytics struct {
 	TotalLikes      int64     `json:"total_likes" gorm:"default:0"`
 	VideoCount      int       `json:"video_count" gorm:"default:0"`
 	DailyUploads    int       `json:"daily_uploads" gorm:"default:0"`
+	// UploadsEstimated is set when the previous snapshot is more than a day
+	// old and DailyUploads is the whole video count change over the gap
+	UploadsEstimated bool      `json:"uploads_estimated" gorm:"default:false"`
+	// VideosDeleted is set when the video count dropped since the previous
+	// snapshot, in which case uploads cannot be derived and are left at 0
+	VideosDeleted   bool      `json:"videos_deleted" gorm:"default:false"`
 	RecordedAt      time.Time `json:"recorded_at" gorm:"autoCreateTime"`
 }
 
@@ -42,12 +48,41 @@ type DashboardResponse struct {
 	GroupStats        []GroupStats `json:"group_stats"`
 }
 
+// Attribution decides which group an account's snapshots are credited to
+type Attribution string
+
+const (
+	// AttributionHistory credits each snapshot to the group that owned the
+	// account on its date
+	AttributionHistory Attribution = "history"
+	// AttributionCurrent credits every snapshot to the account's current
+	// group, for reports on what a group owns now
+	AttributionCurrent Attribution = "current"
+)
+
 type GroupStats struct {
-	GroupID       uint   `json:"group_id"`
-	GroupName     string `json:"group_name"`
-	AccountCount  int    `json:"account_count"`
-	TotalFollowers int64 `json:"total_followers"`
-	GrowthRate    float64 `json:"growth_rate"`
+	GroupID          uint          `json:"group_id"`
+	Attribution      Attribution   `json:"attribution"`
+	GroupName        string        `json:"group_name"`
+	AccountCount     int           `json:"account_count"`
+	TotalFollowers   int64         `json:"total_followers"`
+	TotalLikes       int64         `json:"total_likes"`
+	TotalVideos      int           `json:"total_videos"`
+	MedianFollowers  float64       `json:"median_followers"`
+	MedianGrowthRate float64       `json:"median_growth_rate"`
+	GrowthRate       float64       `json:"growth_rate"`
+	Growth           []GroupGrowth `json:"growth"`
+}
+
+// GroupGrowth is the follower growth of a group over a trailing window,
+// counting only accounts that have a snapshot at the start of the window.
+// With AttributionHistory an account that joined the group during the
+// window counts from its first snapshot in the group, and one that left
+// counts until its last.
+type GroupGrowth struct {
+	Days           int     `json:"days"`
+	FollowerChange int64   `json:"follower_change"`
+	GrowthRate     float64 `json:"growth_rate"`
 }
 
 type TrendResponse struct {
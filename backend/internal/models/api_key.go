@@ -0,0 +1,120 @@
+// internal/models/api_key.go
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// APIKeyPrefix starts every API key, telling keys apart from JWTs
+const APIKeyPrefix = "tas_"
+
+// API key scopes. A key can do what its scopes allow and its owner's
+// permissions permit, never more. Each route that accepts keys names the
+// one scope it needs; the write scopes cover creating and editing only, and
+// destructive or bulk actions have scopes of their own.
+const (
+	ScopeAccountsRead     = "accounts:read"
+	ScopeAccountsWrite    = "accounts:write"
+	ScopeAccountsDelete   = "accounts:delete"
+	ScopeAccountsImport   = "accounts:import"
+	ScopeAccountsTransfer = "accounts:transfer"
+	ScopeAnalyticsRead    = "analytics:read"
+	ScopeAnalyticsWrite   = "analytics:write"
+	ScopeGroupsRead       = "groups:read"
+	ScopeGroupsWrite      = "groups:write"
+	ScopeGroupsMembers    = "groups:members"
+	ScopeGroupsAdmin      = "groups:admin"
+	ScopeJobsRead         = "jobs:read"
+	ScopeJobsWrite        = "jobs:write"
+)
+
+// APIScopePermissions maps every scope to the permission its owner needs
+// to be granted it
+var APIScopePermissions = map[string]string{
+	ScopeAccountsRead:     PermAccountRead,
+	ScopeAccountsWrite:    PermAccountUpdate,
+	ScopeAccountsDelete:   PermAccountDelete,
+	ScopeAccountsImport:   PermAccountImport,
+	ScopeAccountsTransfer: PermAccountTransfer,
+	ScopeAnalyticsRead:    PermAnalyticsRead,
+	ScopeAnalyticsWrite:   PermAccountRefresh,
+	ScopeGroupsRead:       PermGroupRead,
+	ScopeGroupsWrite:      PermGroupUpdate,
+	ScopeGroupsMembers:    PermUserAssignGroup,
+	ScopeGroupsAdmin:      PermGroupDelete,
+	ScopeJobsRead:         PermAccountRead,
+	ScopeJobsWrite:        PermAccountRefresh,
+}
+
+// APIKey is a long-lived credential for scripts, stored hashed. Prefix is
+// the start of the key, kept so users can tell their keys apart.
+type APIKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"size:100;not null"`
+	Prefix     string     `json:"prefix" gorm:"size:16;not null"`
+	KeyHash    string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	Scopes     StringList `json:"scopes" gorm:"type:json"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedBy  uint       `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// HasScope reports whether the key was granted scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Active reports whether the key may be used at now
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+type APIKeyCreateRequest struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1"`
+	// ExpiresAt is optional; keys without it stay valid until revoked
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreatedAPIKey is returned once when a key is created; Key is not stored
+// and cannot be shown again
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// StringList is a []string stored as a JSON array
+type StringList []string
+
+func (l *StringList) Scan(value interface{}) error {
+	if value == nil {
+		*l = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, l)
+}
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return nil, nil
+	}
+	return json.Marshal(l)
+}
@@ -5,6 +5,12 @@ import (
 	"time"
 )
 
+// MaxGroupDepth is how many levels groups can be nested, counting top-level
+// groups as the first
+const MaxGroupDepth = 5
+
+// Group is a team of users and the accounts they run. Groups form a tree,
+// such as region → team → squad, through ParentID.
 type Group struct {
 	ID          uint      `json:"id" gorm:"primaryKey"`
 	Name        string    `json:"name" gorm:"not null"`
@@ -13,7 +19,12 @@ type Group struct {
 	Creator     User      `json:"creator,omitempty" gorm:"foreignKey:CreatedBy"`
 	ManagedBy   *uint     `json:"managed_by"`
 	Manager     *User     `json:"manager,omitempty" gorm:"foreignKey:ManagedBy"`
+	ParentID    *uint     `json:"parent_id" gorm:"index"`
+	Parent      *Group    `json:"parent,omitempty" gorm:"foreignKey:ParentID"`
 	IsActive    bool      `json:"is_active" gorm:"default:true"`
+	// ArchivedAt is set while the group is archived: read-only and hidden
+	// from default lists
+	ArchivedAt *time.Time `json:"archived_at"`
 	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 }
@@ -22,6 +33,7 @@ type GroupCreateRequest struct {
 	Name        string `json:"name" binding:"required,min=3,max=100"`
 	Description string `json:"description" binding:"max=500"`
 	ManagedBy   *uint  `json:"managed_by"`
+	ParentID    *uint  `json:"parent_id"`
 }
 
 type GroupUpdateRequest struct {
@@ -31,6 +43,55 @@ type GroupUpdateRequest struct {
 	IsActive    *bool   `json:"is_active"`
 }
 
+// IsArchived reports whether the group is archived
+func (g *Group) IsArchived() bool {
+	return g.ArchivedAt != nil
+}
+
+// GroupMoveRequest moves a group and its subgroups under another group, or
+// to the top level when ParentID is nil
+type GroupMoveRequest struct {
+	ParentID *uint `json:"parent_id"`
+}
+
+// GroupMergeRequest merges a group into TargetGroupID. With DryRun only the
+// preview is returned.
+type GroupMergeRequest struct {
+	TargetGroupID uint `json:"target_group_id" binding:"required"`
+	DryRun        bool `json:"dry_run"`
+}
+
+type MergedAccount struct {
+	ID          uint   `json:"id"`
+	AccountName string `json:"account_name"`
+}
+
+// MergedMember is a user moving to the target group, with their role in it
+// after the merge
+type MergedMember struct {
+	UserID   uint           `json:"user_id"`
+	Username string         `json:"username"`
+	Role     MembershipRole `json:"role"`
+	NewRole  MembershipRole `json:"new_role"`
+}
+
+type MergedSubgroup struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// GroupMergePreview lists what a merge moves from the source group to the
+// target. Applied is false for a dry run.
+type GroupMergePreview struct {
+	SourceGroupID uint             `json:"source_group_id"`
+	TargetGroupID uint             `json:"target_group_id"`
+	Accounts      []MergedAccount  `json:"accounts"`
+	Members       []MergedMember   `json:"members"`
+	Subgroups     []MergedSubgroup `json:"subgroups"`
+	Jobs          int64            `json:"jobs"`
+	Applied       bool             `json:"applied"`
+}
+
 type GroupResponse struct {
 	ID          uint       `json:"id"`
 	Name        string     `json:"name"`
@@ -39,7 +100,9 @@ type GroupResponse struct {
 	CreatorName string     `json:"creator_name"`
 	ManagedBy   *uint      `json:"managed_by,omitempty"`
 	ManagerName *string    `json:"manager_name,omitempty"`
+	ParentID    *uint      `json:"parent_id,omitempty"`
 	IsActive    bool       `json:"is_active"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UserCount   int        `json:"user_count"`
 	AccountCount int       `json:"account_count"`
@@ -0,0 +1,45 @@
+// internal/models/group_membership.go
+package models
+
+import "time"
+
+// MembershipRole is what a user does in one of their groups
+type MembershipRole string
+
+const (
+	MembershipMember MembershipRole = "member"
+	// MembershipLead is the group's main manager, mirrored in
+	// Group.ManagedBy. A group has at most one lead.
+	MembershipLead MembershipRole = "lead"
+	// MembershipCoManager manages the group alongside its lead
+	MembershipCoManager MembershipRole = "co_manager"
+)
+
+// Manages reports whether the role manages the group
+func (r MembershipRole) Manages() bool {
+	return r == MembershipLead || r == MembershipCoManager
+}
+
+// GroupMembership links a user to one of the groups they work in. Users can
+// belong to several groups; User.GroupID is their primary group.
+type GroupMembership struct {
+	UserID    uint           `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	GroupID   uint           `json:"group_id" gorm:"primaryKey;autoIncrement:false"`
+	Role      MembershipRole `json:"role" gorm:"type:enum('member','lead','co_manager');not null;default:'member'"`
+	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	User      *User          `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Group     *Group         `json:"group,omitempty" gorm:"foreignKey:GroupID"`
+}
+
+type MembershipRequest struct {
+	Role MembershipRole `json:"role" binding:"required,oneof=member lead co_manager"`
+}
+
+type MembershipResponse struct {
+	UserID    uint           `json:"user_id"`
+	Username  string         `json:"username"`
+	UserRole  Role           `json:"user_role"`
+	GroupID   uint           `json:"group_id"`
+	Role      MembershipRole `json:"role"`
+	CreatedAt time.Time      `json:"created_at"`
+}
@@ -0,0 +1,71 @@
+// internal/models/group_policy.go
+package models
+
+import "time"
+
+// GroupPolicy caps what a group may hold. A nil limit or an empty list
+// places no restriction. Groups without a policy row use
+// DefaultGroupPolicy.
+type GroupPolicy struct {
+	GroupID     uint `json:"group_id" gorm:"primaryKey;autoIncrement:false"`
+	MaxAccounts *int `json:"max_accounts"`
+	// MaxOperators caps the group's plain members; leads and co-managers
+	// are not counted
+	MaxOperators *int `json:"max_operators"`
+	// AllowedLocations are the locations the group's accounts may have
+	AllowedLocations StringList `json:"allowed_locations" gorm:"type:json"`
+	// AllowedTags are the keys TikTokAccount.Tags may use
+	AllowedTags StringList `json:"allowed_tags" gorm:"type:json"`
+	// OperatorsCanCreateAccounts lets users whose account.create scope is
+	// own_group create and import accounts in the group, rather than only
+	// its managers
+	OperatorsCanCreateAccounts bool      `json:"operators_can_create_accounts"`
+	UpdatedBy                  *uint     `json:"updated_by"`
+	UpdatedAt                  time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// DefaultGroupPolicy is the unrestricted policy of a group that has none set
+func DefaultGroupPolicy(groupID uint) *GroupPolicy {
+	return &GroupPolicy{GroupID: groupID, OperatorsCanCreateAccounts: true}
+}
+
+// GroupPolicyRequest replaces a group's policy
+type GroupPolicyRequest struct {
+	MaxAccounts                *int     `json:"max_accounts" binding:"omitempty,min=0"`
+	MaxOperators               *int     `json:"max_operators" binding:"omitempty,min=0"`
+	AllowedLocations           []string `json:"allowed_locations" binding:"omitempty,dive,min=1,max=100"`
+	AllowedTags                []string `json:"allowed_tags" binding:"omitempty,dive,min=1,max=100"`
+	OperatorsCanCreateAccounts *bool    `json:"operators_can_create_accounts" binding:"required"`
+}
+
+// QuotaUsage is how much of a quota a group uses. Limit is nil when
+// unlimited.
+type QuotaUsage struct {
+	Used  int64 `json:"used"`
+	Limit *int  `json:"limit"`
+}
+
+// GroupUsage is a group's usage against each of its quotas
+type GroupUsage struct {
+	Accounts  QuotaUsage `json:"accounts"`
+	Operators QuotaUsage `json:"operators"`
+}
+
+type GroupPolicyResponse struct {
+	Policy *GroupPolicy `json:"policy"`
+	Usage  GroupUsage   `json:"usage"`
+}
+
+// ImportAccountFailure is an account that ImportAccounts could not create
+type ImportAccountFailure struct {
+	Index       int    `json:"index"`
+	AccountName string `json:"account_name"`
+	Error       string `json:"error"`
+	// Violation is set when the account broke its group's policy
+	Violation interface{} `json:"violation,omitempty"`
+}
+
+type ImportAccountsResult struct {
+	Created []TikTokAccountResponse `json:"created"`
+	Failed  []ImportAccountFailure  `json:"failed"`
+}
@@ -0,0 +1,92 @@
+// internal/models/job.go
+package models
+
+import (
+	"time"
+)
+
+type JobType string
+
+const (
+	JobTypeRefresh JobType = "refresh"
+)
+
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	JobCancelled JobStatus = "cancelled"
+)
+
+type JobItemStatus string
+
+const (
+	JobItemPending   JobItemStatus = "pending"
+	JobItemRunning   JobItemStatus = "running"
+	JobItemSucceeded JobItemStatus = "succeeded"
+	JobItemFailed    JobItemStatus = "failed"
+	JobItemCancelled JobItemStatus = "cancelled"
+)
+
+// Job is a persisted batch operation processed by background workers
+type Job struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Type       JobType    `json:"type" gorm:"type:varchar(30);not null"`
+	Status     JobStatus  `json:"status" gorm:"type:varchar(20);not null;index"`
+	CreatedBy  uint       `json:"created_by" gorm:"not null"`
+	GroupID    *uint      `json:"group_id"`
+	Total      int        `json:"total" gorm:"default:0"`
+	Succeeded  int        `json:"succeeded" gorm:"default:0"`
+	Failed     int        `json:"failed" gorm:"default:0"`
+	Cancelled  int        `json:"cancelled" gorm:"default:0"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	Items      []JobItem  `json:"items,omitempty" gorm:"foreignKey:JobID"`
+}
+
+// JobItem is the per-account unit of work within a Job
+type JobItem struct {
+	ID              uint          `json:"id" gorm:"primaryKey"`
+	JobID           uint          `json:"job_id" gorm:"not null;index"`
+	TikTokAccountID uint          `json:"tiktok_account_id" gorm:"not null"`
+	AccountName     string        `json:"account_name"`
+	Status          JobItemStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	Attempts        int           `json:"attempts" gorm:"default:0"`
+	LastError       string        `json:"last_error,omitempty" gorm:"type:text"`
+	NextAttemptAt   *time.Time    `json:"next_attempt_at,omitempty"`
+	UpdatedAt       time.Time     `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+type RefreshJobRequest struct {
+	GroupID    *uint  `json:"group_id"`
+	AccountIDs []uint `json:"account_ids"`
+}
+
+type JobResponse struct {
+	Job
+	Progress    float64   `json:"progress"`
+	FailedItems []JobItem `json:"failed_items"`
+}
+
+// ToResponse converts Job to JobResponse
+func (j *Job) ToResponse() *JobResponse {
+	response := &JobResponse{
+		Job:         *j,
+		FailedItems: []JobItem{},
+	}
+
+	if j.Total > 0 {
+		response.Progress = float64(j.Succeeded+j.Failed+j.Cancelled) / float64(j.Total) * 100
+	}
+
+	for _, item := range j.Items {
+		if item.Status == JobItemFailed {
+			response.FailedItems = append(response.FailedItems, item)
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,22 @@
+// internal/models/login_throttle.go
+package models
+
+import (
+	"time"
+)
+
+// Login throttle scopes: failures are counted per username and per client IP
+const (
+	LoginScopeUsername = "username"
+	LoginScopeIP       = "ip"
+)
+
+// LoginThrottle counts recent failed logins for one username or client IP.
+// LockedUntil is set once the failures reach the configured limit.
+type LoginThrottle struct {
+	Scope         string     `json:"scope" gorm:"primaryKey;size:16"`
+	Key           string     `json:"key" gorm:"column:throttle_key;primaryKey;size:255"`
+	Failures      int        `json:"failures" gorm:"not null"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+}
@@ -0,0 +1,41 @@
+// internal/models/refresh_token.go
+package models
+
+import (
+	"time"
+)
+
+// RefreshToken is one issued refresh token, stored hashed. Tokens rotated
+// from the same login share a FamilyID; presenting a token that was already
+// rotated (UsedAt set) revokes the whole family.
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	FamilyID  string     `json:"family_id" gorm:"size:32;not null;index"`
+	TokenHash string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	IPAddress string     `json:"ip_address"`
+	UserAgent string     `json:"user_agent"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TokenPair is what a successful login or refresh returns
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Session is an active refresh token family as shown to users. ID is the
+// family ID; LastUsedAt is when the session last rotated its refresh token.
+type Session struct {
+	ID         string    `json:"id" gorm:"column:family_id"`
+	UserID     uint      `json:"user_id"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current" gorm:"-"`
+}
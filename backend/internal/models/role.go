@@ -0,0 +1,189 @@
+// internal/models/role.go
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// PermissionScope is how far a permission reaches
+type PermissionScope string
+
+const (
+	// ScopeOwnGroup covers the groups the user belongs to in any membership
+	// role
+	ScopeOwnGroup PermissionScope = "own_group"
+	// ScopeManagedGroups also covers the groups nested below those the user
+	// leads or co-manages
+	ScopeManagedGroups PermissionScope = "managed_groups"
+	// ScopeAllGroups covers every group and resources outside any group
+	ScopeAllGroups PermissionScope = "all"
+)
+
+// Rank orders scopes from narrowest to widest; unknown scopes rank 0
+func (s PermissionScope) Rank() int {
+	switch s {
+	case ScopeOwnGroup:
+		return 1
+	case ScopeManagedGroups:
+		return 2
+	case ScopeAllGroups:
+		return 3
+	}
+	return 0
+}
+
+// Permissions checked by the policy layer
+const (
+	PermAccountRead     = "account.read"
+	PermAccountCreate   = "account.create"
+	PermAccountUpdate   = "account.update"
+	PermAccountDelete   = "account.delete"
+	PermAccountImport   = "account.import"
+	PermAccountExport   = "account.export"
+	PermAccountTransfer = "account.transfer"
+	// PermAccountRefresh fetches fresh data from TikTok, directly or as a job
+	PermAccountRefresh = "account.refresh"
+	PermAnalyticsRead  = "analytics.read"
+
+	PermGroupRead          = "group.read"
+	PermGroupCreate        = "group.create"
+	PermGroupUpdate        = "group.update"
+	PermGroupDelete        = "group.delete"
+	PermGroupAssignManager = "group.assign_manager"
+	// PermGroupPolicy sets a group's quotas and allowed account attributes
+	PermGroupPolicy = "group.policy"
+
+	PermUserRead        = "user.read"
+	PermUserCreate      = "user.create"
+	PermUserUpdate      = "user.update"
+	PermUserDelete      = "user.delete"
+	PermUserAssignGroup = "user.assign_group"
+	// PermUserAssignManager sets which manager an operator reports to
+	PermUserAssignManager = "user.assign_manager"
+	// PermUserSecurity manages other users' sessions, two-factor setup,
+	// lockouts and API keys
+	PermUserSecurity = "user.security"
+
+	// PermJobRead shows jobs submitted by other users
+	PermJobRead       = "job.read"
+	PermAuditRead     = "audit.read"
+	PermLockoutManage = "lockout.manage"
+	PermTikTokStatus  = "tiktok.status"
+	PermRoleManage    = "role.manage"
+)
+
+// AllPermissions lists every permission a role can be granted
+var AllPermissions = []string{
+	PermAccountRead, PermAccountCreate, PermAccountUpdate, PermAccountDelete,
+	PermAccountImport, PermAccountExport, PermAccountTransfer, PermAccountRefresh,
+	PermAnalyticsRead,
+	PermGroupRead, PermGroupCreate, PermGroupUpdate, PermGroupDelete, PermGroupAssignManager, PermGroupPolicy,
+	PermUserRead, PermUserCreate, PermUserUpdate, PermUserDelete, PermUserAssignGroup, PermUserAssignManager,
+	PermUserSecurity,
+	PermJobRead, PermAuditRead, PermLockoutManage, PermTikTokStatus, PermRoleManage,
+}
+
+// IsPermission reports whether name is a known permission
+func IsPermission(name string) bool {
+	for _, p := range AllPermissions {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions maps each granted permission to its scope
+type Permissions map[string]PermissionScope
+
+func (p *Permissions) Scan(value interface{}) error {
+	if value == nil {
+		*p = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, p)
+}
+
+func (p Permissions) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	return json.Marshal(p)
+}
+
+// RoleDefinition is a named set of permissions that users are assigned.
+// Builtin roles cannot be deleted, and super_admin cannot be changed.
+type RoleDefinition struct {
+	Name        string      `json:"name" gorm:"primaryKey;size:50"`
+	Description string      `json:"description" gorm:"size:255"`
+	Builtin     bool        `json:"builtin"`
+	Permissions Permissions `json:"permissions" gorm:"type:json"`
+	CreatedAt   time.Time   `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time   `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (RoleDefinition) TableName() string {
+	return "roles"
+}
+
+type RoleCreateRequest struct {
+	Name        string      `json:"name" binding:"required,min=2,max=50"`
+	Description string      `json:"description" binding:"max=255"`
+	Permissions Permissions `json:"permissions" binding:"required"`
+}
+
+type RoleUpdateRequest struct {
+	Description *string `json:"description" binding:"omitempty,max=255"`
+	// Permissions replaces the role's permissions when set
+	Permissions Permissions `json:"permissions"`
+}
+
+// Resource is what an action is performed on, for scope checks. The zero
+// Resource stands for actions not tied to a group, which any scope allows.
+type Resource struct {
+	// GroupIDs are the groups the resource belongs to
+	GroupIDs []uint
+	// Ungrouped marks a resource outside every group, which needs the all
+	// scope
+	Ungrouped bool
+}
+
+// GroupResource is a resource belonging to groupID
+func GroupResource(groupID uint) Resource {
+	return Resource{GroupIDs: []uint{groupID}}
+}
+
+// UserResource is another user as the target of an action, belonging to
+// every group they are a member of. Memberships must be loaded.
+func UserResource(user *User) Resource {
+	groupIDs := user.GroupIDs()
+	return Resource{GroupIDs: groupIDs, Ungrouped: len(groupIDs) == 0}
+}
+
+// GroupScope is the set of groups a user may perform an action in
+type GroupScope struct {
+	All      bool
+	GroupIDs []uint
+}
+
+// Contains reports whether the scope covers groupID
+func (s *GroupScope) Contains(groupID uint) bool {
+	if s.All {
+		return true
+	}
+	for _, id := range s.GroupIDs {
+		if id == groupID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+// internal/models/scheduler_run.go
+package models
+
+import (
+	"time"
+)
+
+type SchedulerRunStatus string
+
+const (
+	SchedulerRunRunning     SchedulerRunStatus = "running"
+	SchedulerRunCompleted   SchedulerRunStatus = "completed"
+	SchedulerRunInterrupted SchedulerRunStatus = "interrupted"
+	SchedulerRunFailed      SchedulerRunStatus = "failed"
+)
+
+// SchedulerRun records one pass of the background snapshot scheduler
+type SchedulerRun struct {
+	ID         uint               `json:"id" gorm:"primaryKey"`
+	StartedAt  time.Time          `json:"started_at" gorm:"not null;index"`
+	FinishedAt *time.Time         `json:"finished_at"`
+	Status     SchedulerRunStatus `json:"status" gorm:"type:varchar(20);not null"`
+	Total      int                `json:"total" gorm:"default:0"`
+	Succeeded  int                `json:"succeeded" gorm:"default:0"`
+	Failed     int                `json:"failed" gorm:"default:0"`
+	Error      string             `json:"error,omitempty" gorm:"type:text"`
+}
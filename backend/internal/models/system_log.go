@@ -0,0 +1,84 @@
+// internal/models/system_log.go
+package models
+
+import (
+	"time"
+)
+
+// Audit actions recorded in system_logs
+const (
+	AuditCreate        = "create"
+	AuditUpdate        = "update"
+	AuditDelete        = "delete"
+	AuditAssignGroup   = "assign_group"
+	AuditAssignManager = "assign_manager"
+	AuditTransfer      = "transfer"
+	AuditLogin         = "login"
+	AuditLoginFailed   = "login_failed"
+	AuditLogout        = "logout"
+	AuditTokenReuse    = "token_reuse"
+	AuditRevokeSession = "revoke_session"
+	AuditLoginLocked   = "login_locked"
+	AuditLoginUnlock   = "login_unlock"
+	AuditRevokeAPIKey  = "revoke_api_key"
+
+	AuditSetMembership    = "set_membership"
+	AuditRemoveMembership = "remove_membership"
+	AuditMoveGroup        = "move_group"
+	AuditUpdatePolicy     = "update_policy"
+	AuditArchive          = "archive"
+	AuditUnarchive        = "unarchive"
+	AuditMerge            = "merge"
+	AuditTransferRequest  = "transfer_request"
+	AuditTransferReject   = "transfer_reject"
+	AuditTransferCancel   = "transfer_cancel"
+
+	AuditTwoFactorEnable  = "two_factor_enable"
+	AuditTwoFactorDisable = "two_factor_disable"
+	AuditTwoFactorReset   = "two_factor_reset"
+)
+
+// Audited resource types
+const (
+	AuditResourceUser    = "user"
+	AuditResourceGroup   = "group"
+	AuditResourceAccount = "account"
+	AuditResourceAuth    = "auth"
+	AuditResourceSession = "session"
+	AuditResourceAPIKey  = "api_key"
+	AuditResourceRole    = "role"
+)
+
+// SystemLog is one audit trail entry: who did what to which resource.
+// Details holds the before/after diff under "changes".
+type SystemLog struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	UserID        *uint     `json:"user_id"`
+	Action        string    `json:"action" gorm:"not null"`
+	Resource      string    `json:"resource"`
+	ResourceID    *uint     `json:"resource_id"`
+	TargetUserID  *uint     `json:"target_user_id"`
+	TargetGroupID *uint     `json:"target_group_id"`
+	Details       JSON      `json:"details" gorm:"type:json"`
+	IPAddress     string    `json:"ip_address"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// AuditLogFilter selects system_logs entries; zero values match everything
+type AuditLogFilter struct {
+	UserID   *uint
+	Resource string
+	Action   string
+	From     *time.Time
+	To       *time.Time
+	// GroupIDs, when not nil, limits entries to these target groups
+	GroupIDs []uint
+	Limit    int
+	Offset   int
+}
+
+// AuditLogPage is one page of audit entries and the total matching count
+type AuditLogPage struct {
+	Logs  []SystemLog `json:"logs"`
+	Total int64       `json:"total"`
+}
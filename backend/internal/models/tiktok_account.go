@@ -34,16 +34,15 @@ type TikTokAccount struct {
 
 // TikTokData represents scraped TikTok account data
 type TikTokData struct {
-	AccountName  string    `json:"account_name"`
-	Nickname     string    `json:"nickname"`
-	UID          string    `json:"uid"`
-	Region       string    `json:"region"`
-	CreatedAt    time.Time `json:"created_at"`
-	Followers    int64     `json:"followers"`
-	Following    int64     `json:"following"`
-	Likes        int64     `json:"likes"`
-	Videos       int64     `json:"videos"`
-	DailyUploads int64     `json:"daily_uploads"`
+	AccountName string    `json:"account_name"`
+	Nickname    string    `json:"nickname"`
+	UID         string    `json:"uid"`
+	Region      string    `json:"region"`
+	CreatedAt   time.Time `json:"created_at"`
+	Followers   int64     `json:"followers"`
+	Following   int64     `json:"following"`
+	Likes       int64     `json:"likes"`
+	Videos      int64     `json:"videos"`
 }
 
 // TikTokAccountCreateRequest represents the payload for creating a new TikTok account
@@ -61,14 +60,14 @@ type TikTokAccountCreateRequest struct {
 	Tags              JSON       `json:"tags"`
 }
 
-// TikTokAccountUpdateRequest represents the payload for updating a TikTok account
+// TikTokAccountUpdateRequest represents the payload for updating a TikTok
+// account. Accounts change group only through transfers.
 type TikTokAccountUpdateRequest struct {
 	AccountName       *string    `json:"account_name"`
 	Nickname          *string    `json:"nickname"`
 	UID               *string    `json:"uid"`
 	Location          *string    `json:"location"`
 	RegistrationDate  *time.Time `json:"registration_date"`
-	GroupID           *uint      `json:"group_id"`
 	AccountOwner      *string    `json:"account_owner"`
 	ContactInfo       *string    `json:"contact_info"`
 	Notes             *string    `json:"notes"`
@@ -0,0 +1,65 @@
+// internal/models/two_factor.go
+package models
+
+import (
+	"time"
+)
+
+// TwoFactorSecret is a user's TOTP secret, encrypted at rest. It only
+// protects logins once ConfirmedAt is set by verifying a first code.
+type TwoFactorSecret struct {
+	UserID      uint       `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	Secret      string     `json:"-" gorm:"not null"`
+	ConfirmedAt *time.Time `json:"confirmed_at"`
+	// LastUsedStep is the time step of the last accepted code, so a code
+	// cannot be replayed within its validity window
+	LastUsedStep uint64    `json:"-"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// RecoveryCode is a single-use code that stands in for a TOTP code
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	CodeHash  string     `json:"-" gorm:"size:64;not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TwoFactorEnrollment is shown once when a user starts enrollment. The
+// provisioning URI is meant to be rendered as a QR code.
+type TwoFactorEnrollment struct {
+	Secret          string   `json:"secret"`
+	ProvisioningURI string   `json:"provisioning_uri"`
+	RecoveryCodes   []string `json:"recovery_codes"`
+}
+
+// TwoFactorStatus describes a user's two-factor setup
+type TwoFactorStatus struct {
+	Enabled           bool `json:"enabled"`
+	Required          bool `json:"required"`
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+}
+
+// LoginResult is either a token pair or a challenge for the second login
+// step. With EnrollmentRequired the challenge is used to enroll first.
+type LoginResult struct {
+	*TokenPair
+	TwoFactorRequired  bool   `json:"two_factor_required,omitempty"`
+	EnrollmentRequired bool   `json:"enrollment_required,omitempty"`
+	ChallengeToken     string `json:"challenge_token,omitempty"`
+}
+
+type TwoFactorChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+}
+
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	// Code is a TOTP code or an unused recovery code
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
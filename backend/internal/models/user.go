@@ -5,6 +5,8 @@ import (
 	"time"
 )
 
+// Role names a RoleDefinition. The builtin roles below always exist;
+// super admins can add more.
 type Role string
 
 const (
@@ -16,10 +18,13 @@ const (
 type User struct {
 	ID          uint      `json:"id" gorm:"primaryKey"`
 	Username    string    `json:"username" gorm:"unique;not null"`
-	Password    string    `json:"-" gorm:"not null"`
-	Role        Role      `json:"role" gorm:"type:enum('super_admin','manager','operator');default:'operator'"`
+	Password    string    `json:"-" gorm:"column:password_hash;not null"`
+	Role        Role      `json:"role" gorm:"size:50;not null;default:'operator'"`
+	// GroupID is the user's primary group; Memberships lists every group
+	// they belong to, including it
 	GroupID     *uint     `json:"group_id"`
 	Group       *Group    `json:"group,omitempty" gorm:"foreignKey:GroupID"`
+	Memberships []GroupMembership `json:"memberships,omitempty" gorm:"foreignKey:UserID"`
 	CreatedBy   *uint     `json:"created_by"`
 	Creator     *User     `json:"creator,omitempty" gorm:"foreignKey:CreatedBy"`
 	ManagedBy   *uint     `json:"managed_by"`
@@ -29,10 +34,19 @@ type User struct {
 	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
+// GroupIDs returns the groups the user belongs to, from Memberships
+func (u *User) GroupIDs() []uint {
+	ids := make([]uint, 0, len(u.Memberships))
+	for _, m := range u.Memberships {
+		ids = append(ids, m.GroupID)
+	}
+	return ids
+}
+
 type UserCreateRequest struct {
 	Username    string `json:"username" binding:"required,min=3,max=50"`
 	Password    string `json:"password" binding:"required,min=8"`
-	Role        Role   `json:"role" binding:"required,oneof=super_admin manager operator"`
+	Role        Role   `json:"role" binding:"required,max=50"`
 	GroupID     *uint  `json:"group_id"`
 	ManagedBy   *uint  `json:"managed_by"`
 }
@@ -40,7 +54,7 @@ type UserCreateRequest struct {
 type UserUpdateRequest struct {
 	Username    *string `json:"username" binding:"omitempty,min=3,max=50"`
 	Password    *string `json:"password" binding:"omitempty,min=8"`
-	Role        *Role   `json:"role" binding:"omitempty,oneof=super_admin manager operator"`
+	Role        *Role   `json:"role" binding:"omitempty,max=50"`
 	GroupID     *uint   `json:"group_id"`
 	IsActive    *bool   `json:"is_active"`
 }
@@ -51,6 +65,7 @@ type UserResponse struct {
 	Role        Role      `json:"role"`
 	GroupID     *uint     `json:"group_id,omitempty"`
 	GroupName   *string   `json:"group_name,omitempty"`
+	Memberships []GroupMembership `json:"memberships"`
 	CreatedBy   *uint     `json:"created_by,omitempty"`
 	CreatorName *string   `json:"creator_name,omitempty"`
 	ManagedBy   *uint     `json:"managed_by,omitempty"`
@@ -2,6 +2,8 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/katuhangugi/tiktok-account-system/internal/models"
 	"gorm.io/gorm"
 )
@@ -14,8 +16,23 @@ func NewAccountRepository(db *gorm.DB) *AccountRepository {
 	return &AccountRepository{db: db}
 }
 
+// WithTx returns the repository bound to the transaction tx
+func (r *AccountRepository) WithTx(tx *gorm.DB) *AccountRepository {
+	return &AccountRepository{db: tx}
+}
+
+// Create saves a new account and starts its group history
 func (r *AccountRepository) Create(account *models.TikTokAccount) error {
-	return r.db.Create(account).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(account).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.AccountGroupHistory{
+			AccountID: account.ID,
+			GroupID:   account.GroupID,
+			StartedAt: account.CreatedAt,
+		}).Error
+	})
 }
 
 func (r *AccountRepository) FindByID(id uint) (*models.TikTokAccount, error) {
@@ -42,16 +59,66 @@ func (r *AccountRepository) ListAccounts(groupID uint) ([]models.TikTokAccount,
 	return accounts, err
 }
 
+// GroupIDOf returns the group of an account without loading the rest of it
+func (r *AccountRepository) GroupIDOf(accountID uint) (uint, error) {
+	var account models.TikTokAccount
+	err := r.db.Select("id", "group_id").First(&account, accountID).Error
+	return account.GroupID, err
+}
+
+// ListAccountsInGroups returns the accounts of the given groups
+func (r *AccountRepository) ListAccountsInGroups(groupIDs []uint) ([]models.TikTokAccount, error) {
+	var accounts []models.TikTokAccount
+	if len(groupIDs) == 0 {
+		return accounts, nil
+	}
+	err := r.db.Preload("Creator").Preload("Group").Where("group_id IN ?", groupIDs).Find(&accounts).Error
+	return accounts, err
+}
+
+func (r *AccountRepository) ListActive() ([]models.TikTokAccount, error) {
+	var accounts []models.TikTokAccount
+	err := r.db.Where("is_active = ?", true).Order("id").Find(&accounts).Error
+	return accounts, err
+}
+
+// Update saves an account's details. Its group is left alone; accounts
+// change group through AccountTransferRepository.Complete or a merge so the
+// move is authorized and recorded in the group history.
 func (r *AccountRepository) Update(account *models.TikTokAccount) error {
-	return r.db.Save(account).Error
+	return r.db.Omit("group_id").Save(account).Error
 }
 
 func (r *AccountRepository) Delete(id uint) error {
 	return r.db.Delete(&models.TikTokAccount{}, id).Error
 }
 
-func (r *AccountRepository) TransferToGroup(accountID, groupID uint) error {
-	return r.db.Model(&models.TikTokAccount{}).Where("id = ?", accountID).Update("group_id", groupID).Error
+// GroupHistory returns the groups an account belonged to, oldest first
+func (r *AccountRepository) GroupHistory(accountID uint) ([]models.AccountGroupHistory, error) {
+	history := []models.AccountGroupHistory{}
+	err := r.db.Where("account_id = ?", accountID).Order("started_at, id").Find(&history).Error
+	return history, err
+}
+
+// recordGroupChange ends the current group history of accountIDs and starts
+// a new period in groupID at the given time
+func recordGroupChange(tx *gorm.DB, accountIDs []uint, groupID uint, at time.Time) error {
+	if len(accountIDs) == 0 {
+		return nil
+	}
+
+	err := tx.Model(&models.AccountGroupHistory{}).
+		Where("account_id IN ? AND ended_at IS NULL", accountIDs).
+		Update("ended_at", at).Error
+	if err != nil {
+		return err
+	}
+
+	history := make([]models.AccountGroupHistory, 0, len(accountIDs))
+	for _, accountID := range accountIDs {
+		history = append(history, models.AccountGroupHistory{AccountID: accountID, GroupID: groupID, StartedAt: at})
+	}
+	return tx.Create(&history).Error
 }
 
 func (r *AccountRepository) GetLatestAnalytics(accountID uint) (*models.DailyAnalytics, error) {
@@ -67,8 +134,139 @@ func (r *AccountRepository) GetAccountTrends(accountID uint, days int) ([]models
 	return analytics, err
 }
 
-func (r *AccountRepository) GetDashboardData(groupID uint) (*models.DashboardResponse, error) {
-	var dashboard models.DashboardResponse
-	// Implement dashboard data query
-	return &dashboard, nil
+const (
+	// dashboardTopAccounts is the number of accounts listed in TopAccounts
+	dashboardTopAccounts = 10
+	// dashboardRecentActivity is the number of snapshots listed in RecentActivity
+	dashboardRecentActivity = 20
+	// dashboardGrowthDays is the window used for RecentGrowth
+	dashboardGrowthDays = 7
+)
+
+// latestSnapshotJoin joins each account (aliased a) to its most recent
+// daily_analytics row (aliased cur).
+const latestSnapshotJoin = `LEFT JOIN daily_analytics cur ON cur.tiktok_account_id = a.id
+	AND cur.date = (SELECT MAX(d.date) FROM daily_analytics d WHERE d.tiktok_account_id = a.id)`
+
+// baselineSnapshotJoin joins each account (aliased a) to its latest
+// daily_analytics row at least ? days old, aliased as alias.
+func baselineSnapshotJoin(alias string) string {
+	return `LEFT JOIN daily_analytics ` + alias + ` ON ` + alias + `.tiktok_account_id = a.id
+	AND ` + alias + `.date = (SELECT MAX(d.date) FROM daily_analytics d
+		WHERE d.tiktok_account_id = a.id AND d.date <= DATE_SUB(CURDATE(), INTERVAL ? DAY))`
+}
+
+type dashboardTotalsRow struct {
+	TotalAccounts  int
+	TotalFollowers int64
+	TotalLikes     int64
+	TotalVideos    int
+	GrowthCurrent  int64
+	GrowthPrevious int64
+}
+
+// GetDashboardData aggregates totals, top accounts and recent activity for
+// the given groups. GroupStats is filled by GroupRepository.ListGroupStats.
+func (r *AccountRepository) GetDashboardData(groupIDs []uint) (*models.DashboardResponse, error) {
+	dashboard := &models.DashboardResponse{
+		TopAccounts:    []models.TikTokAccountResponse{},
+		RecentActivity: []models.DailyAnalytics{},
+		GroupStats:     []models.GroupStats{},
+	}
+	if len(groupIDs) == 0 {
+		return dashboard, nil
+	}
+
+	// Growth only counts accounts that have a baseline snapshot
+	var totals dashboardTotalsRow
+	err := r.db.Raw(`SELECT COUNT(a.id) AS total_accounts,
+			COALESCE(SUM(cur.follower_count), 0) AS total_followers,
+			COALESCE(SUM(cur.total_likes), 0) AS total_likes,
+			COALESCE(SUM(cur.video_count), 0) AS total_videos,
+			COALESCE(SUM(CASE WHEN prev.id IS NOT NULL THEN cur.follower_count END), 0) AS growth_current,
+			COALESCE(SUM(prev.follower_count), 0) AS growth_previous
+		FROM tiktok_accounts a
+		`+latestSnapshotJoin+`
+		`+baselineSnapshotJoin("prev")+`
+		WHERE a.group_id IN ?`, dashboardGrowthDays, groupIDs).Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	dashboard.TotalAccounts = totals.TotalAccounts
+	dashboard.TotalFollowers = totals.TotalFollowers
+	dashboard.TotalLikes = totals.TotalLikes
+	dashboard.TotalVideos = totals.TotalVideos
+	dashboard.RecentGrowth = growthRate(totals.GrowthCurrent, totals.GrowthPrevious)
+
+	if dashboard.TopAccounts, err = r.topAccounts(groupIDs, dashboardTopAccounts); err != nil {
+		return nil, err
+	}
+
+	accountIDs := r.db.Model(&models.TikTokAccount{}).Select("id").Where("group_id IN ?", groupIDs)
+	err = r.db.Where("tiktok_account_id IN (?)", accountIDs).
+		Order("recorded_at desc").Limit(dashboardRecentActivity).
+		Find(&dashboard.RecentActivity).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return dashboard, nil
+}
+
+// topAccounts returns the limit accounts with the most followers in their
+// latest snapshot, each with LatestAnalytics populated
+func (r *AccountRepository) topAccounts(groupIDs []uint, limit int) ([]models.TikTokAccountResponse, error) {
+	var ids []uint
+	err := r.db.Raw(`SELECT a.id FROM tiktok_accounts a
+		`+latestSnapshotJoin+`
+		WHERE a.group_id IN ? AND cur.id IS NOT NULL
+		ORDER BY cur.follower_count DESC, a.id
+		LIMIT ?`, groupIDs, limit).Scan(&ids).Error
+	if err != nil || len(ids) == 0 {
+		return []models.TikTokAccountResponse{}, err
+	}
+
+	var accounts []models.TikTokAccount
+	if err := r.db.Preload("Creator").Preload("Group").Where("id IN ?", ids).Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.TikTokAccount, len(accounts))
+	for i := range accounts {
+		byID[accounts[i].ID] = &accounts[i]
+	}
+
+	var latest []models.DailyAnalytics
+	err = r.db.Raw(`SELECT da.* FROM daily_analytics da
+		JOIN (SELECT tiktok_account_id, MAX(date) AS max_date FROM daily_analytics
+			WHERE tiktok_account_id IN ? GROUP BY tiktok_account_id) m
+		ON m.tiktok_account_id = da.tiktok_account_id AND m.max_date = da.date`, ids).Scan(&latest).Error
+	if err != nil {
+		return nil, err
+	}
+	latestByID := make(map[uint]*models.DailyAnalytics, len(latest))
+	for i := range latest {
+		latestByID[latest[i].TikTokAccountID] = &latest[i]
+	}
+
+	responses := make([]models.TikTokAccountResponse, 0, len(ids))
+	for _, id := range ids {
+		account, ok := byID[id]
+		if !ok {
+			continue
+		}
+		response := account.ToResponse(latestByID[id], nil)
+		response.CreatorName = account.Creator.Username
+		responses = append(responses, *response)
+	}
+
+	return responses, nil
+}
+
+// growthRate returns the percentage change from previous to current
+func growthRate(current, previous int64) float64 {
+	if previous == 0 {
+		return 0
+	}
+	return float64(current-previous) / float64(previous) * 100
 }
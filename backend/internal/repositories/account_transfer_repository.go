@@ -0,0 +1,90 @@
+// internal/repositories/account_transfer_repository.go
+package repositories
+
+import (
+	"time"
+
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"gorm.io/gorm"
+)
+
+type AccountTransferRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountTransferRepository(db *gorm.DB) *AccountTransferRepository {
+	return &AccountTransferRepository{db: db}
+}
+
+// WithTx returns the repository bound to the transaction tx
+func (r *AccountTransferRepository) WithTx(tx *gorm.DB) *AccountTransferRepository {
+	return &AccountTransferRepository{db: tx}
+}
+
+func (r *AccountTransferRepository) Create(transfer *models.AccountTransfer) error {
+	return r.db.Create(transfer).Error
+}
+
+func (r *AccountTransferRepository) FindByID(id uint) (*models.AccountTransfer, error) {
+	var transfer models.AccountTransfer
+	err := r.db.Preload("Account").First(&transfer, id).Error
+	return &transfer, err
+}
+
+// FindPending returns the transfer of an account waiting for approval
+func (r *AccountTransferRepository) FindPending(accountID uint) (*models.AccountTransfer, error) {
+	var transfer models.AccountTransfer
+	err := r.db.Where("account_id = ? AND status = ?", accountID, models.TransferPending).First(&transfer).Error
+	return &transfer, err
+}
+
+// List returns transfers newest first, optionally by status. Unless all,
+// only transfers from or to groupIDs or requested by userID are returned.
+func (r *AccountTransferRepository) List(status models.TransferStatus, all bool, groupIDs []uint, userID uint) ([]models.AccountTransfer, error) {
+	transfers := []models.AccountTransfer{}
+	query := r.db.Preload("Account").Order("created_at desc, id desc")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if !all {
+		if len(groupIDs) > 0 {
+			query = query.Where("from_group_id IN ? OR to_group_id IN ? OR requested_by = ?", groupIDs, groupIDs, userID)
+		} else {
+			query = query.Where("requested_by = ?", userID)
+		}
+	}
+	err := query.Find(&transfers).Error
+	return transfers, err
+}
+
+// Decide stores a rejected or cancelled transfer
+func (r *AccountTransferRepository) Decide(transfer *models.AccountTransfer) error {
+	return r.db.Omit("Account").Save(transfer).Error
+}
+
+// Complete stores an approved transfer and moves its account to the
+// destination group, recording the change in the account's group history.
+// It fails with gorm.ErrRecordNotFound when the account has left the
+// transfer's source group in the meantime.
+func (r *AccountTransferRepository) Complete(transfer *models.AccountTransfer) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.TikTokAccount{}).
+			Where("id = ? AND group_id = ?", transfer.AccountID, transfer.FromGroupID).
+			Update("group_id", transfer.ToGroupID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		at := time.Now()
+		if transfer.DecidedAt != nil {
+			at = *transfer.DecidedAt
+		}
+		if err := recordGroupChange(tx, []uint{transfer.AccountID}, transfer.ToGroupID, at); err != nil {
+			return err
+		}
+		return tx.Omit("Account").Save(transfer).Error
+	})
+}
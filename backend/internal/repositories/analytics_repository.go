@@ -2,6 +2,8 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/katuhangugi/tiktok-account-system/internal/models"
 	"gorm.io/gorm"
 )
@@ -18,12 +20,42 @@ func (r *AnalyticsRepository) Create(analytics *models.DailyAnalytics) error {
 	return r.db.Create(analytics).Error
 }
 
-func (r *AnalyticsRepository) GetByAccountAndDate(accountID uint, date string) (*models.DailyAnalytics, error) {
+func (r *AnalyticsRepository) GetByAccountAndDate(accountID uint, date time.Time) (*models.DailyAnalytics, error) {
+	var analytics models.DailyAnalytics
+	err := r.db.Where("tiktok_account_id = ? AND date = ?", accountID, date.Format("2006-01-02")).First(&analytics).Error
+	return &analytics, err
+}
+
+// GetPreviousSnapshot returns the account's latest snapshot dated before date
+func (r *AnalyticsRepository) GetPreviousSnapshot(accountID uint, date time.Time) (*models.DailyAnalytics, error) {
 	var analytics models.DailyAnalytics
-	err := r.db.Where("tiktok_account_id = ? AND date = ?", accountID, date).First(&analytics).Error
+	err := r.db.Where("tiktok_account_id = ? AND date < ?", accountID, date.Format("2006-01-02")).
+		Order("date desc").First(&analytics).Error
 	return &analytics, err
 }
 
+// ListByAccount returns every snapshot of the account, oldest first
+func (r *AnalyticsRepository) ListByAccount(accountID uint) ([]models.DailyAnalytics, error) {
+	var analytics []models.DailyAnalytics
+	err := r.db.Where("tiktok_account_id = ?", accountID).Order("date asc").Find(&analytics).Error
+	return analytics, err
+}
+
+// ListAccountIDs returns the IDs of all accounts that have snapshots
+func (r *AnalyticsRepository) ListAccountIDs() ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.DailyAnalytics{}).Distinct().
+		Order("tiktok_account_id").Pluck("tiktok_account_id", &ids).Error
+	return ids, err
+}
+
+// UpdateUploads saves only the derived upload fields of a snapshot
+func (r *AnalyticsRepository) UpdateUploads(analytics *models.DailyAnalytics) error {
+	return r.db.Model(analytics).
+		Select("daily_uploads", "uploads_estimated", "videos_deleted").
+		Updates(analytics).Error
+}
+
 func (r *AnalyticsRepository) Update(analytics *models.DailyAnalytics) error {
 	return r.db.Save(analytics).Error
 }
@@ -35,14 +67,33 @@ func (r *AnalyticsRepository) GetAccountTrends(accountID uint, days int) ([]mode
 	return analytics, err
 }
 
-func (r *AnalyticsRepository) GetGroupTrends(groupID uint, days int) ([]models.DailyAnalytics, error) {
+// GetGroupTrends returns the daily analytics credited to groupIDs, a single
+// group or a group with its subgroups. With AttributionHistory each snapshot
+// belongs to the group that owned the account on its date; otherwise to the
+// account's current group.
+func (r *AnalyticsRepository) GetGroupTrends(groupIDs []uint, days int, attribution models.Attribution) ([]models.DailyAnalytics, error) {
 	var analytics []models.DailyAnalytics
-	err := r.db.Joins("JOIN tiktok_accounts ON daily_analytics.tiktok_account_id = tiktok_accounts.id").
-		Where("tiktok_accounts.group_id = ? AND date >= DATE_SUB(CURDATE(), INTERVAL ? DAY)",
-			groupID, days).Order("date asc").Find(&analytics).Error
+	query := r.db.Where("daily_analytics.date >= DATE_SUB(CURDATE(), INTERVAL ? DAY)", days)
+	if attribution == models.AttributionCurrent {
+		query = query.Joins("JOIN tiktok_accounts ON daily_analytics.tiktok_account_id = tiktok_accounts.id").
+			Where("tiktok_accounts.group_id IN ?", groupIDs)
+	} else {
+		query = query.Joins("JOIN account_group_history h ON h.account_id = daily_analytics.tiktok_account_id AND "+
+			ownedOn("h", "daily_analytics.date")).
+			Where("h.group_id IN ?", groupIDs)
+	}
+	err := query.Order("daily_analytics.date asc").Find(&analytics).Error
 	return analytics, err
 }
 
+// ownedOn is the condition that the account_group_history row alias covers
+// the whole of dateExpr, a date column. When an account changes group
+// during a day, that day belongs to the group it ended the day in.
+func ownedOn(alias, dateExpr string) string {
+	return `DATE(` + alias + `.started_at) <= ` + dateExpr + ` AND (` + alias + `.ended_at IS NULL OR DATE(` +
+		alias + `.ended_at) > ` + dateExpr + `)`
+}
+
 func (r *AnalyticsRepository) GetComparisonData(accountIDs []uint, days int) ([]models.DailyAnalytics, error) {
 	var analytics []models.DailyAnalytics
 	err := r.db.Where("tiktok_account_id IN ? AND date >= DATE_SUB(CURDATE(), INTERVAL ? DAY)",
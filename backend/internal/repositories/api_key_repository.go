@@ -0,0 +1,54 @@
+// internal/repositories/api_key_repository.go
+package repositories
+
+import (
+	"time"
+
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"gorm.io/gorm"
+)
+
+// apiKeyTouchInterval limits how often last-use tracking writes to a key
+const apiKeyTouchInterval = time.Minute
+
+type APIKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func (r *APIKeyRepository) Create(key *models.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *APIKeyRepository) FindByHash(hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.Where("key_hash = ?", hash).First(&key).Error
+	return &key, err
+}
+
+// ListByUser returns the user's keys that are not revoked, newest first
+func (r *APIKeyRepository) ListByUser(userID uint) ([]models.APIKey, error) {
+	keys := []models.APIKey{}
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+// Touch records a use of the key, at most once per apiKeyTouchInterval
+func (r *APIKeyRepository) Touch(id uint, ip string, now time.Time) error {
+	return r.db.Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, now.Add(-apiKeyTouchInterval)).
+		Updates(map[string]interface{}{"last_used_at": now, "last_used_ip": ip}).Error
+}
+
+// Revoke revokes one of the user's keys, reporting whether it was active
+func (r *APIKeyRepository) Revoke(userID, id uint) (bool, error) {
+	result := r.db.Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,57 @@
+// internal/repositories/audit_repository.go
+package repositories
+
+import (
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"gorm.io/gorm"
+)
+
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+func (r *AuditRepository) Create(entry *models.SystemLog) error {
+	return r.db.Create(entry).Error
+}
+
+// List returns the entries matching filter, newest first, together with
+// the total number of matches
+func (r *AuditRepository) List(filter models.AuditLogFilter) (*models.AuditLogPage, error) {
+	query := r.db.Model(&models.SystemLog{})
+
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Resource != "" {
+		query = query.Where("resource = ?", filter.Resource)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	if filter.GroupIDs != nil {
+		if len(filter.GroupIDs) == 0 {
+			return &models.AuditLogPage{Logs: []models.SystemLog{}}, nil
+		}
+		query = query.Where("target_group_id IN ?", filter.GroupIDs)
+	}
+
+	page := &models.AuditLogPage{}
+	if err := query.Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+
+	err := query.Order("created_at DESC, id DESC").
+		Limit(filter.Limit).Offset(filter.Offset).
+		Find(&page.Logs).Error
+	return page, err
+}
@@ -0,0 +1,63 @@
+// internal/repositories/group_policy_repository.go
+package repositories
+
+import (
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GroupPolicyRepository struct {
+	db *gorm.DB
+}
+
+func NewGroupPolicyRepository(db *gorm.DB) *GroupPolicyRepository {
+	return &GroupPolicyRepository{db: db}
+}
+
+// WithTx returns the repository bound to the transaction tx
+func (r *GroupPolicyRepository) WithTx(tx *gorm.DB) *GroupPolicyRepository {
+	return &GroupPolicyRepository{db: tx}
+}
+
+// Locked runs fn in a transaction holding a lock on the group's policy row
+// (SELECT ... FOR UPDATE), so quota checks and the changes they allow in fn
+// do not interleave with others in the group. A group without a policy row
+// has no quotas to protect.
+func (r *GroupPolicyRepository) Locked(groupID uint, fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var policies []models.GroupPolicy
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("group_id = ?", groupID).Find(&policies).Error
+		if err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
+
+func (r *GroupPolicyRepository) Find(groupID uint) (*models.GroupPolicy, error) {
+	var policy models.GroupPolicy
+	err := r.db.Where("group_id = ?", groupID).First(&policy).Error
+	return &policy, err
+}
+
+// Save creates or replaces the policy of a group
+func (r *GroupPolicyRepository) Save(policy *models.GroupPolicy) error {
+	return r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(policy).Error
+}
+
+// CountAccounts returns the number of accounts in a group
+func (r *GroupPolicyRepository) CountAccounts(groupID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.TikTokAccount{}).Where("group_id = ?", groupID).Count(&count).Error
+	return count, err
+}
+
+// CountOperators returns the number of operators in a group, its plain
+// members
+func (r *GroupPolicyRepository) CountOperators(groupID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.GroupMembership{}).Where("group_id = ? AND role = ?", groupID, models.MembershipMember).
+		Count(&count).Error
+	return count, err
+}
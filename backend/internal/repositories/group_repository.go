@@ -2,6 +2,9 @@
 package repositories
 
 import (
+	"sort"
+	"time"
+
 	"github.com/katuhangugi/tiktok-account-system/internal/models"
 	"gorm.io/gorm"
 )
@@ -24,33 +27,189 @@ func (r *GroupRepository) FindByID(id uint) (*models.Group, error) {
 	return &group, err
 }
 
-func (r *GroupRepository) ListGroups(managerID uint) ([]models.Group, error) {
+// ListGroups returns every group, or with a managerID the groups that user
+// leads or co-manages. Archived groups are left out unless includeArchived.
+func (r *GroupRepository) ListGroups(managerID uint, includeArchived bool) ([]models.Group, error) {
 	var groups []models.Group
 	query := r.db.Preload("Creator").Preload("Manager")
 
 	if managerID != 0 {
-		query = query.Where("managed_by = ?", managerID)
+		managed := r.db.Model(&models.GroupMembership{}).Select("group_id").
+			Where("user_id = ? AND role IN ?", managerID, []models.MembershipRole{models.MembershipLead, models.MembershipCoManager})
+		query = query.Where("id IN (?)", managed)
+	}
+	if !includeArchived {
+		query = query.Where("archived_at IS NULL")
 	}
 
 	err := query.Find(&groups).Error
 	return groups, err
 }
 
+// ListGroupsByIDs returns the groups with the given IDs, leaving out
+// archived groups unless includeArchived
+func (r *GroupRepository) ListGroupsByIDs(groupIDs []uint, includeArchived bool) ([]models.Group, error) {
+	groups := []models.Group{}
+	if len(groupIDs) == 0 {
+		return groups, nil
+	}
+	query := r.db.Preload("Creator").Preload("Manager").Where("id IN ?", groupIDs)
+	if !includeArchived {
+		query = query.Where("archived_at IS NULL")
+	}
+	err := query.Find(&groups).Error
+	return groups, err
+}
+
 func (r *GroupRepository) Update(group *models.Group) error {
 	return r.db.Save(group).Error
 }
 
+// Delete removes a group. Its memberships and policy go with it, and users
+// left with it as their primary group, such as its lead, lose it.
 func (r *GroupRepository) Delete(id uint) error {
-	return r.db.Delete(&models.Group{}, id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("group_id = ?", id).Update("group_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Group{}, id).Error
+	})
+}
+
+// groupsTable is the quoted groups table for raw queries, as GROUPS is a
+// reserved word in MySQL 8
+const groupsTable = "`groups`"
+
+// SubtreeIDs returns the given groups and every group nested below them
+func (r *GroupRepository) SubtreeIDs(groupIDs []uint) ([]uint, error) {
+	ids := []uint{}
+	if len(groupIDs) == 0 {
+		return ids, nil
+	}
+	err := r.db.Raw(`WITH RECURSIVE subtree (id) AS (
+			SELECT id FROM `+groupsTable+` WHERE id IN ?
+			UNION
+			SELECT g.id FROM `+groupsTable+` g JOIN subtree s ON g.parent_id = s.id
+		)
+		SELECT id FROM subtree`, groupIDs).Scan(&ids).Error
+	return ids, err
+}
+
+// Depth returns the level of a group in the tree, 1 for a top-level group
+func (r *GroupRepository) Depth(groupID uint) (int, error) {
+	var depth int
+	err := r.db.Raw(`WITH RECURSIVE ancestors (id, parent_id) AS (
+			SELECT id, parent_id FROM `+groupsTable+` WHERE id = ?
+			UNION
+			SELECT g.id, g.parent_id FROM `+groupsTable+` g JOIN ancestors a ON g.id = a.parent_id
+		)
+		SELECT COUNT(*) FROM ancestors`, groupID).Scan(&depth).Error
+	return depth, err
+}
+
+// Height returns how many levels the subtree of a group spans, 1 for a
+// group without subgroups
+func (r *GroupRepository) Height(groupID uint) (int, error) {
+	var height int
+	err := r.db.Raw(`WITH RECURSIVE subtree (id, level) AS (
+			SELECT id, 1 FROM `+groupsTable+` WHERE id = ?
+			UNION ALL
+			SELECT g.id, s.level + 1 FROM `+groupsTable+` g JOIN subtree s ON g.parent_id = s.id
+		)
+		SELECT COALESCE(MAX(level), 0) FROM subtree`, groupID).Scan(&height).Error
+	return height, err
 }
 
-func (r *GroupRepository) AssignManager(groupID, managerID uint) error {
-	return r.db.Model(&models.Group{}).Where("id = ?", groupID).Update("managed_by", managerID).Error
+// SetParent moves a group, with its subtree, under parentID, or to the top
+// level when parentID is nil
+func (r *GroupRepository) SetParent(groupID uint, parentID *uint) error {
+	return r.db.Model(&models.Group{}).Where("id = ?", groupID).Update("parent_id", parentID).Error
 }
 
+// ListChildren returns the groups directly below a group
+func (r *GroupRepository) ListChildren(groupID uint) ([]models.Group, error) {
+	groups := []models.Group{}
+	err := r.db.Where("parent_id = ?", groupID).Order("name").Find(&groups).Error
+	return groups, err
+}
+
+// CountJobs returns the number of jobs submitted for a group
+func (r *GroupRepository) CountJobs(groupID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Job{}).Where("group_id = ?", groupID).Count(&count).Error
+	return count, err
+}
+
+// SetArchived archives a group at the given time, or restores it when at
+// is nil
+func (r *GroupRepository) SetArchived(groupID uint, at *time.Time) error {
+	return r.db.Model(&models.Group{}).Where("id = ?", groupID).Update("archived_at", at).Error
+}
+
+// Merge moves the accounts, members, subgroups and jobs of the source group
+// into the target in one transaction. Members keep their role unless they
+// are already in the target; the source's lead becomes a co-manager, as the
+// target keeps its own lead. The emptied source is deleted with
+// deleteSource, and archived otherwise. Audit entries keep the group they
+// were recorded against; the moved accounts' group history records the
+// merge.
+func (r *GroupRepository) Merge(sourceID, targetID uint, deleteSource bool) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var accountIDs []uint
+		if err := tx.Model(&models.TikTokAccount{}).Where("group_id = ?", sourceID).Pluck("id", &accountIDs).Error; err != nil {
+			return err
+		}
+		if err := recordGroupChange(tx, accountIDs, targetID, time.Now()); err != nil {
+			return err
+		}
+		err := tx.Model(&models.TikTokAccount{}).Where("group_id = ?", sourceID).
+			Update("group_id", targetID).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec(`INSERT INTO group_memberships (user_id, group_id, role, created_at)
+			SELECT source.user_id, ?, CASE source.role WHEN ? THEN ? ELSE source.role END, NOW()
+			FROM group_memberships source WHERE source.group_id = ?
+			ON DUPLICATE KEY UPDATE group_memberships.role = group_memberships.role`,
+			targetID, models.MembershipLead, models.MembershipCoManager, sourceID).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("group_id = ?", sourceID).Delete(&models.GroupMembership{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.User{}).Where("group_id = ?", sourceID).Update("group_id", targetID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Group{}).Where("parent_id = ?", sourceID).Update("parent_id", targetID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Job{}).Where("group_id = ?", sourceID).Update("group_id", targetID).Error; err != nil {
+			return err
+		}
+
+		if deleteSource {
+			return tx.Delete(&models.Group{}, sourceID).Error
+		}
+		return tx.Model(&models.Group{}).Where("id = ?", sourceID).
+			Updates(map[string]interface{}{"managed_by": nil, "archived_at": time.Now()}).Error
+	})
+}
+
+// CountChildren returns the number of groups directly below a group
+func (r *GroupRepository) CountChildren(groupID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Group{}).Where("parent_id = ?", groupID).Count(&count).Error
+	return count, err
+}
+
+// GetGroupUsers returns the members of a group, whatever their role in it
 func (r *GroupRepository) GetGroupUsers(groupID uint) ([]models.User, error) {
 	var users []models.User
-	err := r.db.Where("group_id = ?", groupID).Find(&users).Error
+	members := r.db.Model(&models.GroupMembership{}).Select("user_id").Where("group_id = ?", groupID)
+	err := r.db.Preload("Memberships").Where("id IN (?)", members).Find(&users).Error
 	return users, err
 }
 
@@ -60,8 +219,218 @@ func (r *GroupRepository) GetGroupAccounts(groupID uint) ([]models.TikTokAccount
 	return accounts, err
 }
 
-func (r *GroupRepository) GetGroupStats(groupID uint) (*models.GroupStats, error) {
-	var stats models.GroupStats
-	// Implement group statistics query
+// groupStatsWindows are the trailing windows, in days, reported in GroupStats.Growth
+var groupStatsWindows = []int{1, 7, 30}
+
+// groupStatsAccountRow is one account's latest snapshot plus its follower
+// counts at the start of each groupStatsWindows window (nil when missing).
+// With AttributionHistory it covers one period the account spent in the
+// group, and IsCurrent is unset once the account has left.
+type groupStatsAccountRow struct {
+	GroupID       uint
+	IsCurrent     bool
+	FollowerCount *int64
+	TotalLikes    *int64
+	VideoCount    *int
+	Followers1d   *int64 `gorm:"column:followers_1d"`
+	Followers7d   *int64 `gorm:"column:followers_7d"`
+	Followers30d  *int64 `gorm:"column:followers_30d"`
+}
+
+func (r *GroupRepository) GetGroupStats(groupID uint, attribution models.Attribution) (*models.GroupStats, error) {
+	stats, err := r.ListGroupStats([]uint{groupID}, attribution)
+	if err != nil {
+		return nil, err
+	}
+	if len(stats) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &stats[0], nil
+}
+
+// ListGroupStats computes GroupStats for each existing group in groupIDs,
+// ordered by group name. Only one row per account is read from the database.
+func (r *GroupRepository) ListGroupStats(groupIDs []uint, attribution models.Attribution) ([]models.GroupStats, error) {
+	stats := []models.GroupStats{}
+	if len(groupIDs) == 0 {
+		return stats, nil
+	}
+
+	var groups []models.Group
+	if err := r.db.Where("id IN ?", groupIDs).Order("name").Find(&groups).Error; err != nil {
+		return nil, err
+	}
+
+	rows, err := r.statsRows(groupIDs, attribution)
+	if err != nil {
+		return nil, err
+	}
+
+	byGroup := make(map[uint][]groupStatsAccountRow)
+	for _, row := range rows {
+		byGroup[row.GroupID] = append(byGroup[row.GroupID], row)
+	}
+
+	for _, group := range groups {
+		stats = append(stats, buildGroupStats(group, byGroup[group.ID], attribution))
+	}
+
+	return stats, nil
+}
+
+// GetRollupStats computes GroupStats for a group over the accounts of all
+// groupIDs, usually the group and its subgroups
+func (r *GroupRepository) GetRollupStats(groupID uint, groupIDs []uint, attribution models.Attribution) (*models.GroupStats, error) {
+	var group models.Group
+	if err := r.db.First(&group, groupID).Error; err != nil {
+		return nil, err
+	}
+
+	rows, err := r.statsRows(groupIDs, attribution)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := buildGroupStats(group, rows, attribution)
 	return &stats, nil
 }
+
+// statsRows loads a groupStatsAccountRow for every account in groupIDs, or
+// with AttributionHistory for every period an account spent in groupIDs
+// within the longest groupStatsWindows window
+func (r *GroupRepository) statsRows(groupIDs []uint, attribution models.Attribution) ([]groupStatsAccountRow, error) {
+	var rows []groupStatsAccountRow
+	if len(groupIDs) == 0 {
+		return rows, nil
+	}
+	if attribution != models.AttributionCurrent {
+		return r.historyStatsRows(groupIDs)
+	}
+	err := r.db.Raw(`SELECT a.group_id, TRUE AS is_current,
+			cur.follower_count, cur.total_likes, cur.video_count,
+			p1.follower_count AS followers_1d,
+			p7.follower_count AS followers_7d,
+			p30.follower_count AS followers_30d
+		FROM tiktok_accounts a
+		`+latestSnapshotJoin+`
+		`+baselineSnapshotJoin("p1")+`
+		`+baselineSnapshotJoin("p7")+`
+		`+baselineSnapshotJoin("p30")+`
+		WHERE a.group_id IN ?`, 1, 7, 30, groupIDs).Scan(&rows).Error
+	return rows, err
+}
+
+// historyStatsRows loads statsRows by account_group_history. A period's
+// latest snapshot is its last one in the group, and a window starting
+// before the period falls back to the period's first snapshot.
+func (r *GroupRepository) historyStatsRows(groupIDs []uint) ([]groupStatsAccountRow, error) {
+	var rows []groupStatsAccountRow
+	err := r.db.Raw(`SELECT h.group_id, h.ended_at IS NULL AS is_current,
+			cur.follower_count, cur.total_likes, cur.video_count,
+			p1.follower_count AS followers_1d,
+			p7.follower_count AS followers_7d,
+			p30.follower_count AS followers_30d
+		FROM account_group_history h
+		LEFT JOIN daily_analytics cur ON cur.tiktok_account_id = h.account_id
+			AND cur.date = (SELECT MAX(d.date) FROM daily_analytics d
+				WHERE d.tiktok_account_id = h.account_id AND `+ownedOn("h", "d.date")+`)
+		`+ownedBaselineJoin("p1")+`
+		`+ownedBaselineJoin("p7")+`
+		`+ownedBaselineJoin("p30")+`
+		WHERE h.group_id IN ? AND (h.ended_at IS NULL OR DATE(h.ended_at) > DATE_SUB(CURDATE(), INTERVAL ? DAY))`,
+		1, 1, 1, 7, 7, 7, 30, 30, 30, groupIDs, 30).Scan(&rows).Error
+	return rows, err
+}
+
+// ownedBaselineJoin joins each account_group_history period (aliased h)
+// that reaches into the last ? days to its latest snapshot in the group at
+// least ? days old, or else its first snapshot in the group within the
+// last ? days, aliased as alias.
+func ownedBaselineJoin(alias string) string {
+	return `LEFT JOIN daily_analytics ` + alias + ` ON ` + alias + `.tiktok_account_id = h.account_id
+		AND (h.ended_at IS NULL OR DATE(h.ended_at) > DATE_SUB(CURDATE(), INTERVAL ? DAY))
+		AND ` + alias + `.date = COALESCE(
+			(SELECT MAX(d.date) FROM daily_analytics d WHERE d.tiktok_account_id = h.account_id
+				AND d.date <= DATE_SUB(CURDATE(), INTERVAL ? DAY) AND ` + ownedOn("h", "d.date") + `),
+			(SELECT MIN(d.date) FROM daily_analytics d WHERE d.tiktok_account_id = h.account_id
+				AND d.date > DATE_SUB(CURDATE(), INTERVAL ? DAY) AND ` + ownedOn("h", "d.date") + `))`
+}
+
+// buildGroupStats computes GroupStats from rows. Totals and medians cover
+// the accounts the group holds now; growth covers every row.
+func buildGroupStats(group models.Group, rows []groupStatsAccountRow, attribution models.Attribution) models.GroupStats {
+	stats := models.GroupStats{
+		GroupID:     group.ID,
+		Attribution: attribution,
+		GroupName:   group.Name,
+		Growth:      make([]models.GroupGrowth, 0, len(groupStatsWindows)),
+	}
+
+	var followers, growthRates []float64
+	for _, row := range rows {
+		if !row.IsCurrent {
+			continue
+		}
+		stats.AccountCount++
+		if row.FollowerCount == nil {
+			continue
+		}
+		stats.TotalFollowers += *row.FollowerCount
+		stats.TotalLikes += *row.TotalLikes
+		stats.TotalVideos += *row.VideoCount
+		followers = append(followers, float64(*row.FollowerCount))
+		if row.Followers7d != nil && *row.Followers7d > 0 {
+			growthRates = append(growthRates, growthRate(*row.FollowerCount, *row.Followers7d))
+		}
+	}
+	stats.MedianFollowers = median(followers)
+	stats.MedianGrowthRate = median(growthRates)
+
+	for _, days := range groupStatsWindows {
+		var current, previous int64
+		for _, row := range rows {
+			baseline := row.baseline(days)
+			if row.FollowerCount == nil || baseline == nil {
+				continue
+			}
+			current += *row.FollowerCount
+			previous += *baseline
+		}
+		window := models.GroupGrowth{
+			Days:           days,
+			FollowerChange: current - previous,
+			GrowthRate:     growthRate(current, previous),
+		}
+		if days == 7 {
+			stats.GrowthRate = window.GrowthRate
+		}
+		stats.Growth = append(stats.Growth, window)
+	}
+
+	return stats
+}
+
+func (row groupStatsAccountRow) baseline(days int) *int64 {
+	switch days {
+	case 1:
+		return row.Followers1d
+	case 7:
+		return row.Followers7d
+	case 30:
+		return row.Followers30d
+	}
+	return nil
+}
+
+// median returns the median of values, or 0 when empty. values is sorted in place.
+func median(values []float64) float64 {
+	n := len(values)
+	if n == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
@@ -0,0 +1,149 @@
+// internal/repositories/job_repository.go
+package repositories
+
+import (
+	"time"
+
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type JobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// Create inserts the job together with its items
+func (r *JobRepository) Create(job *models.Job) error {
+	return r.db.Create(job).Error
+}
+
+func (r *JobRepository) FindByID(id uint) (*models.Job, error) {
+	var job models.Job
+	err := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).First(&job, id).Error
+	return &job, err
+}
+
+// ClaimNextItem atomically picks the oldest due pending item of an active
+// job, marks it running and counts the attempt. It returns
+// gorm.ErrRecordNotFound when there is nothing to do.
+func (r *JobRepository) ClaimNextItem() (*models.JobItem, error) {
+	var item models.JobItem
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{
+			Strength: "UPDATE",
+			Table:    clause.Table{Name: "job_items"},
+			Options:  "SKIP LOCKED",
+		}).
+			Joins("JOIN jobs ON jobs.id = job_items.job_id").
+			Where("job_items.status = ? AND jobs.status IN ?", models.JobItemPending,
+				[]models.JobStatus{models.JobQueued, models.JobRunning}).
+			Where("job_items.next_attempt_at IS NULL OR job_items.next_attempt_at <= ?", now).
+			Order("job_items.id").
+			First(&item).Error
+		if err != nil {
+			return err
+		}
+
+		item.Status = models.JobItemRunning
+		item.Attempts++
+		if err := tx.Save(&item).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Job{}).
+			Where("id = ? AND status = ?", item.JobID, models.JobQueued).
+			Updates(map[string]interface{}{"status": models.JobRunning, "started_at": now}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *JobRepository) UpdateItem(item *models.JobItem) error {
+	return r.db.Save(item).Error
+}
+
+// RefreshProgress recomputes the job counters from its items and marks the
+// job completed once no item is pending or running
+func (r *JobRepository) RefreshProgress(jobID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var job models.Job
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&job, jobID).Error; err != nil {
+			return err
+		}
+
+		var counts []struct {
+			Status models.JobItemStatus
+			Count  int
+		}
+		err := tx.Model(&models.JobItem{}).Select("status, COUNT(*) AS count").
+			Where("job_id = ?", jobID).Group("status").Scan(&counts).Error
+		if err != nil {
+			return err
+		}
+
+		job.Succeeded, job.Failed, job.Cancelled = 0, 0, 0
+		open := 0
+		for _, c := range counts {
+			switch c.Status {
+			case models.JobItemSucceeded:
+				job.Succeeded = c.Count
+			case models.JobItemFailed:
+				job.Failed = c.Count
+			case models.JobItemCancelled:
+				job.Cancelled = c.Count
+			default:
+				open += c.Count
+			}
+		}
+
+		if open == 0 && job.FinishedAt == nil {
+			now := time.Now()
+			job.FinishedAt = &now
+			if job.Status != models.JobCancelled {
+				job.Status = models.JobCompleted
+			}
+		}
+
+		return tx.Omit("Items").Save(&job).Error
+	})
+}
+
+// Cancel marks the job cancelled and cancels its pending items. Items that
+// are already running are allowed to finish.
+func (r *JobRepository) Cancel(jobID uint) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Job{}).
+			Where("id = ? AND status IN ?", jobID, []models.JobStatus{models.JobQueued, models.JobRunning}).
+			Update("status", models.JobCancelled).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.JobItem{}).
+			Where("job_id = ? AND status = ?", jobID, models.JobItemPending).
+			Update("status", models.JobItemCancelled).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.RefreshProgress(jobID)
+}
+
+// RequeueRunning returns items left running by a crashed worker to pending
+func (r *JobRepository) RequeueRunning() (int64, error) {
+	result := r.db.Model(&models.JobItem{}).
+		Where("status = ?", models.JobItemRunning).
+		Update("status", models.JobItemPending)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,59 @@
+// internal/repositories/login_throttle_repository.go
+package repositories
+
+import (
+	"time"
+
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"gorm.io/gorm"
+)
+
+type LoginThrottleRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginThrottleRepository(db *gorm.DB) *LoginThrottleRepository {
+	return &LoginThrottleRepository{db: db}
+}
+
+func (r *LoginThrottleRepository) Find(scope, key string) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	err := r.db.Where("scope = ? AND throttle_key = ?", scope, key).First(&throttle).Error
+	return &throttle, err
+}
+
+// RecordFailure counts a failed login at now and returns the updated
+// counter. Failures before windowStart are forgotten, and so is a lockout
+// that has run out.
+func (r *LoginThrottleRepository) RecordFailure(scope, key string, now, windowStart time.Time) (*models.LoginThrottle, error) {
+	err := r.db.Exec(`INSERT INTO login_throttles (scope, throttle_key, failures, last_failure_at)
+VALUES (?, ?, 1, ?)
+ON DUPLICATE KEY UPDATE
+    failures = IF(last_failure_at < ? OR locked_until <= ?, 1, failures + 1),
+    locked_until = IF(locked_until <= ?, NULL, locked_until),
+    last_failure_at = ?`,
+		scope, key, now, windowStart, now, now, now).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.Find(scope, key)
+}
+
+func (r *LoginThrottleRepository) Lock(scope, key string, until time.Time) error {
+	return r.db.Model(&models.LoginThrottle{}).
+		Where("scope = ? AND throttle_key = ?", scope, key).
+		Update("locked_until", until).Error
+}
+
+// Reset clears the counter, reporting whether there was one
+func (r *LoginThrottleRepository) Reset(scope, key string) (bool, error) {
+	result := r.db.Where("scope = ? AND throttle_key = ?", scope, key).Delete(&models.LoginThrottle{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ListLocked returns the counters locked at now, latest lockout first
+func (r *LoginThrottleRepository) ListLocked(now time.Time) ([]models.LoginThrottle, error) {
+	var throttles []models.LoginThrottle
+	err := r.db.Where("locked_until > ?", now).Order("locked_until DESC").Find(&throttles).Error
+	return throttles, err
+}
@@ -0,0 +1,92 @@
+// internal/repositories/membership_repository.go
+package repositories
+
+import (
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MembershipRepository struct {
+	db *gorm.DB
+}
+
+func NewMembershipRepository(db *gorm.DB) *MembershipRepository {
+	return &MembershipRepository{db: db}
+}
+
+// WithTx returns the repository bound to the transaction tx
+func (r *MembershipRepository) WithTx(tx *gorm.DB) *MembershipRepository {
+	return &MembershipRepository{db: tx}
+}
+
+func (r *MembershipRepository) ListByUser(userID uint) ([]models.GroupMembership, error) {
+	memberships := []models.GroupMembership{}
+	err := r.db.Where("user_id = ?", userID).Find(&memberships).Error
+	return memberships, err
+}
+
+func (r *MembershipRepository) ListByGroup(groupID uint) ([]models.GroupMembership, error) {
+	memberships := []models.GroupMembership{}
+	err := r.db.Preload("User").Where("group_id = ?", groupID).Order("role, user_id").Find(&memberships).Error
+	return memberships, err
+}
+
+func (r *MembershipRepository) Find(userID, groupID uint) (*models.GroupMembership, error) {
+	var membership models.GroupMembership
+	err := r.db.Where("user_id = ? AND group_id = ?", userID, groupID).First(&membership).Error
+	return &membership, err
+}
+
+// Set adds the user to the group or changes their role in it, keeping
+// groups.managed_by pointing at the lead. A new lead replaces the previous
+// one, who stays in the group as a member.
+func (r *MembershipRepository) Set(userID, groupID uint, role models.MembershipRole) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if role == models.MembershipLead {
+			err := tx.Model(&models.GroupMembership{}).
+				Where("group_id = ? AND role = ? AND user_id <> ?", groupID, models.MembershipLead, userID).
+				Update("role", models.MembershipMember).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		membership := &models.GroupMembership{UserID: userID, GroupID: groupID, Role: role}
+		err := tx.Clauses(clause.OnConflict{DoUpdates: clause.AssignmentColumns([]string{"role"})}).
+			Create(membership).Error
+		if err != nil {
+			return err
+		}
+
+		if role == models.MembershipLead {
+			return tx.Model(&models.Group{}).Where("id = ?", groupID).Update("managed_by", userID).Error
+		}
+		return clearLead(tx, userID, groupID)
+	})
+}
+
+// Remove takes the user out of the group, clearing it as their primary
+// group or their lead position. It reports whether they were a member.
+func (r *MembershipRepository) Remove(userID, groupID uint) (bool, error) {
+	removed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND group_id = ?", userID, groupID).Delete(&models.GroupMembership{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		removed = true
+
+		if err := clearLead(tx, userID, groupID); err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("id = ? AND group_id = ?", userID, groupID).
+			Update("group_id", nil).Error
+	})
+	return removed, err
+}
+
+func clearLead(tx *gorm.DB, userID, groupID uint) error {
+	return tx.Model(&models.Group{}).Where("id = ? AND managed_by = ?", groupID, userID).
+		Update("managed_by", nil).Error
+}
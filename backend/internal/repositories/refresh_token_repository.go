@@ -0,0 +1,86 @@
+// internal/repositories/refresh_token_repository.go
+package repositories
+
+import (
+	"time"
+
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"gorm.io/gorm"
+)
+
+type RefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+func (r *RefreshTokenRepository) Create(token *models.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *RefreshTokenRepository) FindByHash(hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.Where("token_hash = ?", hash).First(&token).Error
+	return &token, err
+}
+
+// MarkUsed flags the token as rotated. It reports false when another
+// request already used or revoked it, so concurrent reuse is caught.
+func (r *RefreshTokenRepository) MarkUsed(id uint) (bool, error) {
+	result := r.db.Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+// IsFamilyActive reports whether the family's current token is still
+// unused, unrevoked and unexpired
+func (r *RefreshTokenRepository) IsFamilyActive(familyID string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND used_at IS NULL AND revoked_at IS NULL AND expires_at > ?", familyID, time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ListSessions returns the user's active sessions, most recently used first.
+// IP address and user agent are those of the latest refresh.
+func (r *RefreshTokenRepository) ListSessions(userID uint) ([]models.Session, error) {
+	sessions := []models.Session{}
+	err := r.db.Raw(`SELECT t.family_id, t.user_id, t.ip_address, t.user_agent,
+			f.started_at AS created_at, t.created_at AS last_used_at, t.expires_at
+		FROM refresh_tokens t
+		JOIN (
+			SELECT family_id, MIN(created_at) AS started_at
+			FROM refresh_tokens
+			WHERE user_id = ?
+			GROUP BY family_id
+		) f ON f.family_id = t.family_id
+		WHERE t.user_id = ? AND t.used_at IS NULL AND t.revoked_at IS NULL AND t.expires_at > ?
+		ORDER BY t.created_at DESC`, userID, userID, time.Now()).Scan(&sessions).Error
+	return sessions, err
+}
+
+func (r *RefreshTokenRepository) RevokeFamily(familyID string) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeUserFamily revokes one session of the user. It reports false when
+// the user has no active session with that ID.
+func (r *RefreshTokenRepository) RevokeUserFamily(userID uint, familyID string) (bool, error) {
+	result := r.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND family_id = ? AND revoked_at IS NULL", userID, familyID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// RevokeUser revokes every token family of the user
+func (r *RefreshTokenRepository) RevokeUser(userID uint) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
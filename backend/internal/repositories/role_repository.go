@@ -0,0 +1,39 @@
+// internal/repositories/role_repository.go
+package repositories
+
+import (
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"gorm.io/gorm"
+)
+
+type RoleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+func (r *RoleRepository) List() ([]models.RoleDefinition, error) {
+	roles := []models.RoleDefinition{}
+	err := r.db.Order("builtin DESC, name").Find(&roles).Error
+	return roles, err
+}
+
+func (r *RoleRepository) FindByName(name string) (*models.RoleDefinition, error) {
+	var role models.RoleDefinition
+	err := r.db.Where("name = ?", name).First(&role).Error
+	return &role, err
+}
+
+func (r *RoleRepository) Create(role *models.RoleDefinition) error {
+	return r.db.Create(role).Error
+}
+
+func (r *RoleRepository) Update(role *models.RoleDefinition) error {
+	return r.db.Save(role).Error
+}
+
+func (r *RoleRepository) Delete(name string) error {
+	return r.db.Where("name = ?", name).Delete(&models.RoleDefinition{}).Error
+}
@@ -0,0 +1,29 @@
+// internal/repositories/scheduler_run_repository.go
+package repositories
+
+import (
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"gorm.io/gorm"
+)
+
+type SchedulerRunRepository struct {
+	db *gorm.DB
+}
+
+func NewSchedulerRunRepository(db *gorm.DB) *SchedulerRunRepository {
+	return &SchedulerRunRepository{db: db}
+}
+
+func (r *SchedulerRunRepository) Create(run *models.SchedulerRun) error {
+	return r.db.Create(run).Error
+}
+
+func (r *SchedulerRunRepository) Update(run *models.SchedulerRun) error {
+	return r.db.Save(run).Error
+}
+
+func (r *SchedulerRunRepository) ListRecent(limit int) ([]models.SchedulerRun, error) {
+	var runs []models.SchedulerRun
+	err := r.db.Order("started_at desc").Limit(limit).Find(&runs).Error
+	return runs, err
+}
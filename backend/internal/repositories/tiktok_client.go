@@ -0,0 +1,19 @@
+// internal/repositories/tiktok_client.go
+package repositories
+
+import (
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+)
+
+// TikTokClientInterface defines the contract for TikTok data sources
+type TikTokClientInterface interface {
+	GetAccountData(accountName string) (*models.TikTokData, error)
+	ValidateAccount(accountName string) (bool, error)
+	GetStatus() (string, error)
+}
+
+// GroupAwareTikTokClient is implemented by clients that can use a
+// different data source for each group
+type GroupAwareTikTokClient interface {
+	GetGroupAccountData(groupID uint, accountName string) (*models.TikTokData, error)
+}
@@ -0,0 +1,84 @@
+// internal/repositories/two_factor_repository.go
+package repositories
+
+import (
+	"time"
+
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"gorm.io/gorm"
+)
+
+type TwoFactorRepository struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorRepository(db *gorm.DB) *TwoFactorRepository {
+	return &TwoFactorRepository{db: db}
+}
+
+func (r *TwoFactorRepository) FindSecret(userID uint) (*models.TwoFactorSecret, error) {
+	var secret models.TwoFactorSecret
+	err := r.db.Where("user_id = ?", userID).First(&secret).Error
+	return &secret, err
+}
+
+// SaveEnrollment replaces the user's secret and recovery codes
+func (r *TwoFactorRepository) SaveEnrollment(secret *models.TwoFactorSecret, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := deleteTwoFactor(tx, secret.UserID); err != nil {
+			return err
+		}
+		if err := tx.Create(secret).Error; err != nil {
+			return err
+		}
+
+		codes := make([]models.RecoveryCode, len(codeHashes))
+		for i, hash := range codeHashes {
+			codes[i] = models.RecoveryCode{UserID: secret.UserID, CodeHash: hash}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseStep records that the code for step was accepted, confirming a pending
+// enrollment. It reports false when that step or a later one was already
+// used, which means the code is being replayed.
+func (r *TwoFactorRepository) UseStep(userID uint, step uint64) (bool, error) {
+	result := r.db.Model(&models.TwoFactorSecret{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Updates(map[string]interface{}{
+			"last_used_step": step,
+			"confirmed_at":   gorm.Expr("COALESCE(confirmed_at, ?)", time.Now()),
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// UseRecoveryCode spends an unused recovery code, reporting whether one matched
+func (r *TwoFactorRepository) UseRecoveryCode(userID uint, codeHash string) (bool, error) {
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *TwoFactorRepository) CountRecoveryCodes(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// Delete removes the user's secret and recovery codes
+func (r *TwoFactorRepository) Delete(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return deleteTwoFactor(tx, userID)
+	})
+}
+
+func deleteTwoFactor(tx *gorm.DB, userID uint) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("user_id = ?", userID).Delete(&models.TwoFactorSecret{}).Error
+}
@@ -4,6 +4,7 @@ package repositories
 import (
 	"github.com/katuhangugi/tiktok-account-system/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository struct {
@@ -14,32 +15,46 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+// WithTx returns the repository bound to the transaction tx
+func (r *UserRepository) WithTx(tx *gorm.DB) *UserRepository {
+	return &UserRepository{db: tx}
+}
+
 func (r *UserRepository) Create(user *models.User) error {
 	return r.db.Create(user).Error
 }
 
 func (r *UserRepository) FindByID(id uint) (*models.User, error) {
 	var user models.User
-	err := r.db.Preload("Group").Preload("Creator").Preload("Manager").First(&user, id).Error
+	err := r.db.Preload("Group").Preload("Memberships").Preload("Creator").Preload("Manager").First(&user, id).Error
 	return &user, err
 }
 
 func (r *UserRepository) FindByUsername(username string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("username = ?", username).First(&user).Error
+	err := r.db.Preload("Memberships").Where("username = ?", username).First(&user).Error
 	return &user, err
 }
 
-func (r *UserRepository) ListUsers(role string, groupID uint, managerID uint) ([]models.User, error) {
+// ListUsers returns the users matching the filters that are members of a
+// group in scope
+func (r *UserRepository) ListUsers(role string, groupID uint, managerID uint, scope *models.GroupScope) ([]models.User, error) {
 	var users []models.User
-	query := r.db.Preload("Group").Preload("Creator").Preload("Manager")
+	if !scope.All && len(scope.GroupIDs) == 0 {
+		return users, nil
+	}
+	query := r.db.Preload("Group").Preload("Memberships").Preload("Creator").Preload("Manager")
+
+	if !scope.All {
+		query = query.Where("id IN (?)", r.db.Model(&models.GroupMembership{}).Select("user_id").Where("group_id IN ?", scope.GroupIDs))
+	}
 
 	if role != "" {
 		query = query.Where("role = ?", role)
 	}
 
 	if groupID != 0 {
-		query = query.Where("group_id = ?", groupID)
+		query = query.Where("id IN (?)", r.db.Model(&models.GroupMembership{}).Select("user_id").Where("group_id = ?", groupID))
 	}
 
 	if managerID != 0 {
@@ -58,8 +73,31 @@ func (r *UserRepository) Delete(id uint) error {
 	return r.db.Delete(&models.User{}, id).Error
 }
 
+// AssignToGroup makes groupID the user's primary group and adds them to it.
+// Their plain membership of the previous primary group is dropped; groups
+// they lead or co-manage are kept.
 func (r *UserRepository) AssignToGroup(userID, groupID uint) error {
-	return r.db.Model(&models.User{}).Where("id = ?", userID).Update("group_id", groupID).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Select("id", "group_id").First(&user, userID).Error; err != nil {
+			return err
+		}
+
+		if user.GroupID != nil && *user.GroupID != groupID {
+			err := tx.Where("user_id = ? AND group_id = ? AND role = ?", userID, *user.GroupID, models.MembershipMember).
+				Delete(&models.GroupMembership{}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		membership := &models.GroupMembership{UserID: userID, GroupID: groupID, Role: models.MembershipMember}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(membership).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.User{}).Where("id = ?", userID).Update("group_id", groupID).Error
+	})
 }
 
 func (r *UserRepository) AssignManager(userID, managerID uint) error {
@@ -72,31 +110,12 @@ func (r *UserRepository) CountByRole(role string) (int64, error) {
 	return count, err
 }
 
-func (r *UserRepository) CanDeleteUser(deleterID, targetID uint) (bool, error) {
-	var deleter, target models.User
-	if err := r.db.First(&deleter, deleterID).Error; err != nil {
-		return false, err
-	}
-
-	if err := r.db.First(&target, targetID).Error; err != nil {
-		return false, err
-	}
-
-	// Cannot delete self
-	if deleter.ID == target.ID {
-		return false, nil
-	}
-
-	// Super admin can delete anyone except other super admins
-	if deleter.Role == models.RoleSuperAdmin {
-		return target.Role != models.RoleSuperAdmin || deleterID == 1, nil
-	}
-
-	// Managers can only delete operators they created
-	if deleter.Role == models.RoleManager {
-		return target.Role == models.RoleOperator && target.CreatedBy != nil && *target.CreatedBy == deleter.ID, nil
+// CountActiveByRoles returns the number of active users holding any of roles
+func (r *UserRepository) CountActiveByRoles(roles []models.Role) (int64, error) {
+	var count int64
+	if len(roles) == 0 {
+		return 0, nil
 	}
-
-	// Operators cannot delete anyone
-	return false, nil
+	err := r.db.Model(&models.User{}).Where("role IN ? AND is_active = ?", roles, true).Count(&count).Error
+	return count, err
 }
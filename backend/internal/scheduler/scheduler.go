@@ -0,0 +1,206 @@
+// internal/scheduler/scheduler.go
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/katuhangugi/tiktok-account-system/internal/config"
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"github.com/katuhangugi/tiktok-account-system/internal/repositories"
+	"github.com/katuhangugi/tiktok-account-system/pkg/cron"
+	"github.com/katuhangugi/tiktok-account-system/pkg/logger"
+)
+
+// AccountFetcher fetches and stores a snapshot for a single account
+type AccountFetcher interface {
+	FetchAccountData(account *models.TikTokAccount) error
+}
+
+// Scheduler periodically snapshots every active TikTok account
+type Scheduler struct {
+	cfg         config.SchedulerConfig
+	schedule    cron.Schedule
+	accountRepo *repositories.AccountRepository
+	runRepo     *repositories.SchedulerRunRepository
+	fetcher     AccountFetcher
+	log         *logger.Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates a scheduler; call Start to begin running it
+func New(cfg config.SchedulerConfig, accountRepo *repositories.AccountRepository,
+	runRepo *repositories.SchedulerRunRepository, fetcher AccountFetcher, log *logger.Logger) (*Scheduler, error) {
+	schedule, err := cron.Parse(cfg.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %v", cfg.Schedule, err)
+	}
+
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+
+	return &Scheduler{
+		cfg:         cfg,
+		schedule:    schedule,
+		accountRepo: accountRepo,
+		runRepo:     runRepo,
+		fetcher:     fetcher,
+		log:         log,
+	}, nil
+}
+
+// Start runs the scheduling loop in the background
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go s.loop(ctx)
+}
+
+// Stop cancels the loop and waits for an in-progress run to wind down,
+// giving up when ctx expires
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context) {
+	defer close(s.done)
+
+	for {
+		next := s.schedule.Next(time.Now())
+		if next.IsZero() {
+			s.log.Warnf("Scheduler: schedule %q has no future activation, stopping", s.cfg.Schedule)
+			return
+		}
+		s.log.Infof("Scheduler: next snapshot run at %s", next.Format(time.RFC3339))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if _, err := s.RunOnce(ctx); err != nil {
+			s.log.Errorf("Scheduler: snapshot run failed: %v", err)
+		}
+	}
+}
+
+// RunOnce snapshots every active account and records the run history.
+// Cancelling ctx stops dispatching new accounts; in-flight fetches finish.
+func (s *Scheduler) RunOnce(ctx context.Context) (*models.SchedulerRun, error) {
+	run := &models.SchedulerRun{
+		StartedAt: time.Now(),
+		Status:    models.SchedulerRunRunning,
+	}
+	if err := s.runRepo.Create(run); err != nil {
+		return nil, fmt.Errorf("failed to record scheduler run: %v", err)
+	}
+
+	accounts, err := s.accountRepo.ListActive()
+	if err != nil {
+		s.finish(run, models.SchedulerRunFailed, err)
+		return run, err
+	}
+	run.Total = len(accounts)
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	jobs := make(chan *models.TikTokAccount)
+	for i := 0; i < s.cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for account := range jobs {
+				if !s.sleepJitter(ctx) {
+					continue
+				}
+
+				err := s.fetcher.FetchAccountData(account)
+
+				mu.Lock()
+				if err != nil {
+					run.Failed++
+					s.log.Warnf("Scheduler: failed to snapshot account %d (%s): %v", account.ID, account.AccountName, err)
+				} else {
+					run.Succeeded++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+dispatch:
+	for i := range accounts {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case jobs <- &accounts[i]:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		s.finish(run, models.SchedulerRunInterrupted, errors.New("stopped during shutdown"))
+	} else {
+		s.finish(run, models.SchedulerRunCompleted, nil)
+	}
+
+	s.log.Infof("Scheduler: run %d %s, %d/%d succeeded, %d failed",
+		run.ID, run.Status, run.Succeeded, run.Total, run.Failed)
+	return run, nil
+}
+
+// sleepJitter waits a random delay up to the configured jitter. It returns
+// false if ctx is cancelled first.
+func (s *Scheduler) sleepJitter(ctx context.Context) bool {
+	if s.cfg.Jitter <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(time.Duration(rand.Int63n(int64(s.cfg.Jitter))))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (s *Scheduler) finish(run *models.SchedulerRun, status models.SchedulerRunStatus, err error) {
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	run.Status = status
+	if err != nil {
+		run.Error = err.Error()
+	}
+
+	if err := s.runRepo.Update(run); err != nil {
+		s.log.Errorf("Scheduler: failed to record run %d: %v", run.ID, err)
+	}
+}
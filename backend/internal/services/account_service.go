@@ -7,42 +7,48 @@ import (
 
 	"github.com/katuhangugi/tiktok-account-system/internal/models"
 	"github.com/katuhangugi/tiktok-account-system/internal/repositories"
+	"gorm.io/gorm"
 )
 
 type AccountService struct {
-	accountRepo *repositories.AccountRepository
-	userRepo    *repositories.UserRepository
-	groupRepo   *repositories.GroupRepository
-	tikTokRepo  *repositories.TikTokRepository
+	accountRepo   *repositories.AccountRepository
+	transferRepo  *repositories.AccountTransferRepository
+	userRepo      *repositories.UserRepository
+	groupRepo     *repositories.GroupRepository
+	tikTokRepo    *repositories.TikTokRepository
+	policy        *PolicyService
+	groupPolicies *GroupPolicyService
+	audit         *AuditService
 }
 
-func NewAccountService(accountRepo *repositories.AccountRepository, userRepo *repositories.UserRepository,
-	groupRepo *repositories.GroupRepository, tikTokRepo *repositories.TikTokRepository) *AccountService {
+func NewAccountService(accountRepo *repositories.AccountRepository, transferRepo *repositories.AccountTransferRepository,
+	userRepo *repositories.UserRepository, groupRepo *repositories.GroupRepository, tikTokRepo *repositories.TikTokRepository,
+	policy *PolicyService, groupPolicies *GroupPolicyService, audit *AuditService) *AccountService {
 	return &AccountService{
-		accountRepo: accountRepo,
-		userRepo:    userRepo,
-		groupRepo:   groupRepo,
-		tikTokRepo:  tikTokRepo,
+		accountRepo:   accountRepo,
+		transferRepo:  transferRepo,
+		userRepo:      userRepo,
+		groupRepo:     groupRepo,
+		tikTokRepo:    tikTokRepo,
+		policy:        policy,
+		groupPolicies: groupPolicies,
+		audit:         audit,
 	}
 }
 
-func (s *AccountService) CreateAccount(userID uint, req *models.TikTokAccountCreateRequest) (*models.TikTokAccountResponse, error) {
+func (s *AccountService) CreateAccount(actor Actor, req *models.TikTokAccountCreateRequest) (*models.TikTokAccountResponse, error) {
 	// Check if user has access to the specified group
+	userID := actor.UserID
 	user, err := s.userRepo.FindByID(userID)
 	if err != nil {
 		return nil, errors.New("user not found")
 	}
 
-	if user.Role == models.RoleOperator {
-		if user.GroupID == nil || *user.GroupID != req.GroupID {
-			return nil, errors.New("no access to this group")
-		}
-	} else if user.Role == models.RoleManager {
-		// Check if manager manages this group
-		group, err := s.groupRepo.FindByID(req.GroupID)
-		if err != nil || group.ManagedBy == nil || *group.ManagedBy != user.ID {
-			return nil, errors.New("no access to this group")
-		}
+	if err := s.policy.Authorize(user, models.PermAccountCreate, models.GroupResource(req.GroupID)); err != nil {
+		return nil, err
+	}
+	if err := s.groupPolicies.CheckAccountCreation(user, req.GroupID); err != nil {
+		return nil, err
 	}
 
 	account := &models.TikTokAccount{
@@ -61,10 +67,21 @@ func (s *AccountService) CreateAccount(userID uint, req *models.TikTokAccountCre
 		IsActive:          true,
 	}
 
-	if err := s.accountRepo.Create(account); err != nil {
+	err = s.groupPolicies.AddAccount(req.GroupID, req.Location, req.Tags, func(tx *gorm.DB) error {
+		return s.accountRepo.WithTx(tx).Create(account)
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	s.audit.Record(actor, AuditEntry{
+		Action:        models.AuditCreate,
+		Resource:      models.AuditResourceAccount,
+		ResourceID:    account.ID,
+		TargetGroupID: &account.GroupID,
+		After:         account,
+	})
+
 	// Fetch initial data from TikTok API
 	if err := s.tikTokRepo.FetchAccountData(account); err != nil {
 		// Log error but don't fail the operation
@@ -118,42 +135,44 @@ func (s *AccountService) GetAccount(accountID uint) (*models.TikTokAccountRespon
 	return response, nil
 }
 
-func (s *AccountService) ListAccounts(userID uint, groupID uint) ([]models.TikTokAccountResponse, error) {
+// AccountGroupID returns the group an account belongs to, for access checks
+func (s *AccountService) AccountGroupID(accountID uint) (uint, error) {
+	return s.accountRepo.GroupIDOf(accountID)
+}
+
+// ListAccounts returns the accounts of a group, with subtree also those of
+// its subgroups the user may read, or without a group every account the
+// user may read
+func (s *AccountService) ListAccounts(userID uint, groupID uint, subtree bool) ([]models.TikTokAccountResponse, error) {
 	user, err := s.userRepo.FindByID(userID)
 	if err != nil {
 		return nil, errors.New("user not found")
 	}
 
-	// If groupID is specified, check access
-	if groupID != 0 {
-		if user.Role == models.RoleOperator {
-			if user.GroupID == nil || *user.GroupID != groupID {
-				return nil, errors.New("no access to this group")
-			}
-		} else if user.Role == models.RoleManager {
-			group, err := s.groupRepo.FindByID(groupID)
-			if err != nil || group.ManagedBy == nil || *group.ManagedBy != user.ID {
-				return nil, errors.New("no access to this group")
-			}
+	var accounts []models.TikTokAccount
+	if groupID != 0 && subtree {
+		groupIDs, scopeErr := s.policy.Subtree(user, models.PermAccountRead, groupID)
+		if scopeErr != nil {
+			return nil, scopeErr
+		}
+		accounts, err = s.accountRepo.ListAccountsInGroups(groupIDs)
+	} else if groupID != 0 {
+		if err := s.policy.Authorize(user, models.PermAccountRead, models.GroupResource(groupID)); err != nil {
+			return nil, err
 		}
+		accounts, err = s.accountRepo.ListAccounts(groupID)
 	} else {
-		// If no groupID specified, filter by accessible groups
-		if user.Role == models.RoleOperator && user.GroupID != nil {
-			groupID = *user.GroupID
-		} else if user.Role == models.RoleManager {
-			// Get all groups managed by this manager
-			groups, err := s.groupRepo.ListGroups(user.ID)
-			if err != nil {
-				return nil, err
-			}
-			if len(groups) == 0 {
-				return []models.TikTokAccountResponse{}, nil
-			}
-			// We'll need to modify the repository to handle multiple group IDs
+		// Without a group, list the accounts of every group the user can read
+		scope, scopeErr := s.policy.GroupScope(user, models.PermAccountRead)
+		if scopeErr != nil {
+			return nil, scopeErr
+		}
+		if scope.All {
+			accounts, err = s.accountRepo.ListAccounts(0)
+		} else {
+			accounts, err = s.accountRepo.ListAccountsInGroups(scope.GroupIDs)
 		}
 	}
-
-	accounts, err := s.accountRepo.ListAccounts(groupID)
 	if err != nil {
 		return nil, err
 	}
@@ -202,27 +221,24 @@ func (s *AccountService) ListAccounts(userID uint, groupID uint) ([]models.TikTo
 	return responses, nil
 }
 
-func (s *AccountService) UpdateAccount(userID, accountID uint, req *models.TikTokAccountUpdateRequest) (*models.TikTokAccountResponse, error) {
+func (s *AccountService) UpdateAccount(actor Actor, accountID uint, req *models.TikTokAccountUpdateRequest) (*models.TikTokAccountResponse, error) {
 	account, err := s.accountRepo.FindByID(accountID)
 	if err != nil {
 		return nil, errors.New("account not found")
 	}
+	before := *account
 
 	// Check if user has access to this account's group
-	user, err := s.userRepo.FindByID(userID)
+	user, err := s.userRepo.FindByID(actor.UserID)
 	if err != nil {
 		return nil, errors.New("user not found")
 	}
 
-	if user.Role == models.RoleOperator {
-		if user.GroupID == nil || *user.GroupID != account.GroupID {
-			return nil, errors.New("no access to this account")
-		}
-	} else if user.Role == models.RoleManager {
-		group, err := s.groupRepo.FindByID(account.GroupID)
-		if err != nil || group.ManagedBy == nil || *group.ManagedBy != user.ID {
-			return nil, errors.New("no access to this account")
-		}
+	if err := s.policy.Authorize(user, models.PermAccountUpdate, models.GroupResource(account.GroupID)); err != nil {
+		return nil, err
+	}
+	if err := s.groupPolicies.CheckWritable(account.GroupID); err != nil {
+		return nil, err
 	}
 
 	// Apply updates
@@ -246,21 +262,6 @@ func (s *AccountService) UpdateAccount(userID, accountID uint, req *models.TikTo
 		account.RegistrationDate = *req.RegistrationDate
 	}
 
-	if req.GroupID != nil {
-		// Check if user has access to the new group
-		if user.Role == models.RoleOperator {
-			if user.GroupID == nil || *user.GroupID != *req.GroupID {
-				return nil, errors.New("no access to the new group")
-			}
-		} else if user.Role == models.RoleManager {
-			group, err := s.groupRepo.FindByID(*req.GroupID)
-			if err != nil || group.ManagedBy == nil || *group.ManagedBy != user.ID {
-				return nil, errors.New("no access to the new group")
-			}
-		}
-		account.GroupID = *req.GroupID
-	}
-
 	if req.AccountOwner != nil {
 		account.AccountOwner = *req.AccountOwner
 	}
@@ -285,43 +286,289 @@ func (s *AccountService) UpdateAccount(userID, accountID uint, req *models.TikTo
 		account.IsActive = *req.IsActive
 	}
 
+	if req.Location != nil || req.Tags != nil {
+		if err := s.groupPolicies.CheckAccount(account.GroupID, account.Location, account.Tags, false); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := s.accountRepo.Update(account); err != nil {
 		return nil, err
 	}
 
+	s.audit.Record(actor, AuditEntry{
+		Action:        models.AuditUpdate,
+		Resource:      models.AuditResourceAccount,
+		ResourceID:    account.ID,
+		TargetGroupID: &account.GroupID,
+		Before:        &before,
+		After:         account,
+	})
+
 	return s.GetAccount(account.ID)
 }
 
-func (s *AccountService) DeleteAccount(userID, accountID uint) error {
+func (s *AccountService) DeleteAccount(actor Actor, accountID uint) error {
 	account, err := s.accountRepo.FindByID(accountID)
 	if err != nil {
 		return errors.New("account not found")
 	}
 
 	// Check if user has access to this account's group
-	user, err := s.userRepo.FindByID(userID)
+	user, err := s.userRepo.FindByID(actor.UserID)
 	if err != nil {
 		return errors.New("user not found")
 	}
 
-	if user.Role == models.RoleOperator {
-		if user.GroupID == nil || *user.GroupID != account.GroupID {
-			return errors.New("no access to this account")
+	if err := s.policy.Authorize(user, models.PermAccountDelete, models.GroupResource(account.GroupID)); err != nil {
+		return err
+	}
+	if err := s.groupPolicies.CheckWritable(account.GroupID); err != nil {
+		return err
+	}
+
+	if err := s.accountRepo.Delete(account.ID); err != nil {
+		return err
+	}
+
+	s.audit.Record(actor, AuditEntry{
+		Action:        models.AuditDelete,
+		Resource:      models.AuditResourceAccount,
+		ResourceID:    account.ID,
+		TargetGroupID: &account.GroupID,
+		Before:        account,
+	})
+
+	return nil
+}
+
+// TransferToGroup moves an account to another group. The user needs
+// account.transfer in the account's group. Without it in the destination,
+// the transfer waits for a manager of the destination when
+// req.RequestApproval is set and is refused otherwise.
+func (s *AccountService) TransferToGroup(actor Actor, req *models.TransferAccountRequest) (*models.AccountTransfer, error) {
+	account, err := s.accountRepo.FindByID(req.AccountID)
+	if err != nil {
+		return nil, errors.New("account not found")
+	}
+
+	user, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if err := s.policy.Authorize(user, models.PermAccountTransfer, models.GroupResource(account.GroupID)); err != nil {
+		return nil, err
+	}
+	if err := s.groupPolicies.CheckWritable(account.GroupID); err != nil {
+		return nil, err
+	}
+	if account.GroupID == req.GroupID {
+		return nil, errors.New("account is already in this group")
+	}
+	if _, err := s.groupRepo.FindByID(req.GroupID); err != nil {
+		return nil, errors.New("group not found")
+	}
+	if err := s.groupPolicies.CheckAccount(req.GroupID, account.Location, account.Tags, true); err != nil {
+		return nil, err
+	}
+
+	transfer := &models.AccountTransfer{
+		AccountID:   account.ID,
+		FromGroupID: account.GroupID,
+		ToGroupID:   req.GroupID,
+		RequestedBy: user.ID,
+		Note:        req.Note,
+	}
+
+	if err := s.policy.Authorize(user, models.PermAccountTransfer, models.GroupResource(req.GroupID)); err != nil {
+		if !req.RequestApproval {
+			return nil, errors.New("no access to the new group; set request_approval to ask its managers")
+		}
+		if _, err := s.transferRepo.FindPending(account.ID); err == nil {
+			return nil, errors.New("account already has a pending transfer")
 		}
-	} else if user.Role == models.RoleManager {
-		group, err := s.groupRepo.FindByID(account.GroupID)
-		if err != nil || group.ManagedBy == nil || *group.ManagedBy != user.ID {
-			return errors.New("no access to this account")
+
+		transfer.Status = models.TransferPending
+		if err := s.transferRepo.Create(transfer); err != nil {
+			return nil, errors.New("failed to request transfer")
+		}
+		s.recordTransfer(actor, models.AuditTransferRequest, transfer)
+		return transfer, nil
+	}
+
+	now := time.Now()
+	transfer.Status = models.TransferApproved
+	transfer.DecidedBy = &user.ID
+	transfer.DecidedAt = &now
+	if err := s.completeTransfer(transfer, account); err != nil {
+		return nil, err
+	}
+	s.recordTransfer(actor, models.AuditTransfer, transfer)
+
+	return transfer, nil
+}
+
+// ListTransfers returns transfers from or to the groups in which the user
+// may transfer accounts, and those they requested, optionally by status
+func (s *AccountService) ListTransfers(userID uint, status models.TransferStatus) ([]models.AccountTransfer, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	scope := &models.GroupScope{}
+	if s.policy.Can(user, models.PermAccountTransfer) {
+		if scope, err = s.policy.GroupScope(user, models.PermAccountTransfer); err != nil {
+			return nil, err
 		}
 	}
 
-	return s.accountRepo.Delete(account.ID)
+	return s.transferRepo.List(status, scope.All, scope.GroupIDs, user.ID)
+}
+
+// ApproveTransfer completes a pending transfer. The user needs
+// account.transfer in the destination group, and the account must still be
+// in the group it was requested from.
+func (s *AccountService) ApproveTransfer(actor Actor, transferID uint, req *models.TransferDecisionRequest) (*models.AccountTransfer, error) {
+	transfer, user, err := s.pendingTransfer(actor, transferID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.policy.Authorize(user, models.PermAccountTransfer, models.GroupResource(transfer.ToGroupID)); err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountRepo.FindByID(transfer.AccountID)
+	if err != nil {
+		return nil, errors.New("account not found")
+	}
+	if account.GroupID != transfer.FromGroupID {
+		return nil, errors.New("account has left the group it was requested from")
+	}
+	if err := s.groupPolicies.CheckWritable(transfer.FromGroupID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	transfer.Status = models.TransferApproved
+	transfer.DecidedBy = &user.ID
+	transfer.DecidedAt = &now
+	transfer.DecisionNote = req.Note
+	if err := s.completeTransfer(transfer, account); err != nil {
+		return nil, err
+	}
+	s.recordTransfer(actor, models.AuditTransfer, transfer)
+
+	return transfer, nil
+}
+
+// RejectTransfer refuses a pending transfer. The user needs
+// account.transfer in the destination group.
+func (s *AccountService) RejectTransfer(actor Actor, transferID uint, req *models.TransferDecisionRequest) (*models.AccountTransfer, error) {
+	transfer, user, err := s.pendingTransfer(actor, transferID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.policy.Authorize(user, models.PermAccountTransfer, models.GroupResource(transfer.ToGroupID)); err != nil {
+		return nil, err
+	}
+
+	return s.decideTransfer(actor, transfer, user, models.TransferRejected, req.Note)
+}
+
+// CancelTransfer withdraws a pending transfer. Only its requester may
+// cancel it.
+func (s *AccountService) CancelTransfer(actor Actor, transferID uint, req *models.TransferDecisionRequest) (*models.AccountTransfer, error) {
+	transfer, user, err := s.pendingTransfer(actor, transferID)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.RequestedBy != user.ID {
+		return nil, errors.New("only the requester can cancel a transfer")
+	}
+
+	return s.decideTransfer(actor, transfer, user, models.TransferCancelled, req.Note)
+}
+
+// GetGroupHistory returns the groups an account belonged to, oldest first
+func (s *AccountService) GetGroupHistory(accountID uint) ([]models.AccountGroupHistory, error) {
+	if _, err := s.accountRepo.FindByID(accountID); err != nil {
+		return nil, errors.New("account not found")
+	}
+	return s.accountRepo.GroupHistory(accountID)
+}
+
+// completeTransfer moves the account of an approved transfer once it fits
+// the destination group's policy, under the group's policy lock
+func (s *AccountService) completeTransfer(transfer *models.AccountTransfer, account *models.TikTokAccount) error {
+	err := s.groupPolicies.AddAccount(transfer.ToGroupID, account.Location, account.Tags, func(tx *gorm.DB) error {
+		return s.transferRepo.WithTx(tx).Complete(transfer)
+	})
+	var violation *GroupPolicyError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &violation):
+		return err
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return errors.New("account has left the group it was requested from")
+	}
+	return errors.New("failed to transfer account")
+}
+
+func (s *AccountService) pendingTransfer(actor Actor, transferID uint) (*models.AccountTransfer, *models.User, error) {
+	user, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return nil, nil, errors.New("user not found")
+	}
+	transfer, err := s.transferRepo.FindByID(transferID)
+	if err != nil {
+		return nil, nil, errors.New("transfer not found")
+	}
+	if transfer.Status != models.TransferPending {
+		return nil, nil, errors.New("transfer is not pending")
+	}
+	return transfer, user, nil
+}
+
+func (s *AccountService) decideTransfer(actor Actor, transfer *models.AccountTransfer, user *models.User,
+	status models.TransferStatus, note string) (*models.AccountTransfer, error) {
+	now := time.Now()
+	transfer.Status = status
+	transfer.DecidedBy = &user.ID
+	transfer.DecidedAt = &now
+	transfer.DecisionNote = note
+	if err := s.transferRepo.Decide(transfer); err != nil {
+		return nil, errors.New("failed to update transfer")
+	}
+
+	action := models.AuditTransferReject
+	if status == models.TransferCancelled {
+		action = models.AuditTransferCancel
+	}
+	s.recordTransfer(actor, action, transfer)
+
+	return transfer, nil
 }
 
-func (s *AccountService) TransferToGroup(userID, accountID, groupID uint) error {
-	// Check if user has access to both current and new groups
-	// Implementation similar to UpdateAccount
-	return s.accountRepo.TransferToGroup(accountID, groupID)
+// recordTransfer audits a transfer against both of its groups so each
+// group's managers see it
+func (s *AccountService) recordTransfer(actor Actor, action string, transfer *models.AccountTransfer) {
+	for _, groupID := range []uint{transfer.FromGroupID, transfer.ToGroupID} {
+		groupID := groupID
+		s.audit.Record(actor, AuditEntry{
+			Action:        action,
+			Resource:      models.AuditResourceAccount,
+			ResourceID:    transfer.AccountID,
+			TargetGroupID: &groupID,
+			Details: models.JSON{
+				"transfer_id":   transfer.ID,
+				"from_group_id": transfer.FromGroupID,
+				"to_group_id":   transfer.ToGroupID,
+				"status":        transfer.Status,
+			},
+		})
+	}
 }
 
 func (s *AccountService) GetAccountTrends(accountID uint, days int) (*models.TrendResponse, error) {
@@ -349,39 +596,62 @@ func (s *AccountService) GetAccountTrends(accountID uint, days int) (*models.Tre
 	return response, nil
 }
 
-func (s *AccountService) GetDashboardData(userID uint) (*models.DashboardResponse, error) {
+// GetDashboardData aggregates the groups the user may read, or with a
+// groupID only that group and, with subtree, the subgroups below it
+func (s *AccountService) GetDashboardData(userID, groupID uint, subtree bool) (*models.DashboardResponse, error) {
 	user, err := s.userRepo.FindByID(userID)
 	if err != nil {
 		return nil, errors.New("user not found")
 	}
 
-	var groupIDs []uint
-	if user.Role == models.RoleSuperAdmin {
-		// Super admin gets data for all groups
-		groups, err := s.groupRepo.ListGroups(0)
+	if groupID != 0 {
+		groupIDs := []uint{groupID}
+		if subtree {
+			groupIDs, err = s.policy.Subtree(user, models.PermAnalyticsRead, groupID)
+		} else {
+			err = s.policy.Authorize(user, models.PermAnalyticsRead, models.GroupResource(groupID))
+		}
 		if err != nil {
 			return nil, err
 		}
-		for _, g := range groups {
-			groupIDs = append(groupIDs, g.ID)
-		}
-	} else if user.Role == models.RoleManager {
-		// Manager gets data for groups they manage
-		groups, err := s.groupRepo.ListGroups(user.ID)
+		return s.dashboard(groupIDs)
+	}
+
+	scope, err := s.policy.GroupScope(user, models.PermAnalyticsRead)
+	if err != nil {
+		return nil, err
+	}
+
+	groupIDs := scope.GroupIDs
+	if scope.All {
+		groups, err := s.groupRepo.ListGroups(0, true)
 		if err != nil {
 			return nil, err
 		}
+		groupIDs = nil
 		for _, g := range groups {
 			groupIDs = append(groupIDs, g.ID)
 		}
-	} else if user.Role == models.RoleOperator && user.GroupID != nil {
-		// Operator gets data for their group only
-		groupIDs = append(groupIDs, *user.GroupID)
 	}
 
 	if len(groupIDs) == 0 {
 		return &models.DashboardResponse{}, nil
 	}
 
-	return s.accountRepo.GetDashboardData(groupIDs)
+	return s.dashboard(groupIDs)
+}
+
+func (s *AccountService) dashboard(groupIDs []uint) (*models.DashboardResponse, error) {
+	dashboard, err := s.accountRepo.GetDashboardData(groupIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// The dashboard totals cover the accounts the groups hold now, so their
+	// stats do too
+	if dashboard.GroupStats, err = s.groupRepo.ListGroupStats(groupIDs, models.AttributionCurrent); err != nil {
+		return nil, err
+	}
+
+	return dashboard, nil
 }
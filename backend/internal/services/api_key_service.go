@@ -0,0 +1,171 @@
+// internal/services/api_key_service.go
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"github.com/katuhangugi/tiktok-account-system/internal/repositories"
+	"github.com/katuhangugi/tiktok-account-system/internal/utils"
+)
+
+// apiKeyDisplayLength is how much of a key is kept to recognize it by
+const apiKeyDisplayLength = 12
+
+// APIKeyService issues, lists and revokes API keys and authenticates
+// requests made with them. Users manage their own keys; holders of
+// user.security also manage the keys of users they control, such as service
+// users created for automation.
+type APIKeyService struct {
+	repo     *repositories.APIKeyRepository
+	userRepo *repositories.UserRepository
+	users    *UserService
+	policy   *PolicyService
+	audit    *AuditService
+}
+
+func NewAPIKeyService(repo *repositories.APIKeyRepository, userRepo *repositories.UserRepository,
+	users *UserService, policy *PolicyService, audit *AuditService) *APIKeyService {
+	return &APIKeyService{repo: repo, userRepo: userRepo, users: users, policy: policy, audit: audit}
+}
+
+// CreateKey issues a key owned by ownerID. The owner must hold the
+// permission behind every scope. The key itself is only returned here.
+func (s *APIKeyService) CreateKey(actor Actor, ownerID uint, req *models.APIKeyCreateRequest) (*models.CreatedAPIKey, error) {
+	owner, err := s.owner(actor, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var scopes models.StringList
+	seen := make(map[string]bool)
+	for _, scope := range req.Scopes {
+		if seen[scope] {
+			continue
+		}
+		perm, ok := models.APIScopePermissions[scope]
+		if !ok {
+			return nil, errors.New("unknown scope " + scope)
+		}
+		if !s.policy.Can(owner, perm) {
+			return nil, errors.New("scope " + scope + " exceeds the owner's permissions")
+		}
+		seen[scope] = true
+		scopes = append(scopes, scope)
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("expiry must be in the future")
+	}
+
+	secret, _, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return nil, errors.New("failed to generate API key")
+	}
+	key := models.APIKeyPrefix + secret
+
+	record := &models.APIKey{
+		UserID:    owner.ID,
+		Name:      req.Name,
+		Prefix:    key[:apiKeyDisplayLength],
+		KeyHash:   utils.HashToken(key),
+		Scopes:    scopes,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: actor.UserID,
+	}
+	if err := s.repo.Create(record); err != nil {
+		return nil, errors.New("failed to store API key")
+	}
+
+	s.audit.Record(actor, AuditEntry{
+		Action:        models.AuditCreate,
+		Resource:      models.AuditResourceAPIKey,
+		ResourceID:    record.ID,
+		TargetUserID:  &owner.ID,
+		TargetGroupID: owner.GroupID,
+		Details:       models.JSON{"name": record.Name, "scopes": record.Scopes, "expires_at": record.ExpiresAt},
+	})
+
+	return &models.CreatedAPIKey{APIKey: *record, Key: key}, nil
+}
+
+// ListKeys returns the unrevoked keys owned by ownerID
+func (s *APIKeyService) ListKeys(actor Actor, ownerID uint) ([]models.APIKey, error) {
+	if _, err := s.owner(actor, ownerID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListByUser(ownerID)
+}
+
+func (s *APIKeyService) RevokeKey(actor Actor, ownerID, keyID uint) error {
+	owner, err := s.owner(actor, ownerID)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := s.repo.Revoke(owner.ID, keyID)
+	if err != nil {
+		return errors.New("failed to revoke API key")
+	}
+	if !revoked {
+		return errors.New("API key not found")
+	}
+
+	s.audit.Record(actor, AuditEntry{
+		Action:        models.AuditRevokeAPIKey,
+		Resource:      models.AuditResourceAPIKey,
+		ResourceID:    keyID,
+		TargetUserID:  &owner.ID,
+		TargetGroupID: owner.GroupID,
+	})
+
+	return nil
+}
+
+// AuthenticateAPIKey resolves an API key to its owner. Revoked and expired
+// keys and keys of deactivated users are rejected.
+func (s *APIKeyService) AuthenticateAPIKey(key, ip string) (*models.User, *models.APIKey, error) {
+	record, err := s.repo.FindByHash(utils.HashToken(key))
+	if err != nil {
+		return nil, nil, errors.New("invalid API key")
+	}
+
+	now := time.Now()
+	if !record.Active(now) {
+		return nil, nil, errors.New("invalid API key")
+	}
+
+	user, err := s.userRepo.FindByID(record.UserID)
+	if err != nil {
+		return nil, nil, errors.New("user not found")
+	}
+	if !user.IsActive {
+		return nil, nil, errors.New("account is inactive")
+	}
+
+	// Last-use tracking is informational; a failed write does not reject
+	// the request
+	s.repo.Touch(record.ID, ip, now)
+
+	return user, record, nil
+}
+
+// owner loads the key owner if the actor may manage their keys: their own,
+// or those of a user they control
+func (s *APIKeyService) owner(actor Actor, ownerID uint) (*models.User, error) {
+	if actor.APIKeyID != 0 {
+		return nil, errors.New("API keys cannot manage API keys")
+	}
+
+	if ownerID == actor.UserID {
+		user, err := s.userRepo.FindByID(ownerID)
+		if err != nil {
+			return nil, errors.New("user not found")
+		}
+		return user, nil
+	}
+
+	return s.users.controlledUser(actor.UserID, ownerID)
+}
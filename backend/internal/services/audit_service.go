@@ -0,0 +1,237 @@
+// internal/services/audit_service.go
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"github.com/katuhangugi/tiktok-account-system/internal/repositories"
+	"github.com/katuhangugi/tiktok-account-system/pkg/logger"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+)
+
+// Actor identifies who is making a mutating call, for access checks and the
+// audit trail
+type Actor struct {
+	UserID uint
+	IP     string
+	// APIKeyID is set when the call was authenticated with an API key
+	APIKeyID uint
+}
+
+// AuditService records and queries the audit trail in system_logs
+type AuditService struct {
+	auditRepo *repositories.AuditRepository
+	userRepo  *repositories.UserRepository
+	policy    *PolicyService
+	log       *logger.Logger
+}
+
+func NewAuditService(auditRepo *repositories.AuditRepository, userRepo *repositories.UserRepository,
+	policy *PolicyService, log *logger.Logger) *AuditService {
+	return &AuditService{
+		auditRepo: auditRepo,
+		userRepo:  userRepo,
+		policy:    policy,
+		log:       log,
+	}
+}
+
+// AuditEntry describes one audited change. Before and After are the
+// resource before and after the change (nil for creates and deletes) and
+// are reduced to a field diff.
+type AuditEntry struct {
+	Action        string
+	Resource      string
+	ResourceID    uint
+	TargetUserID  *uint
+	TargetGroupID *uint
+	Before        interface{}
+	After         interface{}
+	// Details are extra values stored next to the diff
+	Details models.JSON
+}
+
+// Record writes an audit entry. Failures are logged rather than returned so
+// that auditing never undoes a change that already succeeded.
+func (s *AuditService) Record(actor Actor, entry AuditEntry) {
+	if s == nil {
+		return
+	}
+
+	details := models.JSON{}
+	for k, v := range entry.Details {
+		details[k] = v
+	}
+	if actor.APIKeyID != 0 {
+		details["api_key_id"] = actor.APIKeyID
+	}
+	if entry.Before != nil || entry.After != nil {
+		if changes := auditDiff(entry.Before, entry.After); len(changes) > 0 {
+			details["changes"] = changes
+		}
+	}
+
+	log := &models.SystemLog{
+		Action:        entry.Action,
+		Resource:      entry.Resource,
+		TargetUserID:  entry.TargetUserID,
+		TargetGroupID: entry.TargetGroupID,
+		Details:       details,
+		IPAddress:     actor.IP,
+	}
+	if actor.UserID != 0 {
+		userID := actor.UserID
+		log.UserID = &userID
+	}
+	if entry.ResourceID != 0 {
+		resourceID := entry.ResourceID
+		log.ResourceID = &resourceID
+	}
+
+	if err := s.auditRepo.Create(log); err != nil {
+		s.log.Errorf("Audit: failed to record %s %s %d by user %d: %v",
+			entry.Action, entry.Resource, entry.ResourceID, actor.UserID, err)
+	}
+}
+
+// ListLogs returns audit entries visible to the user: everything with
+// audit.read at the all scope, otherwise only entries about their groups
+func (s *AuditService) ListLogs(userID uint, filter models.AuditLogFilter) (*models.AuditLogPage, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	scope, err := s.policy.GroupScope(user, models.PermAuditRead)
+	if err != nil {
+		return nil, errors.New("no access to the audit trail")
+	}
+	if scope.All {
+		filter.GroupIDs = nil
+	} else {
+		// An empty, non-nil list matches nothing
+		filter.GroupIDs = append([]uint{}, scope.GroupIDs...)
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditPageSize
+	}
+	if filter.Limit > maxAuditPageSize {
+		filter.Limit = maxAuditPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	return s.auditRepo.List(filter)
+}
+
+// auditDiff compares two values of the same struct type field by field and
+// returns {"field": {"from": old, "to": new}} keyed by JSON name. Either side
+// may be nil, which compares against the zero value. Fields hidden from JSON
+// are reported as redacted, and associations and timestamps are skipped.
+func auditDiff(before, after interface{}) map[string]interface{} {
+	b, a := auditValue(before), auditValue(after)
+	if !b.IsValid() && !a.IsValid() {
+		return nil
+	}
+	if !b.IsValid() {
+		b = reflect.Zero(a.Type())
+	}
+	if !a.IsValid() {
+		a = reflect.Zero(b.Type())
+	}
+	if a.Type() != b.Type() || a.Kind() != reflect.Struct {
+		return nil
+	}
+
+	changes := make(map[string]interface{})
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" || !auditableField(field) {
+			continue
+		}
+
+		name, redacted := auditFieldName(field)
+		from, to := b.Field(i).Interface(), a.Field(i).Interface()
+		if reflect.DeepEqual(from, to) {
+			continue
+		}
+
+		if redacted {
+			changes[name] = map[string]interface{}{"from": "[redacted]", "to": "[redacted]"}
+			continue
+		}
+		changes[name] = map[string]interface{}{
+			"from": auditPlain(b.Field(i)),
+			"to":   auditPlain(a.Field(i)),
+		}
+	}
+	return changes
+}
+
+func auditValue(v interface{}) reflect.Value {
+	rv := reflect.ValueOf(v)
+	for rv.IsValid() && rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+	return rv
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// auditableField skips associations, has-many slices and bookkeeping timestamps
+func auditableField(field reflect.StructField) bool {
+	switch field.Name {
+	case "ID", "CreatedAt", "UpdatedAt":
+		return false
+	}
+
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return t == timeType
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Struct
+	}
+	return true
+}
+
+// auditFieldName returns the JSON name of the field and whether it is
+// hidden from JSON, in which case its value must not be logged
+func auditFieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return strings.ToLower(field.Name), true
+	}
+	if name := strings.Split(tag, ",")[0]; name != "" {
+		return name, false
+	}
+	return field.Name, false
+}
+
+// auditPlain dereferences pointers so the stored diff holds plain values
+func auditPlain(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		return v.Elem().Interface()
+	}
+	return v.Interface()
+}
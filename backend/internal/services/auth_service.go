@@ -3,6 +3,7 @@ package services
 
 import (
 	"errors"
+	"time"
 
 	"github.com/katuhangugi/tiktok-account-system/internal/config"
 	"github.com/katuhangugi/tiktok-account-system/internal/models"
@@ -11,62 +12,376 @@ import (
 )
 
 type AuthService struct {
-	userRepo *repositories.UserRepository
-	config   *config.Config
+	userRepo  *repositories.UserRepository
+	tokenRepo *repositories.RefreshTokenRepository
+	twoFactor *TwoFactorService
+	throttle  *LoginThrottleService
+	config    *config.Config
+	audit     *AuditService
 }
 
-func NewAuthService(userRepo *repositories.UserRepository, config *config.Config) *AuthService {
-	return &AuthService{userRepo: userRepo, config: config}
+func NewAuthService(userRepo *repositories.UserRepository, tokenRepo *repositories.RefreshTokenRepository,
+	twoFactor *TwoFactorService, throttle *LoginThrottleService, config *config.Config, audit *AuditService) *AuthService {
+	return &AuthService{
+		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
+		twoFactor: twoFactor,
+		throttle:  throttle,
+		config:    config,
+		audit:     audit,
+	}
 }
 
-func (s *AuthService) Login(username, password string) (string, string, error) {
+// Login checks the credentials. Users with two-factor authentication, or
+// whose role requires it, get a challenge token for VerifyTwoFactor instead
+// of a session. Both successful and failed attempts are recorded in the
+// audit trail, and repeated failures return a *LoginThrottledError.
+func (s *AuthService) Login(username, password, ip, userAgent string) (*models.LoginResult, error) {
+	if err := s.throttle.Check(username, ip); err != nil {
+		return nil, err
+	}
+
 	user, err := s.userRepo.FindByUsername(username)
 	if err != nil {
-		return "", "", errors.New("invalid credentials")
+		s.recordLoginFailure(ip, nil, username, "unknown user")
+		return nil, errors.New("invalid credentials")
 	}
 
 	if !user.IsActive {
-		return "", "", errors.New("account is inactive")
+		s.recordLoginFailure(ip, user, username, "account inactive")
+		return nil, errors.New("account is inactive")
 	}
 
 	if !utils.CheckPasswordHash(password, user.Password) {
-		return "", "", errors.New("invalid credentials")
+		s.recordLoginFailure(ip, user, username, "wrong password")
+		return nil, errors.New("invalid credentials")
+	}
+
+	enabled, err := s.twoFactor.Enabled(user.ID)
+	if err != nil {
+		return nil, errors.New("failed to check two-factor authentication")
+	}
+
+	if enabled || s.twoFactor.Required(user) {
+		challenge, err := utils.GenerateChallengeToken(user.ID, s.config)
+		if err != nil {
+			return nil, errors.New("failed to generate challenge")
+		}
+		return &models.LoginResult{
+			TwoFactorRequired:  true,
+			EnrollmentRequired: !enabled,
+			ChallengeToken:     challenge,
+		}, nil
+	}
+
+	tokens, err := s.startSession(user, ip, userAgent, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.LoginResult{TokenPair: tokens}, nil
+}
+
+// EnrollWithChallenge starts two-factor enrollment during login, for users
+// whose role requires it but who have not enrolled yet
+func (s *AuthService) EnrollWithChallenge(challengeToken, ip string) (*models.TwoFactorEnrollment, error) {
+	userID, err := utils.ValidateChallengeToken(challengeToken, s.config)
+	if err != nil {
+		return nil, errors.New("invalid or expired challenge")
+	}
+
+	return s.twoFactor.Enroll(Actor{UserID: userID, IP: ip})
+}
+
+// VerifyTwoFactor completes a login with a TOTP or recovery code. The first
+// valid TOTP code after enrollment also turns two-factor authentication on.
+func (s *AuthService) VerifyTwoFactor(challengeToken, code, ip, userAgent string) (*models.TokenPair, error) {
+	userID, err := utils.ValidateChallengeToken(challengeToken, s.config)
+	if err != nil {
+		return nil, errors.New("invalid or expired challenge")
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("invalid credentials")
+	}
+
+	if err := s.throttle.Check(user.Username, ip); err != nil {
+		return nil, err
+	}
+
+	if !user.IsActive {
+		s.recordLoginFailure(ip, user, user.Username, "account inactive")
+		return nil, errors.New("account is inactive")
+	}
+
+	wasEnabled, err := s.twoFactor.Enabled(user.ID)
+	if err != nil {
+		return nil, errors.New("failed to check two-factor authentication")
+	}
+
+	ok, usedRecovery, err := s.twoFactor.VerifyCode(user.ID, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		s.recordLoginFailure(ip, user, user.Username, "wrong two-factor code")
+		return nil, errors.New("invalid two-factor code")
+	}
+
+	actor := Actor{UserID: user.ID, IP: ip}
+	if !wasEnabled {
+		s.audit.Record(actor, AuditEntry{
+			Action:        models.AuditTwoFactorEnable,
+			Resource:      models.AuditResourceUser,
+			ResourceID:    user.ID,
+			TargetUserID:  &user.ID,
+			TargetGroupID: user.GroupID,
+		})
+	}
+
+	return s.startSession(user, ip, userAgent, models.JSON{"two_factor": true, "recovery_code": usedRecovery})
+}
+
+// startSession opens a new refresh token family for a fully authenticated
+// user and audits the login
+func (s *AuthService) startSession(user *models.User, ip, userAgent string, details models.JSON) (*models.TokenPair, error) {
+	familyID, err := utils.NewTokenID()
+	if err != nil {
+		return nil, errors.New("failed to generate session")
+	}
+
+	tokens, err := s.issueTokens(user, familyID, ip, userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	s.throttle.Succeed(user.Username)
+	s.audit.Record(Actor{UserID: user.ID, IP: ip}, AuditEntry{
+		Action:        models.AuditLogin,
+		Resource:      models.AuditResourceAuth,
+		ResourceID:    user.ID,
+		TargetUserID:  &user.ID,
+		TargetGroupID: user.GroupID,
+		Details:       details,
+	})
+
+	return tokens, nil
+}
+
+// recordLoginFailure counts a failed login towards a lockout and audits it;
+// user is nil for unknown usernames
+func (s *AuthService) recordLoginFailure(ip string, user *models.User, username, reason string) {
+	s.throttle.Fail(username, ip, user)
+
+	entry := AuditEntry{
+		Action:   models.AuditLoginFailed,
+		Resource: models.AuditResourceAuth,
+		Details:  models.JSON{"username": username, "reason": reason},
+	}
+	if user != nil {
+		entry.ResourceID = user.ID
+		entry.TargetUserID = &user.ID
+		entry.TargetGroupID = user.GroupID
 	}
+	s.audit.Record(Actor{IP: ip}, entry)
+}
 
-	accessToken, err := utils.GenerateToken(user.ID, string(user.Role), user.GroupID, s.config)
+// RefreshToken rotates a refresh token: the presented token is spent and a
+// new access and refresh token are issued in the same family. Presenting a
+// token that was already spent means it leaked, so the whole family is
+// revoked.
+func (s *AuthService) RefreshToken(refreshToken, ip, userAgent string) (*models.TokenPair, error) {
+	token, err := s.tokenRepo.FindByHash(utils.HashToken(refreshToken))
 	if err != nil {
-		return "", "", errors.New("failed to generate token")
+		return nil, errors.New("invalid refresh token")
+	}
+
+	if token.RevokedAt != nil || !time.Now().Before(token.ExpiresAt) {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	if token.UsedAt != nil {
+		return nil, s.revokeReusedFamily(token, ip)
 	}
 
-	refreshToken, err := utils.GenerateRefreshToken(user.ID, s.config)
+	fresh, err := s.tokenRepo.MarkUsed(token.ID)
 	if err != nil {
-		return "", "", errors.New("failed to generate refresh token")
+		return nil, errors.New("failed to rotate refresh token")
+	}
+	if !fresh {
+		// Another request spent the token between the lookup and now
+		return nil, s.revokeReusedFamily(token, ip)
+	}
+
+	user, err := s.userRepo.FindByID(token.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if !user.IsActive {
+		if err := s.tokenRepo.RevokeFamily(token.FamilyID); err != nil {
+			return nil, errors.New("failed to revoke session")
+		}
+		return nil, errors.New("account is inactive")
+	}
+
+	return s.issueTokens(user, token.FamilyID, ip, userAgent)
+}
+
+func (s *AuthService) revokeReusedFamily(token *models.RefreshToken, ip string) error {
+	if err := s.tokenRepo.RevokeFamily(token.FamilyID); err != nil {
+		return errors.New("failed to revoke session")
 	}
 
-	return accessToken, refreshToken, nil
+	s.audit.Record(Actor{IP: ip}, AuditEntry{
+		Action:       models.AuditTokenReuse,
+		Resource:     models.AuditResourceAuth,
+		ResourceID:   token.UserID,
+		TargetUserID: &token.UserID,
+		Details:      models.JSON{"family_id": token.FamilyID},
+	})
+
+	return errors.New("refresh token reuse detected, session revoked")
 }
 
-func (s *AuthService) RefreshToken(refreshToken string) (string, error) {
-	claims, err := utils.ValidateToken(refreshToken)
+// issueTokens stores a new refresh token in the family and signs an access
+// token bound to it
+func (s *AuthService) issueTokens(user *models.User, familyID, ip, userAgent string) (*models.TokenPair, error) {
+	refreshToken, hash, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return nil, errors.New("failed to generate refresh token")
+	}
+
+	record := &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(s.config.JWT.RefreshTokenTTL),
+		IPAddress: ip,
+		UserAgent: truncate(userAgent, 255),
+	}
+	if err := s.tokenRepo.Create(record); err != nil {
+		return nil, errors.New("failed to store refresh token")
+	}
+
+	accessToken, err := utils.GenerateToken(user.ID, string(user.Role), user.GroupID, user.GroupIDs(), familyID, s.config)
 	if err != nil {
-		return "", errors.New("invalid refresh token")
+		return nil, errors.New("failed to generate token")
+	}
+
+	return &models.TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// Logout revokes the session the access token was issued from
+func (s *AuthService) Logout(actor Actor, sessionID string) error {
+	if err := s.tokenRepo.RevokeFamily(sessionID); err != nil {
+		return errors.New("failed to revoke session")
+	}
+
+	s.audit.Record(actor, AuditEntry{
+		Action:       models.AuditLogout,
+		Resource:     models.AuditResourceAuth,
+		ResourceID:   actor.UserID,
+		TargetUserID: &actor.UserID,
+		Details:      models.JSON{"family_id": sessionID},
+	})
+
+	return nil
+}
+
+// LogoutAll revokes every session of the user
+func (s *AuthService) LogoutAll(actor Actor) error {
+	if err := s.tokenRepo.RevokeUser(actor.UserID); err != nil {
+		return errors.New("failed to revoke sessions")
+	}
+
+	s.audit.Record(actor, AuditEntry{
+		Action:       models.AuditLogout,
+		Resource:     models.AuditResourceAuth,
+		ResourceID:   actor.UserID,
+		TargetUserID: &actor.UserID,
+		Details:      models.JSON{"all_sessions": true},
+	})
+
+	return nil
+}
+
+// ListSessions returns the user's active sessions, flagging the one the
+// request was made with
+func (s *AuthService) ListSessions(userID uint, currentSessionID string) ([]models.Session, error) {
+	sessions, err := s.tokenRepo.ListSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession ends one of the caller's own sessions
+func (s *AuthService) RevokeSession(actor Actor, sessionID string) error {
+	revoked, err := s.tokenRepo.RevokeUserFamily(actor.UserID, sessionID)
+	if err != nil {
+		return errors.New("failed to revoke session")
+	}
+	if !revoked {
+		return errors.New("session not found")
+	}
+
+	s.audit.Record(actor, AuditEntry{
+		Action:       models.AuditRevokeSession,
+		Resource:     models.AuditResourceSession,
+		ResourceID:   actor.UserID,
+		TargetUserID: &actor.UserID,
+		Details:      models.JSON{"family_id": sessionID},
+	})
+
+	return nil
+}
+
+// Authenticate resolves an access token to its user. Tokens of deactivated
+// users and of revoked or expired sessions are rejected even before the
+// token itself expires.
+func (s *AuthService) Authenticate(accessToken string) (*models.User, *utils.Claims, error) {
+	claims, err := utils.ValidateToken(accessToken, s.config)
+	if err != nil {
+		return nil, nil, errors.New("invalid token")
+	}
+
+	if claims.SessionID == "" {
+		return nil, nil, errors.New("invalid token")
 	}
 
 	user, err := s.userRepo.FindByID(claims.UserID)
 	if err != nil {
-		return "", errors.New("user not found")
+		return nil, nil, errors.New("user not found")
 	}
 
 	if !user.IsActive {
-		return "", errors.New("account is inactive")
+		return nil, nil, errors.New("account is inactive")
 	}
 
-	newAccessToken, err := utils.GenerateToken(user.ID, string(user.Role), user.GroupID, s.config)
+	active, err := s.tokenRepo.IsFamilyActive(claims.SessionID)
 	if err != nil {
-		return "", errors.New("failed to generate new access token")
+		return nil, nil, errors.New("failed to check session")
+	}
+	if !active {
+		return nil, nil, errors.New("session has been revoked")
 	}
 
-	return newAccessToken, nil
+	return user, claims, nil
+}
+
+// truncate cuts s to at most n bytes
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
 }
 
 func (s *AuthService) GetCurrentUser(userID uint) (*models.UserResponse, error) {
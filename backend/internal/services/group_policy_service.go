@@ -0,0 +1,330 @@
+// internal/services/group_policy_service.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"github.com/katuhangugi/tiktok-account-system/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// Codes identifying the rule a GroupPolicyError broke
+const (
+	PolicyAccountLimit          = "account_limit"
+	PolicyOperatorLimit         = "operator_limit"
+	PolicyLocationNotAllowed    = "location_not_allowed"
+	PolicyTagNotAllowed         = "tag_not_allowed"
+	PolicyAccountCreationDenied = "account_creation_denied"
+	PolicyGroupArchived         = "group_archived"
+)
+
+// GroupPolicyError is returned when a change would break a group's policy
+type GroupPolicyError struct {
+	GroupID uint   `json:"group_id"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Limit   *int   `json:"limit,omitempty"`
+	Used    *int64 `json:"used,omitempty"`
+	// Value is the location or tag that is not allowed
+	Value string `json:"value,omitempty"`
+}
+
+func (e *GroupPolicyError) Error() string {
+	return e.Message
+}
+
+// GroupPolicyService manages group quotas and checks changes against them
+type GroupPolicyService struct {
+	repo      *repositories.GroupPolicyRepository
+	groupRepo *repositories.GroupRepository
+	userRepo  *repositories.UserRepository
+	policy    *PolicyService
+	audit     *AuditService
+}
+
+func NewGroupPolicyService(repo *repositories.GroupPolicyRepository, groupRepo *repositories.GroupRepository,
+	userRepo *repositories.UserRepository, policy *PolicyService, audit *AuditService) *GroupPolicyService {
+	return &GroupPolicyService{repo: repo, groupRepo: groupRepo, userRepo: userRepo, policy: policy, audit: audit}
+}
+
+// GetPolicy returns a group's policy and its usage against each quota
+func (s *GroupPolicyService) GetPolicy(userID, groupID uint) (*models.GroupPolicyResponse, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if _, err := s.groupRepo.FindByID(groupID); err != nil {
+		return nil, errors.New("group not found")
+	}
+	if err := s.policy.Authorize(user, models.PermGroupRead, models.GroupResource(groupID)); err != nil {
+		return nil, err
+	}
+
+	policy, err := s.policyFor(groupID)
+	if err != nil {
+		return nil, err
+	}
+	return s.response(policy)
+}
+
+// UpdatePolicy replaces a group's policy. Existing accounts and operators
+// above a new limit are kept; only further additions are refused.
+func (s *GroupPolicyService) UpdatePolicy(actor Actor, groupID uint, req *models.GroupPolicyRequest) (*models.GroupPolicyResponse, error) {
+	user, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if _, err := s.groupRepo.FindByID(groupID); err != nil {
+		return nil, errors.New("group not found")
+	}
+	if err := s.policy.Authorize(user, models.PermGroupPolicy, models.GroupResource(groupID)); err != nil {
+		return nil, err
+	}
+	if err := s.CheckWritable(groupID); err != nil {
+		return nil, err
+	}
+
+	before, err := s.policyFor(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &models.GroupPolicy{
+		GroupID:                    groupID,
+		MaxAccounts:                req.MaxAccounts,
+		MaxOperators:               req.MaxOperators,
+		AllowedLocations:           req.AllowedLocations,
+		AllowedTags:                req.AllowedTags,
+		OperatorsCanCreateAccounts: *req.OperatorsCanCreateAccounts,
+		UpdatedBy:                  &user.ID,
+	}
+	err = s.repo.Locked(groupID, func(tx *gorm.DB) error {
+		return s.repo.WithTx(tx).Save(policy)
+	})
+	if err != nil {
+		return nil, errors.New("failed to save group policy")
+	}
+
+	s.audit.Record(actor, AuditEntry{
+		Action:        models.AuditUpdatePolicy,
+		Resource:      models.AuditResourceGroup,
+		ResourceID:    groupID,
+		TargetGroupID: &groupID,
+		Before:        before,
+		After:         policy,
+	})
+
+	return s.response(policy)
+}
+
+// CheckWritable verifies that the group is not archived
+func (s *GroupPolicyService) CheckWritable(groupID uint) error {
+	group, err := s.groupRepo.FindByID(groupID)
+	if err != nil {
+		return errors.New("group not found")
+	}
+	if group.IsArchived() {
+		return &GroupPolicyError{
+			GroupID: groupID,
+			Code:    PolicyGroupArchived,
+			Message: fmt.Sprintf("group %q is archived and read-only", group.Name),
+		}
+	}
+	return nil
+}
+
+// CheckAccountCreation verifies that user may create accounts in the group.
+// Users who may create accounts only in their own groups are operators for
+// OperatorsCanCreateAccounts; wider scopes are not restricted.
+func (s *GroupPolicyService) CheckAccountCreation(user *models.User, groupID uint) error {
+	if s.policy.ScopeOf(user, models.PermAccountCreate) != models.ScopeOwnGroup {
+		return nil
+	}
+
+	policy, err := s.policyFor(groupID)
+	if err != nil {
+		return err
+	}
+	if !policy.OperatorsCanCreateAccounts {
+		return &GroupPolicyError{
+			GroupID: groupID,
+			Code:    PolicyAccountCreationDenied,
+			Message: "operators cannot create accounts in this group",
+		}
+	}
+	return nil
+}
+
+// CheckAccount verifies that an account with location and tags may be in
+// the group, which must not be archived. With adding the account is joining
+// the group, so it must also fit in the account quota.
+func (s *GroupPolicyService) CheckAccount(groupID uint, location string, tags models.JSON, adding bool) error {
+	if err := s.CheckWritable(groupID); err != nil {
+		return err
+	}
+
+	policy, err := s.policyFor(groupID)
+	if err != nil {
+		return err
+	}
+
+	if len(policy.AllowedLocations) > 0 && !containsFold(policy.AllowedLocations, location) {
+		return &GroupPolicyError{
+			GroupID: groupID,
+			Code:    PolicyLocationNotAllowed,
+			Message: fmt.Sprintf("location %q is not allowed in this group", location),
+			Value:   location,
+		}
+	}
+
+	if len(policy.AllowedTags) > 0 {
+		keys := make([]string, 0, len(tags))
+		for tag := range tags {
+			keys = append(keys, tag)
+		}
+		sort.Strings(keys)
+		for _, tag := range keys {
+			if !containsFold(policy.AllowedTags, tag) {
+				return &GroupPolicyError{
+					GroupID: groupID,
+					Code:    PolicyTagNotAllowed,
+					Message: fmt.Sprintf("tag %q is not allowed in this group", tag),
+					Value:   tag,
+				}
+			}
+		}
+	}
+
+	if adding && policy.MaxAccounts != nil {
+		used, err := s.repo.CountAccounts(groupID)
+		if err != nil {
+			return err
+		}
+		if used >= int64(*policy.MaxAccounts) {
+			return &GroupPolicyError{
+				GroupID: groupID,
+				Code:    PolicyAccountLimit,
+				Message: fmt.Sprintf("group has reached its limit of %d accounts", *policy.MaxAccounts),
+				Limit:   policy.MaxAccounts,
+				Used:    &used,
+			}
+		}
+	}
+
+	return nil
+}
+
+// AddAccount runs add once an account with location and tags passes
+// CheckAccount for joining the group. Both run in one transaction holding
+// the group's policy lock, so concurrent additions cannot exceed the account
+// quota.
+func (s *GroupPolicyService) AddAccount(groupID uint, location string, tags models.JSON, add func(tx *gorm.DB) error) error {
+	return s.repo.Locked(groupID, func(tx *gorm.DB) error {
+		if err := s.withTx(tx).CheckAccount(groupID, location, tags, true); err != nil {
+			return err
+		}
+		return add(tx)
+	})
+}
+
+// AddOperator runs add once user passes CheckOperator for joining the group
+// with role, holding the group's policy lock like AddAccount
+func (s *GroupPolicyService) AddOperator(groupID uint, user *models.User, role models.MembershipRole,
+	add func(tx *gorm.DB) error) error {
+	return s.repo.Locked(groupID, func(tx *gorm.DB) error {
+		if err := s.withTx(tx).CheckOperator(groupID, user, role); err != nil {
+			return err
+		}
+		return add(tx)
+	})
+}
+
+// CheckOperator verifies that user can take role in the group: it must not
+// be archived, and plain members, the group's operators, must fit in its
+// operator quota. Users who already are plain members are not counted twice.
+func (s *GroupPolicyService) CheckOperator(groupID uint, user *models.User, role models.MembershipRole) error {
+	if err := s.CheckWritable(groupID); err != nil {
+		return err
+	}
+	if role != models.MembershipMember {
+		return nil
+	}
+	for _, m := range user.Memberships {
+		if m.GroupID == groupID && m.Role == models.MembershipMember {
+			return nil
+		}
+	}
+
+	policy, err := s.policyFor(groupID)
+	if err != nil {
+		return err
+	}
+	if policy.MaxOperators == nil {
+		return nil
+	}
+
+	used, err := s.repo.CountOperators(groupID)
+	if err != nil {
+		return err
+	}
+	if used >= int64(*policy.MaxOperators) {
+		return &GroupPolicyError{
+			GroupID: groupID,
+			Code:    PolicyOperatorLimit,
+			Message: fmt.Sprintf("group has reached its limit of %d operators", *policy.MaxOperators),
+			Limit:   policy.MaxOperators,
+			Used:    &used,
+		}
+	}
+	return nil
+}
+
+// withTx returns the service with its quota queries bound to tx
+func (s *GroupPolicyService) withTx(tx *gorm.DB) *GroupPolicyService {
+	locked := *s
+	locked.repo = s.repo.WithTx(tx)
+	return &locked
+}
+
+func (s *GroupPolicyService) policyFor(groupID uint) (*models.GroupPolicy, error) {
+	policy, err := s.repo.Find(groupID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.DefaultGroupPolicy(groupID), nil
+	}
+	if err != nil {
+		return nil, errors.New("failed to load group policy")
+	}
+	return policy, nil
+}
+
+func (s *GroupPolicyService) response(policy *models.GroupPolicy) (*models.GroupPolicyResponse, error) {
+	accounts, err := s.repo.CountAccounts(policy.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	operators, err := s.repo.CountOperators(policy.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.GroupPolicyResponse{
+		Policy: policy,
+		Usage: models.GroupUsage{
+			Accounts:  models.QuotaUsage{Used: accounts, Limit: policy.MaxAccounts},
+			Operators: models.QuotaUsage{Used: operators, Limit: policy.MaxOperators},
+		},
+	}, nil
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,883 @@
+// internal/services/group_service.go
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"github.com/katuhangugi/tiktok-account-system/internal/repositories"
+	"gorm.io/gorm"
+)
+
+type GroupService struct {
+	groupRepo      *repositories.GroupRepository
+	userRepo       *repositories.UserRepository
+	membershipRepo *repositories.MembershipRepository
+	policy         *PolicyService
+	groupPolicies  *GroupPolicyService
+	audit          *AuditService
+}
+
+func NewGroupService(groupRepo *repositories.GroupRepository, userRepo *repositories.UserRepository,
+	membershipRepo *repositories.MembershipRepository, policy *PolicyService, groupPolicies *GroupPolicyService,
+	audit *AuditService) *GroupService {
+	return &GroupService{
+		groupRepo:      groupRepo,
+		userRepo:       userRepo,
+		membershipRepo: membershipRepo,
+		policy:         policy,
+		groupPolicies:  groupPolicies,
+		audit:          audit,
+	}
+}
+
+func (s *GroupService) GetGroup(groupID uint) (*models.GroupResponse, error) {
+	group, err := s.groupRepo.FindByID(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := newGroupResponse(group)
+	return &response, nil
+}
+
+// ListGroups returns the groups the user may read, leaving out archived
+// groups unless includeArchived
+func (s *GroupService) ListGroups(userID uint, includeArchived bool) ([]models.GroupResponse, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	scope, err := s.policy.GroupScope(user, models.PermGroupRead)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []models.Group
+	if scope.All {
+		groups, err = s.groupRepo.ListGroups(0, includeArchived)
+	} else {
+		groups, err = s.groupRepo.ListGroupsByIDs(scope.GroupIDs, includeArchived)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newGroupResponses(groups), nil
+}
+
+func (s *GroupService) GetManagedGroups(userID uint) ([]models.GroupResponse, error) {
+	groups, err := s.groupRepo.ListGroups(userID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return newGroupResponses(groups), nil
+}
+
+func newGroupResponses(groups []models.Group) []models.GroupResponse {
+	responses := make([]models.GroupResponse, 0, len(groups))
+	for i := range groups {
+		responses = append(responses, newGroupResponse(&groups[i]))
+	}
+	return responses
+}
+
+func (s *GroupService) CreateGroup(actor Actor, req *models.GroupCreateRequest) (*models.GroupResponse, error) {
+	creator, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return nil, errors.New("creator not found")
+	}
+
+	// Subgroups can be created by anyone who may create groups in the parent
+	parentResource := models.Resource{}
+	if req.ParentID != nil {
+		if _, err := s.groupRepo.FindByID(*req.ParentID); err != nil {
+			return nil, errors.New("parent group not found")
+		}
+		parentResource = models.GroupResource(*req.ParentID)
+	}
+	if err := s.policy.Authorize(creator, models.PermGroupCreate, parentResource); err != nil {
+		return nil, err
+	}
+	if req.ParentID != nil {
+		if err := s.groupPolicies.CheckWritable(*req.ParentID); err != nil {
+			return nil, err
+		}
+		if err := s.checkDepth(*req.ParentID, 1); err != nil {
+			return nil, err
+		}
+	}
+
+	managedBy := req.ManagedBy
+	if s.policy.ScopeOf(creator, models.PermGroupCreate) != models.ScopeAllGroups {
+		// Users limited to their own groups create groups they manage
+		if managedBy != nil && *managedBy != creator.ID {
+			return nil, errors.New("you can only create groups you manage")
+		}
+		managedBy = &creator.ID
+	} else if managedBy != nil && *managedBy != creator.ID {
+		if err := s.policy.Authorize(creator, models.PermGroupAssignManager, models.Resource{}); err != nil {
+			return nil, err
+		}
+	}
+
+	if managedBy != nil {
+		if err := s.checkManager(*managedBy); err != nil {
+			return nil, err
+		}
+	}
+
+	group := &models.Group{
+		Name:        req.Name,
+		Description: req.Description,
+		CreatedBy:   creator.ID,
+		ManagedBy:   managedBy,
+		ParentID:    req.ParentID,
+		IsActive:    true,
+	}
+
+	if err := s.groupRepo.Create(group); err != nil {
+		return nil, err
+	}
+	if managedBy != nil {
+		if err := s.membershipRepo.Set(*managedBy, group.ID, models.MembershipLead); err != nil {
+			return nil, errors.New("group created but its lead could not be set")
+		}
+	}
+	if managedBy != nil || group.ParentID != nil {
+		s.policy.InvalidateMemberships()
+	}
+
+	s.audit.Record(actor, AuditEntry{
+		Action:        models.AuditCreate,
+		Resource:      models.AuditResourceGroup,
+		ResourceID:    group.ID,
+		TargetGroupID: &group.ID,
+		After:         group,
+	})
+
+	return s.GetGroup(group.ID)
+}
+
+func (s *GroupService) UpdateGroup(actor Actor, groupID uint, req *models.GroupUpdateRequest) (*models.GroupResponse, error) {
+	group, err := s.groupRepo.FindByID(groupID)
+	if err != nil {
+		return nil, errors.New("group not found")
+	}
+	before := *group
+
+	updater, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return nil, errors.New("updater not found")
+	}
+
+	if err := s.policy.Authorize(updater, models.PermGroupUpdate, models.GroupResource(group.ID)); err != nil {
+		return nil, err
+	}
+	if group.IsArchived() {
+		return nil, s.groupPolicies.CheckWritable(group.ID)
+	}
+
+	if req.Name != nil {
+		group.Name = *req.Name
+	}
+
+	if req.Description != nil {
+		group.Description = *req.Description
+	}
+
+	if req.ManagedBy != nil {
+		if err := s.policy.Authorize(updater, models.PermGroupAssignManager, models.GroupResource(group.ID)); err != nil {
+			return nil, err
+		}
+		if err := s.checkManager(*req.ManagedBy); err != nil {
+			return nil, err
+		}
+		group.ManagedBy = req.ManagedBy
+		group.Manager = nil
+	}
+
+	if req.IsActive != nil {
+		group.IsActive = *req.IsActive
+	}
+
+	if err := s.groupRepo.Update(group); err != nil {
+		return nil, err
+	}
+	if req.ManagedBy != nil {
+		if err := s.membershipRepo.Set(*req.ManagedBy, group.ID, models.MembershipLead); err != nil {
+			return nil, errors.New("group updated but its lead could not be set")
+		}
+		s.policy.InvalidateMemberships()
+	}
+
+	s.audit.Record(actor, AuditEntry{
+		Action:        models.AuditUpdate,
+		Resource:      models.AuditResourceGroup,
+		ResourceID:    group.ID,
+		TargetGroupID: &group.ID,
+		Before:        &before,
+		After:         group,
+	})
+
+	return s.GetGroup(group.ID)
+}
+
+// MoveGroup moves a group, with all its subgroups, under parentID, or to the
+// top level when parentID is nil. It needs group.update on the group and on
+// its new parent; moving to the top level needs it on all groups. The move
+// is recorded as a single audit entry.
+func (s *GroupService) MoveGroup(actor Actor, groupID uint, parentID *uint) (*models.GroupResponse, error) {
+	group, err := s.groupRepo.FindByID(groupID)
+	if err != nil {
+		return nil, errors.New("group not found")
+	}
+	before := *group
+
+	mover, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if err := s.policy.Authorize(mover, models.PermGroupUpdate, models.GroupResource(group.ID)); err != nil {
+		return nil, err
+	}
+	if group.IsArchived() {
+		return nil, s.groupPolicies.CheckWritable(group.ID)
+	}
+
+	subtree, err := s.groupRepo.SubtreeIDs([]uint{group.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	if parentID == nil {
+		if err := s.policy.Authorize(mover, models.PermGroupUpdate, models.Resource{Ungrouped: true}); err != nil {
+			return nil, errors.New("no permission to create top-level groups")
+		}
+	} else {
+		if _, err := s.groupRepo.FindByID(*parentID); err != nil {
+			return nil, errors.New("parent group not found")
+		}
+		if err := s.policy.Authorize(mover, models.PermGroupUpdate, models.GroupResource(*parentID)); err != nil {
+			return nil, err
+		}
+		if err := s.groupPolicies.CheckWritable(*parentID); err != nil {
+			return nil, err
+		}
+		for _, id := range subtree {
+			if id == *parentID {
+				return nil, errors.New("a group cannot be moved under itself or one of its subgroups")
+			}
+		}
+		height, err := s.groupRepo.Height(group.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.checkDepth(*parentID, height); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.groupRepo.SetParent(group.ID, parentID); err != nil {
+		return nil, errors.New("failed to move group")
+	}
+	s.policy.InvalidateMemberships()
+	group.ParentID = parentID
+	group.Parent = nil
+
+	s.audit.Record(actor, AuditEntry{
+		Action:        models.AuditMoveGroup,
+		Resource:      models.AuditResourceGroup,
+		ResourceID:    group.ID,
+		TargetGroupID: &group.ID,
+		Before:        &before,
+		After:         group,
+		Details: models.JSON{
+			"from_parent_id": before.ParentID,
+			"to_parent_id":   parentID,
+			"group_ids":      subtree,
+		},
+	})
+
+	return s.GetGroup(group.ID)
+}
+
+// checkDepth verifies that a subtree spanning levels levels fits below
+// parentID without exceeding models.MaxGroupDepth
+func (s *GroupService) checkDepth(parentID uint, levels int) error {
+	depth, err := s.groupRepo.Depth(parentID)
+	if err != nil {
+		return err
+	}
+	if depth+levels > models.MaxGroupDepth {
+		return errors.New("groups cannot be nested this deep")
+	}
+	return nil
+}
+
+// ArchiveGroup makes a group read-only and hides it from default lists.
+// Its accounts cannot be changed and nobody can join it, but members can
+// still be removed. Subgroups must be archived first.
+func (s *GroupService) ArchiveGroup(actor Actor, groupID uint) (*models.GroupResponse, error) {
+	group, err := s.authorizedGroup(actor, groupID, models.PermGroupDelete)
+	if err != nil {
+		return nil, err
+	}
+	if group.IsArchived() {
+		return nil, errors.New("group is already archived")
+	}
+
+	children, err := s.groupRepo.ListChildren(group.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range children {
+		if !child.IsArchived() {
+			return nil, errors.New("archive the subgroups of this group first")
+		}
+	}
+
+	now := time.Now()
+	if err := s.groupRepo.SetArchived(group.ID, &now); err != nil {
+		return nil, errors.New("failed to archive group")
+	}
+
+	s.audit.Record(actor, AuditEntry{
+		Action:        models.AuditArchive,
+		Resource:      models.AuditResourceGroup,
+		ResourceID:    group.ID,
+		TargetGroupID: &group.ID,
+	})
+
+	return s.GetGroup(group.ID)
+}
+
+// UnarchiveGroup makes an archived group writable and listed again. Its
+// parent must not be archived.
+func (s *GroupService) UnarchiveGroup(actor Actor, groupID uint) (*models.GroupResponse, error) {
+	group, err := s.authorizedGroup(actor, groupID, models.PermGroupDelete)
+	if err != nil {
+		return nil, err
+	}
+	if !group.IsArchived() {
+		return nil, errors.New("group is not archived")
+	}
+	if group.ParentID != nil {
+		if err := s.groupPolicies.CheckWritable(*group.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.groupRepo.SetArchived(group.ID, nil); err != nil {
+		return nil, errors.New("failed to unarchive group")
+	}
+
+	s.audit.Record(actor, AuditEntry{
+		Action:        models.AuditUnarchive,
+		Resource:      models.AuditResourceGroup,
+		ResourceID:    group.ID,
+		TargetGroupID: &group.ID,
+	})
+
+	return s.GetGroup(group.ID)
+}
+
+// MergeGroup moves everything in a group into the target group and archives
+// the emptied group, all in one transaction. With req.DryRun nothing is
+// changed and only the preview of what would move is returned. It needs
+// group.delete on the merged group and group.update on the target. The
+// target's quotas do not limit merges; the preview shows what it receives.
+func (s *GroupService) MergeGroup(actor Actor, groupID uint, req *models.GroupMergeRequest) (*models.GroupMergePreview, error) {
+	source, err := s.authorizedGroup(actor, groupID, models.PermGroupDelete)
+	if err != nil {
+		return nil, err
+	}
+	if source.IsArchived() {
+		return nil, s.groupPolicies.CheckWritable(source.ID)
+	}
+
+	preview, err := s.prepareMerge(actor, source, req.TargetGroupID)
+	if err != nil || req.DryRun {
+		return preview, err
+	}
+
+	if err := s.groupRepo.Merge(source.ID, req.TargetGroupID, false); err != nil {
+		return nil, errors.New("failed to merge groups")
+	}
+	s.policy.InvalidateMemberships()
+	preview.Applied = true
+
+	s.recordMerge(actor, source, preview)
+
+	return preview, nil
+}
+
+// DeleteGroup removes a group. Groups that still have members, accounts or
+// subgroups are refused so nothing is orphaned, unless reassignTo names a
+// group to merge them into first; leads and co-managers are removed with an
+// otherwise empty group.
+func (s *GroupService) DeleteGroup(actor Actor, groupID uint, reassignTo *uint) error {
+	group, err := s.authorizedGroup(actor, groupID, models.PermGroupDelete)
+	if err != nil {
+		return err
+	}
+
+	if reassignTo != nil {
+		preview, err := s.prepareMerge(actor, group, *reassignTo)
+		if err != nil {
+			return err
+		}
+		if err := s.groupRepo.Merge(group.ID, *reassignTo, true); err != nil {
+			return errors.New("failed to reassign and delete group")
+		}
+		s.policy.InvalidateMemberships()
+		preview.Applied = true
+
+		s.recordMerge(actor, group, preview)
+		s.recordDelete(actor, group, models.JSON{"reassigned_to": *reassignTo})
+		return nil
+	}
+
+	memberships, err := s.membershipRepo.ListByGroup(group.ID)
+	if err != nil {
+		return err
+	}
+	members := 0
+	for _, m := range memberships {
+		if m.Role == models.MembershipMember {
+			members++
+		}
+	}
+	accounts, err := s.groupRepo.GetGroupAccounts(group.ID)
+	if err != nil {
+		return err
+	}
+	if members > 0 || len(accounts) > 0 {
+		return errors.New("group still has users or accounts; give a group to reassign them to")
+	}
+	children, err := s.groupRepo.CountChildren(group.ID)
+	if err != nil {
+		return err
+	}
+	if children > 0 {
+		return errors.New("group still has subgroups; give a group to reassign them to")
+	}
+
+	if err := s.groupRepo.Delete(group.ID); err != nil {
+		return err
+	}
+	s.policy.InvalidateMemberships()
+
+	s.recordDelete(actor, group, nil)
+	return nil
+}
+
+// prepareMerge checks that source can be merged into targetID and lists
+// what would move
+func (s *GroupService) prepareMerge(actor Actor, source *models.Group, targetID uint) (*models.GroupMergePreview, error) {
+	if targetID == source.ID {
+		return nil, errors.New("a group cannot be merged into itself")
+	}
+	target, err := s.authorizedGroup(actor, targetID, models.PermGroupUpdate)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.groupPolicies.CheckWritable(target.ID); err != nil {
+		return nil, err
+	}
+
+	// Subgroups move under the target, so it must not be one of them and
+	// they must still fit below it
+	subtree, err := s.groupRepo.SubtreeIDs([]uint{source.ID})
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range subtree {
+		if id == target.ID {
+			return nil, errors.New("a group cannot be merged into one of its subgroups")
+		}
+	}
+	height, err := s.groupRepo.Height(source.ID)
+	if err != nil {
+		return nil, err
+	}
+	if height > 1 {
+		if err := s.checkDepth(target.ID, height-1); err != nil {
+			return nil, err
+		}
+	}
+
+	preview := &models.GroupMergePreview{
+		SourceGroupID: source.ID,
+		TargetGroupID: target.ID,
+		Accounts:      []models.MergedAccount{},
+		Members:       []models.MergedMember{},
+		Subgroups:     []models.MergedSubgroup{},
+	}
+
+	accounts, err := s.groupRepo.GetGroupAccounts(source.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, account := range accounts {
+		preview.Accounts = append(preview.Accounts, models.MergedAccount{ID: account.ID, AccountName: account.AccountName})
+	}
+
+	memberships, err := s.membershipRepo.ListByGroup(source.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range memberships {
+		member := models.MergedMember{UserID: m.UserID, Role: m.Role, NewRole: m.Role}
+		if m.User != nil {
+			member.Username = m.User.Username
+		}
+		if existing, err := s.membershipRepo.Find(m.UserID, target.ID); err == nil {
+			member.NewRole = existing.Role
+		} else if m.Role == models.MembershipLead {
+			member.NewRole = models.MembershipCoManager
+		}
+		preview.Members = append(preview.Members, member)
+	}
+
+	children, err := s.groupRepo.ListChildren(source.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range children {
+		preview.Subgroups = append(preview.Subgroups, models.MergedSubgroup{ID: child.ID, Name: child.Name})
+	}
+
+	if preview.Jobs, err = s.groupRepo.CountJobs(source.ID); err != nil {
+		return nil, err
+	}
+
+	return preview, nil
+}
+
+// authorizedGroup loads a group after checking that the actor may perform
+// action in it
+func (s *GroupService) authorizedGroup(actor Actor, groupID uint, action string) (*models.Group, error) {
+	group, err := s.groupRepo.FindByID(groupID)
+	if err != nil {
+		return nil, errors.New("group not found")
+	}
+
+	user, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if err := s.policy.Authorize(user, action, models.GroupResource(group.ID)); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// recordMerge audits a merge against both groups so the managers of each
+// see it
+func (s *GroupService) recordMerge(actor Actor, source *models.Group, preview *models.GroupMergePreview) {
+	accountIDs := make([]uint, 0, len(preview.Accounts))
+	for _, account := range preview.Accounts {
+		accountIDs = append(accountIDs, account.ID)
+	}
+	userIDs := make([]uint, 0, len(preview.Members))
+	for _, member := range preview.Members {
+		userIDs = append(userIDs, member.UserID)
+	}
+	subgroupIDs := make([]uint, 0, len(preview.Subgroups))
+	for _, subgroup := range preview.Subgroups {
+		subgroupIDs = append(subgroupIDs, subgroup.ID)
+	}
+
+	for _, targetGroupID := range []uint{preview.TargetGroupID, source.ID} {
+		targetGroupID := targetGroupID
+		s.audit.Record(actor, AuditEntry{
+			Action:        models.AuditMerge,
+			Resource:      models.AuditResourceGroup,
+			ResourceID:    source.ID,
+			TargetGroupID: &targetGroupID,
+			Details: models.JSON{
+				"from_group_id": source.ID,
+				"to_group_id":   preview.TargetGroupID,
+				"account_ids":   accountIDs,
+				"user_ids":      userIDs,
+				"subgroup_ids":  subgroupIDs,
+				"jobs":          preview.Jobs,
+			},
+		})
+	}
+}
+
+func (s *GroupService) recordDelete(actor Actor, group *models.Group, details models.JSON) {
+	s.audit.Record(actor, AuditEntry{
+		Action:        models.AuditDelete,
+		Resource:      models.AuditResourceGroup,
+		ResourceID:    group.ID,
+		TargetGroupID: &group.ID,
+		Before:        group,
+		Details:       details,
+	})
+}
+
+func (s *GroupService) GetGroupUsers(userID, groupID uint) ([]models.UserResponse, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	group, err := s.groupRepo.FindByID(groupID)
+	if err != nil {
+		return nil, errors.New("group not found")
+	}
+
+	if err := s.policy.Authorize(user, models.PermGroupRead, models.GroupResource(group.ID)); err != nil {
+		return nil, err
+	}
+
+	users, err := s.groupRepo.GetGroupUsers(group.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.UserResponse, 0, len(users))
+	for i := range users {
+		responses = append(responses, newUserResponse(&users[i]))
+	}
+
+	return responses, nil
+}
+
+// ListMembers returns the members of a group with their role in it
+func (s *GroupService) ListMembers(userID, groupID uint) ([]models.MembershipResponse, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if err := s.policy.Authorize(user, models.PermGroupRead, models.GroupResource(groupID)); err != nil {
+		return nil, err
+	}
+
+	memberships, err := s.membershipRepo.ListByGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.MembershipResponse, 0, len(memberships))
+	for _, m := range memberships {
+		response := models.MembershipResponse{
+			UserID:    m.UserID,
+			GroupID:   m.GroupID,
+			Role:      m.Role,
+			CreatedAt: m.CreatedAt,
+		}
+		if m.User != nil {
+			response.Username = m.User.Username
+			response.UserRole = m.User.Role
+		}
+		responses = append(responses, response)
+	}
+
+	return responses, nil
+}
+
+// SetMembership adds a user to a group or changes their role in it. Adding
+// members needs user.assign_group in the group. Appointing the lead needs
+// group.assign_manager; co-managers can also be appointed by the lead, so
+// they can delegate.
+func (s *GroupService) SetMembership(actor Actor, groupID, userID uint, role models.MembershipRole) error {
+	group, err := s.groupRepo.FindByID(groupID)
+	if err != nil {
+		return errors.New("group not found")
+	}
+
+	actingUser, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if group.IsArchived() {
+		return s.groupPolicies.CheckWritable(group.ID)
+	}
+
+	var previous models.MembershipRole
+	if m, err := s.membershipRepo.Find(user.ID, group.ID); err == nil {
+		previous = m.Role
+	}
+	if previous == role {
+		return nil
+	}
+
+	if err := s.authorizeMembership(actingUser, group, user, role); err != nil {
+		return err
+	}
+	if previous != "" {
+		if err := s.authorizeMembership(actingUser, group, user, previous); err != nil {
+			return err
+		}
+	}
+	if role.Manages() {
+		if err := s.checkManager(user.ID); err != nil {
+			return err
+		}
+	}
+	err = s.groupPolicies.AddOperator(group.ID, user, role, func(tx *gorm.DB) error {
+		return s.membershipRepo.WithTx(tx).Set(user.ID, group.ID, role)
+	})
+	var violation *GroupPolicyError
+	if errors.As(err, &violation) {
+		return err
+	}
+	if err != nil {
+		return errors.New("failed to update group membership")
+	}
+	s.policy.InvalidateMemberships()
+
+	s.audit.Record(actor, AuditEntry{
+		Action:        models.AuditSetMembership,
+		Resource:      models.AuditResourceGroup,
+		ResourceID:    group.ID,
+		TargetUserID:  &user.ID,
+		TargetGroupID: &group.ID,
+		Details:       models.JSON{"role": role, "previous_role": previous},
+	})
+
+	return nil
+}
+
+// RemoveMembership takes a user out of a group, with the same permissions
+// as giving them their current role in it
+func (s *GroupService) RemoveMembership(actor Actor, groupID, userID uint) error {
+	group, err := s.groupRepo.FindByID(groupID)
+	if err != nil {
+		return errors.New("group not found")
+	}
+
+	actingUser, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	membership, err := s.membershipRepo.Find(user.ID, group.ID)
+	if err != nil {
+		return errors.New("user is not a member of this group")
+	}
+
+	if err := s.authorizeMembership(actingUser, group, user, membership.Role); err != nil {
+		return err
+	}
+
+	if _, err := s.membershipRepo.Remove(user.ID, group.ID); err != nil {
+		return errors.New("failed to remove group membership")
+	}
+	s.policy.InvalidateMemberships()
+
+	s.audit.Record(actor, AuditEntry{
+		Action:        models.AuditRemoveMembership,
+		Resource:      models.AuditResourceGroup,
+		ResourceID:    group.ID,
+		TargetUserID:  &user.ID,
+		TargetGroupID: &group.ID,
+		Details:       models.JSON{"previous_role": membership.Role},
+	})
+
+	return nil
+}
+
+// authorizeMembership checks that actingUser may give user role in group
+func (s *GroupService) authorizeMembership(actingUser *models.User, group *models.Group, user *models.User, role models.MembershipRole) error {
+	if actingUser.ID != user.ID && !s.policy.CanAssignRole(actingUser, user.Role) {
+		return errors.New("no permission to manage users with role " + string(user.Role))
+	}
+
+	resource := models.GroupResource(group.ID)
+	switch role {
+	case models.MembershipLead:
+		return s.policy.Authorize(actingUser, models.PermGroupAssignManager, resource)
+	case models.MembershipCoManager:
+		if group.ManagedBy != nil && *group.ManagedBy == actingUser.ID {
+			return nil
+		}
+		return s.policy.Authorize(actingUser, models.PermGroupAssignManager, resource)
+	}
+	return s.policy.Authorize(actingUser, models.PermUserAssignGroup, resource)
+}
+
+// GetGroupStats returns the stats of a group, or with subtree of the group
+// and the subgroups the user may read rolled up together, crediting
+// snapshots to groups by attribution
+func (s *GroupService) GetGroupStats(userID, groupID uint, subtree bool, attribution models.Attribution) (*models.GroupStats, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	group, err := s.groupRepo.FindByID(groupID)
+	if err != nil {
+		return nil, errors.New("group not found")
+	}
+
+	if subtree {
+		groupIDs, err := s.policy.Subtree(user, models.PermAnalyticsRead, group.ID)
+		if err != nil {
+			return nil, err
+		}
+		return s.groupRepo.GetRollupStats(group.ID, groupIDs, attribution)
+	}
+
+	if err := s.policy.Authorize(user, models.PermAnalyticsRead, models.GroupResource(group.ID)); err != nil {
+		return nil, err
+	}
+
+	return s.groupRepo.GetGroupStats(group.ID, attribution)
+}
+
+// checkManager verifies that managerID belongs to a user who can manage
+// groups
+func (s *GroupService) checkManager(managerID uint) error {
+	manager, err := s.userRepo.FindByID(managerID)
+	if err != nil {
+		return errors.New("manager not found")
+	}
+	if !s.policy.Can(manager, models.PermGroupUpdate) {
+		return errors.New("group manager must be allowed to update groups")
+	}
+	return nil
+}
+
+func newGroupResponse(group *models.Group) models.GroupResponse {
+	response := models.GroupResponse{
+		ID:          group.ID,
+		Name:        group.Name,
+		Description: group.Description,
+		CreatedBy:   group.CreatedBy,
+		CreatorName: group.Creator.Username,
+		ManagedBy:   group.ManagedBy,
+		ParentID:    group.ParentID,
+		IsActive:    group.IsActive,
+		ArchivedAt:  group.ArchivedAt,
+		CreatedAt:   group.CreatedAt,
+	}
+
+	if group.Manager != nil {
+		managerName := group.Manager.Username
+		response.ManagerName = &managerName
+	}
+
+	return response
+}
@@ -0,0 +1,144 @@
+// internal/services/job_service.go
+package services
+
+import (
+	"errors"
+
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"github.com/katuhangugi/tiktok-account-system/internal/repositories"
+)
+
+type JobService struct {
+	jobRepo     *repositories.JobRepository
+	accountRepo *repositories.AccountRepository
+	userRepo    *repositories.UserRepository
+	groupRepo   *repositories.GroupRepository
+	policy      *PolicyService
+}
+
+func NewJobService(jobRepo *repositories.JobRepository, accountRepo *repositories.AccountRepository,
+	userRepo *repositories.UserRepository, groupRepo *repositories.GroupRepository, policy *PolicyService) *JobService {
+	return &JobService{
+		jobRepo:     jobRepo,
+		accountRepo: accountRepo,
+		userRepo:    userRepo,
+		groupRepo:   groupRepo,
+		policy:      policy,
+	}
+}
+
+// SubmitRefresh queues a refresh job for a whole group or a list of
+// accounts. Workers pick it up asynchronously.
+func (s *JobService) SubmitRefresh(userID uint, req *models.RefreshJobRequest) (*models.JobResponse, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	var accounts []models.TikTokAccount
+	if req.GroupID != nil {
+		if err := s.policy.Authorize(user, models.PermAccountRefresh, models.GroupResource(*req.GroupID)); err != nil {
+			return nil, err
+		}
+		groupAccounts, err := s.accountRepo.ListAccounts(*req.GroupID)
+		if err != nil {
+			return nil, err
+		}
+		for _, account := range groupAccounts {
+			if account.IsActive {
+				accounts = append(accounts, account)
+			}
+		}
+	} else if len(req.AccountIDs) > 0 {
+		for _, accountID := range req.AccountIDs {
+			account, err := s.accountRepo.FindByID(accountID)
+			if err != nil {
+				return nil, errors.New("account not found")
+			}
+			if err := s.policy.Authorize(user, models.PermAccountRefresh, models.GroupResource(account.GroupID)); err != nil {
+				return nil, errors.New("no access to one or more accounts")
+			}
+			accounts = append(accounts, *account)
+		}
+	} else {
+		return nil, errors.New("group_id or account_ids is required")
+	}
+
+	if len(accounts) == 0 {
+		return nil, errors.New("no accounts to refresh")
+	}
+
+	job := &models.Job{
+		Type:      models.JobTypeRefresh,
+		Status:    models.JobQueued,
+		CreatedBy: userID,
+		GroupID:   req.GroupID,
+		Total:     len(accounts),
+	}
+	for _, account := range accounts {
+		job.Items = append(job.Items, models.JobItem{
+			TikTokAccountID: account.ID,
+			AccountName:     account.AccountName,
+			Status:          models.JobItemPending,
+		})
+	}
+
+	if err := s.jobRepo.Create(job); err != nil {
+		return nil, err
+	}
+
+	return job.ToResponse(), nil
+}
+
+func (s *JobService) GetJob(userID, jobID uint) (*models.JobResponse, error) {
+	job, err := s.findAccessibleJob(userID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	return job.ToResponse(), nil
+}
+
+func (s *JobService) CancelJob(userID, jobID uint) (*models.JobResponse, error) {
+	job, err := s.findAccessibleJob(userID, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Status == models.JobCompleted || job.Status == models.JobCancelled {
+		return nil, errors.New("job has already finished")
+	}
+
+	if err := s.jobRepo.Cancel(job.ID); err != nil {
+		return nil, err
+	}
+
+	return s.GetJob(userID, jobID)
+}
+
+// findAccessibleJob loads a job visible to the user: jobs they submitted,
+// and jobs in groups where they hold job.read
+func (s *JobService) findAccessibleJob(userID, jobID uint) (*models.Job, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	job, err := s.jobRepo.FindByID(jobID)
+	if err != nil {
+		return nil, errors.New("job not found")
+	}
+
+	if job.CreatedBy == user.ID {
+		return job, nil
+	}
+
+	resource := models.Resource{Ungrouped: job.GroupID == nil}
+	if job.GroupID != nil {
+		resource = models.GroupResource(*job.GroupID)
+	}
+	if err := s.policy.Authorize(user, models.PermJobRead, resource); err != nil {
+		return nil, errors.New("no access to this job")
+	}
+
+	return job, nil
+}
@@ -0,0 +1,293 @@
+// internal/services/policy_service.go
+package services
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"github.com/katuhangugi/tiktok-account-system/internal/repositories"
+)
+
+// roleCacheTTL bounds how long a role change made by another server
+// instance takes to apply here
+const roleCacheTTL = 30 * time.Second
+
+// membershipTTL bounds how long a membership change made by another server
+// instance takes to apply here
+const membershipTTL = 10 * time.Second
+
+// cachedMemberships are the group memberships of one user, and the groups
+// they manage with every group nested below those
+type cachedMemberships struct {
+	memberships []models.GroupMembership
+	managed     []uint
+	loadedAt    time.Time
+}
+
+// PolicyService decides what users may do. Each role maps permissions to a
+// scope: the groups the user belongs to, those and the subgroups of the
+// groups they lead or co-manage, or all groups. Role definitions are
+// cached and reloaded after roleCacheTTL or when changed through
+// RoleService; memberships are cached for membershipTTL.
+type PolicyService struct {
+	roleRepo       *repositories.RoleRepository
+	membershipRepo *repositories.MembershipRepository
+	groupRepo      *repositories.GroupRepository
+
+	mu       sync.RWMutex
+	roles    map[models.Role]*models.RoleDefinition
+	loadedAt time.Time
+
+	membershipMu sync.Mutex
+	memberships  map[uint]cachedMemberships
+}
+
+func NewPolicyService(roleRepo *repositories.RoleRepository, membershipRepo *repositories.MembershipRepository,
+	groupRepo *repositories.GroupRepository) *PolicyService {
+	return &PolicyService{
+		roleRepo:       roleRepo,
+		membershipRepo: membershipRepo,
+		groupRepo:      groupRepo,
+		memberships:    make(map[uint]cachedMemberships),
+	}
+}
+
+// Authorize returns an error unless user may perform action on resource.
+// A resource in several groups needs access to each of them.
+func (p *PolicyService) Authorize(user *models.User, action string, resource models.Resource) error {
+	scope := p.ScopeOf(user, action)
+	if scope == "" {
+		return errors.New("missing permission " + action)
+	}
+
+	if scope == models.ScopeAllGroups {
+		return nil
+	}
+	if resource.Ungrouped {
+		return errors.New("no access to resources outside your groups")
+	}
+	if len(resource.GroupIDs) == 0 {
+		return nil
+	}
+
+	groups, err := p.GroupScope(user, action)
+	if err != nil {
+		return err
+	}
+	for _, groupID := range resource.GroupIDs {
+		if !groups.Contains(groupID) {
+			return errors.New("no access to this group")
+		}
+	}
+	return nil
+}
+
+// ScopeOf returns the scope of action for user, or "" when not granted
+func (p *PolicyService) ScopeOf(user *models.User, action string) models.PermissionScope {
+	role := p.role(user.Role)
+	if role == nil {
+		return ""
+	}
+
+	scope := role.Permissions[action]
+	if scope.Rank() == 0 {
+		return ""
+	}
+	return scope
+}
+
+// Can reports whether user holds action at any scope
+func (p *PolicyService) Can(user *models.User, action string) bool {
+	return p.ScopeOf(user, action) != ""
+}
+
+// GroupScope lists the groups in which user may perform action, for
+// filtering queries
+func (p *PolicyService) GroupScope(user *models.User, action string) (*models.GroupScope, error) {
+	scope := p.ScopeOf(user, action)
+	if scope == "" {
+		return nil, errors.New("missing permission " + action)
+	}
+	if scope == models.ScopeAllGroups {
+		return &models.GroupScope{All: true}, nil
+	}
+
+	access, err := p.userMemberships(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.GroupScope{}
+	seen := make(map[uint]bool)
+	add := func(groupID uint) {
+		if !seen[groupID] {
+			seen[groupID] = true
+			result.GroupIDs = append(result.GroupIDs, groupID)
+		}
+	}
+	for _, m := range access.memberships {
+		add(m.GroupID)
+	}
+	if scope == models.ScopeManagedGroups {
+		for _, groupID := range access.managed {
+			add(groupID)
+		}
+	}
+
+	return result, nil
+}
+
+// Subtree returns rootID and the groups nested below it in which user may
+// perform action, for rolling up a group's subtree. It fails unless user
+// may perform action in rootID.
+func (p *PolicyService) Subtree(user *models.User, action string, rootID uint) ([]uint, error) {
+	if err := p.Authorize(user, action, models.GroupResource(rootID)); err != nil {
+		return nil, err
+	}
+
+	groupIDs, err := p.groupRepo.SubtreeIDs([]uint{rootID})
+	if err != nil {
+		return nil, errors.New("failed to load subgroups")
+	}
+
+	scope, err := p.GroupScope(user, action)
+	if err != nil {
+		return nil, err
+	}
+	if scope.All {
+		return groupIDs, nil
+	}
+
+	visible := []uint{rootID}
+	for _, groupID := range groupIDs {
+		if groupID != rootID && scope.Contains(groupID) {
+			visible = append(visible, groupID)
+		}
+	}
+	return visible, nil
+}
+
+// CanAssignRole reports whether user may give role to someone, or manage
+// users who hold it. Holders of role.manage may assign any role; anyone
+// else only roles below their own, whose permissions they hold themselves
+// at the same or a wider scope.
+func (p *PolicyService) CanAssignRole(user *models.User, role models.Role) bool {
+	target := p.role(role)
+	if target == nil {
+		return false
+	}
+	if p.Can(user, models.PermRoleManage) {
+		return true
+	}
+	if role == user.Role {
+		return false
+	}
+
+	for action, scope := range target.Permissions {
+		if p.ScopeOf(user, action).Rank() < scope.Rank() {
+			return false
+		}
+	}
+	return true
+}
+
+// RoleExists reports whether role is defined
+func (p *PolicyService) RoleExists(role models.Role) bool {
+	return p.role(role) != nil
+}
+
+// RolesWith lists the roles that grant action at any scope
+func (p *PolicyService) RolesWith(action string) []models.Role {
+	var roles []models.Role
+	for name, role := range p.roleDefinitions() {
+		if role.Permissions[action].Rank() > 0 {
+			roles = append(roles, name)
+		}
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i] < roles[j] })
+	return roles
+}
+
+// Invalidate drops the cached roles so the next check reloads them
+func (p *PolicyService) Invalidate() {
+	p.mu.Lock()
+	p.loadedAt = time.Time{}
+	p.mu.Unlock()
+}
+
+// InvalidateMemberships drops the cached memberships, after users joined
+// or left groups or changed their role in one, or groups were created,
+// moved or deleted
+func (p *PolicyService) InvalidateMemberships() {
+	p.membershipMu.Lock()
+	p.memberships = make(map[uint]cachedMemberships)
+	p.membershipMu.Unlock()
+}
+
+func (p *PolicyService) userMemberships(userID uint) (cachedMemberships, error) {
+	p.membershipMu.Lock()
+	cached, ok := p.memberships[userID]
+	p.membershipMu.Unlock()
+	if ok && time.Since(cached.loadedAt) < membershipTTL {
+		return cached, nil
+	}
+
+	memberships, err := p.membershipRepo.ListByUser(userID)
+	if err != nil {
+		return cachedMemberships{}, errors.New("failed to load group memberships")
+	}
+
+	// Managers see everything nested below the groups they manage
+	var managedRoots []uint
+	for _, m := range memberships {
+		if m.Role.Manages() {
+			managedRoots = append(managedRoots, m.GroupID)
+		}
+	}
+	managed, err := p.groupRepo.SubtreeIDs(managedRoots)
+	if err != nil {
+		return cachedMemberships{}, errors.New("failed to load managed groups")
+	}
+
+	cached = cachedMemberships{memberships: memberships, managed: managed, loadedAt: time.Now()}
+	p.membershipMu.Lock()
+	p.memberships[userID] = cached
+	p.membershipMu.Unlock()
+
+	return cached, nil
+}
+
+func (p *PolicyService) role(name models.Role) *models.RoleDefinition {
+	return p.roleDefinitions()[name]
+}
+
+// roleDefinitions returns the cached roles, reloading them when stale. The
+// map is replaced on reload and never modified, so callers may keep reading
+// it.
+func (p *PolicyService) roleDefinitions() map[models.Role]*models.RoleDefinition {
+	p.mu.RLock()
+	fresh := time.Since(p.loadedAt) < roleCacheTTL
+	roles := p.roles
+	p.mu.RUnlock()
+	if fresh {
+		return roles
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if time.Since(p.loadedAt) >= roleCacheTTL {
+		// On a failed reload the previous roles stay in use until the next
+		// attempt
+		if defs, err := p.roleRepo.List(); err == nil {
+			p.roles = make(map[models.Role]*models.RoleDefinition, len(defs))
+			for i := range defs {
+				p.roles[models.Role(defs[i].Name)] = &defs[i]
+			}
+			p.loadedAt = time.Now()
+		}
+	}
+	return p.roles
+}
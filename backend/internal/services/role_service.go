@@ -0,0 +1,165 @@
+// internal/services/role_service.go
+package services
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"github.com/katuhangugi/tiktok-account-system/internal/repositories"
+)
+
+var roleNameRe = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// RoleService lets super admins define roles without a code change
+type RoleService struct {
+	roleRepo *repositories.RoleRepository
+	userRepo *repositories.UserRepository
+	policy   *PolicyService
+	audit    *AuditService
+}
+
+func NewRoleService(roleRepo *repositories.RoleRepository, userRepo *repositories.UserRepository,
+	policy *PolicyService, audit *AuditService) *RoleService {
+	return &RoleService{roleRepo: roleRepo, userRepo: userRepo, policy: policy, audit: audit}
+}
+
+// ListRoles returns every role with its permissions, and the permissions a
+// role can be granted
+func (s *RoleService) ListRoles() ([]models.RoleDefinition, error) {
+	return s.roleRepo.List()
+}
+
+func (s *RoleService) CreateRole(actor Actor, req *models.RoleCreateRequest) (*models.RoleDefinition, error) {
+	if err := s.authorize(actor); err != nil {
+		return nil, err
+	}
+
+	if !roleNameRe.MatchString(req.Name) {
+		return nil, errors.New("role name must be lowercase letters, digits and underscores")
+	}
+	if _, err := s.roleRepo.FindByName(req.Name); err == nil {
+		return nil, errors.New("role already exists")
+	}
+	if err := validatePermissions(req.Permissions); err != nil {
+		return nil, err
+	}
+
+	role := &models.RoleDefinition{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	}
+	if err := s.roleRepo.Create(role); err != nil {
+		return nil, err
+	}
+	s.policy.Invalidate()
+
+	s.audit.Record(actor, AuditEntry{
+		Action:   models.AuditCreate,
+		Resource: models.AuditResourceRole,
+		After:    role,
+		Details:  models.JSON{"role": role.Name},
+	})
+
+	return role, nil
+}
+
+// UpdateRole changes a role's description or permissions. Users holding
+// the role are affected on their next request.
+func (s *RoleService) UpdateRole(actor Actor, name string, req *models.RoleUpdateRequest) (*models.RoleDefinition, error) {
+	if err := s.authorize(actor); err != nil {
+		return nil, err
+	}
+
+	role, err := s.roleRepo.FindByName(name)
+	if err != nil {
+		return nil, errors.New("role not found")
+	}
+	if models.Role(role.Name) == models.RoleSuperAdmin {
+		return nil, errors.New("the super_admin role cannot be changed")
+	}
+	before := *role
+
+	if req.Description != nil {
+		role.Description = *req.Description
+	}
+	if req.Permissions != nil {
+		if err := validatePermissions(req.Permissions); err != nil {
+			return nil, err
+		}
+		role.Permissions = req.Permissions
+	}
+
+	if err := s.roleRepo.Update(role); err != nil {
+		return nil, err
+	}
+	s.policy.Invalidate()
+
+	s.audit.Record(actor, AuditEntry{
+		Action:   models.AuditUpdate,
+		Resource: models.AuditResourceRole,
+		Before:   &before,
+		After:    role,
+		Details:  models.JSON{"role": role.Name},
+	})
+
+	return role, nil
+}
+
+// DeleteRole removes a custom role that no user holds
+func (s *RoleService) DeleteRole(actor Actor, name string) error {
+	if err := s.authorize(actor); err != nil {
+		return err
+	}
+
+	role, err := s.roleRepo.FindByName(name)
+	if err != nil {
+		return errors.New("role not found")
+	}
+	if role.Builtin {
+		return errors.New("builtin roles cannot be deleted")
+	}
+
+	count, err := s.userRepo.CountByRole(role.Name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("role is still assigned to users")
+	}
+
+	if err := s.roleRepo.Delete(role.Name); err != nil {
+		return err
+	}
+	s.policy.Invalidate()
+
+	s.audit.Record(actor, AuditEntry{
+		Action:   models.AuditDelete,
+		Resource: models.AuditResourceRole,
+		Before:   role,
+		Details:  models.JSON{"role": role.Name},
+	})
+
+	return nil
+}
+
+func (s *RoleService) authorize(actor Actor) error {
+	user, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	return s.policy.Authorize(user, models.PermRoleManage, models.Resource{})
+}
+
+func validatePermissions(permissions models.Permissions) error {
+	for action, scope := range permissions {
+		if !models.IsPermission(action) {
+			return errors.New("unknown permission " + action)
+		}
+		if scope.Rank() == 0 {
+			return errors.New("invalid scope for " + action)
+		}
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"errors"
+	"math"
 	"time"
 
 	"github.com/katuhangugi/tiktok-account-system/internal/models"
@@ -33,29 +34,9 @@ func NewTikTokService(
 	}
 }
 
-// TikTokData represents the response structure from TikTok API
-type TikTokData struct {
-	AccountName  string `json:"account_name"`
-	Nickname     string `json:"nickname"`
-	UID          string `json:"uid"`
-	Region       string `json:"region"`
-	Followers    int64  `json:"follower_count"`
-	Following    int64  `json:"following_count"`
-	Likes        int64  `json:"total_likes"`
-	Videos       int64  `json:"video_count"`
-	DailyUploads int64  `json:"daily_uploads"`
-}
-
-// TikTokClientInterface defines the contract for TikTok API clients
-type TikTokClientInterface interface {
-	GetAccountData(accountName string) (*TikTokData, error)
-	ValidateAccount(accountName string) (bool, error)
-	GetStatus() (string, error)
-}
-
 // FetchAccountData retrieves account data from TikTok API and stores it
 func (s *TikTokService) FetchAccountData(account *models.TikTokAccount) error {
-	data, err := s.tikTokClient.GetAccountData(account.AccountName)
+	data, err := s.getAccountData(account)
 	if err != nil {
 		s.log.Error("Failed to fetch account data",
 			"accountID", account.ID,
@@ -67,15 +48,33 @@ func (s *TikTokService) FetchAccountData(account *models.TikTokAccount) error {
 	return s.StoreTikTokData(context.Background(), account, data)
 }
 
+// getAccountData asks the client for the account, letting group-aware
+// clients pick the data source configured for the account's group
+func (s *TikTokService) getAccountData(account *models.TikTokAccount) (*models.TikTokData, error) {
+	if client, ok := s.tikTokClient.(repositories.GroupAwareTikTokClient); ok {
+		return client.GetGroupAccountData(account.GroupID, account.AccountName)
+	}
+	return s.tikTokClient.GetAccountData(account.AccountName)
+}
+
 // StoreTikTokData persists TikTok data to the database
-func (s *TikTokService) StoreTikTokData(ctx context.Context, account *models.TikTokAccount, data *TikTokData) error {
-	// Update account basic info if changed
-	if account.Nickname != data.Nickname ||
-		account.UID != data.UID ||
-		account.Location != data.Region {
+func (s *TikTokService) StoreTikTokData(ctx context.Context, account *models.TikTokAccount, data *models.TikTokData) error {
+	// Update account basic info if changed; providers that don't report a
+	// field leave it empty, which must not wipe the stored value
+	changed := false
+	if data.Nickname != "" && account.Nickname != data.Nickname {
 		account.Nickname = data.Nickname
+		changed = true
+	}
+	if data.UID != "" && account.UID != data.UID {
 		account.UID = data.UID
+		changed = true
+	}
+	if data.Region != "" && account.Location != data.Region {
 		account.Location = data.Region
+		changed = true
+	}
+	if changed {
 		if err := s.accountRepo.Update(account); err != nil {
 			s.log.Error("Failed to update account",
 				"accountID", account.ID,
@@ -88,13 +87,19 @@ func (s *TikTokService) StoreTikTokData(ctx context.Context, account *models.Tik
 	analytics := &models.DailyAnalytics{
 		TikTokAccountID: account.ID,
 		Date:            time.Now().UTC().Truncate(24 * time.Hour),
-		FollowerCount:   data.Followers,
-		FollowingCount:  data.Following,
+		FollowerCount:   int(data.Followers),
+		FollowingCount:  int(data.Following),
 		TotalLikes:      data.Likes,
-		VideoCount:      data.Videos,
-		DailyUploads:    data.DailyUploads,
+		VideoCount:      int(data.Videos),
 	}
 
+	// Derive uploads from the last snapshot before today, if any
+	previous, err := s.analyticsRepo.GetPreviousSnapshot(account.ID, analytics.Date)
+	if err != nil {
+		previous = nil
+	}
+	deriveUploads(analytics, previous)
+
 	// Check if analytics already exists for this date
 	existing, err := s.analyticsRepo.GetByAccountAndDate(account.ID, analytics.Date)
 	if err == nil && existing != nil {
@@ -104,6 +109,8 @@ func (s *TikTokService) StoreTikTokData(ctx context.Context, account *models.Tik
 		existing.TotalLikes = analytics.TotalLikes
 		existing.VideoCount = analytics.VideoCount
 		existing.DailyUploads = analytics.DailyUploads
+		existing.UploadsEstimated = analytics.UploadsEstimated
+		existing.VideosDeleted = analytics.VideosDeleted
 		return s.analyticsRepo.Update(existing)
 	}
 
@@ -111,6 +118,65 @@ func (s *TikTokService) StoreTikTokData(ctx context.Context, account *models.Tik
 	return s.analyticsRepo.Create(analytics)
 }
 
+// deriveUploads sets the upload fields of snapshot from the change in video
+// count since previous, the account's latest earlier snapshot. A change over
+// a gap of several days is kept whole on this snapshot, so sums of uploads
+// still match the video count, and flagged as estimated since the days of
+// the uploads are unknown; a drop means videos were deleted, which hides any
+// uploads, so it is flagged and counted as zero.
+func deriveUploads(snapshot, previous *models.DailyAnalytics) {
+	snapshot.DailyUploads = 0
+	snapshot.UploadsEstimated = false
+	snapshot.VideosDeleted = false
+	if previous == nil {
+		return
+	}
+
+	delta := snapshot.VideoCount - previous.VideoCount
+	if delta < 0 {
+		snapshot.VideosDeleted = true
+		return
+	}
+
+	snapshot.DailyUploads = delta
+	days := int(math.Round(snapshot.Date.Sub(previous.Date).Hours() / 24))
+	snapshot.UploadsEstimated = days > 1
+}
+
+// RecomputeUploads re-derives the upload fields of every stored snapshot of
+// the account, oldest first, and returns how many snapshots changed. With
+// dryRun nothing is written.
+func (s *TikTokService) RecomputeUploads(accountID uint, dryRun bool) (int, error) {
+	snapshots, err := s.analyticsRepo.ListByAccount(accountID)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	var previous *models.DailyAnalytics
+	for i := range snapshots {
+		snapshot := &snapshots[i]
+		before := *snapshot
+		deriveUploads(snapshot, previous)
+		previous = snapshot
+
+		if snapshot.DailyUploads == before.DailyUploads &&
+			snapshot.UploadsEstimated == before.UploadsEstimated &&
+			snapshot.VideosDeleted == before.VideosDeleted {
+			continue
+		}
+		changed++
+		if dryRun {
+			continue
+		}
+		if err := s.analyticsRepo.UpdateUploads(snapshot); err != nil {
+			return changed, err
+		}
+	}
+
+	return changed, nil
+}
+
 // ValidateAccount checks if a TikTok account exists
 func (s *TikTokService) ValidateAccount(accountName string) (bool, error) {
 	valid, err := s.tikTokClient.ValidateAccount(accountName)
@@ -146,31 +212,3 @@ func (s *TikTokService) RefreshAccountData(accountID uint) error {
 
 	return s.FetchAccountData(account)
 }
-
-// BatchRefresh refreshes data for all accounts in a group
-func (s *TikTokService) BatchRefresh(groupID uint) error {
-	accounts, err := s.accountRepo.ListAccounts(groupID)
-	if err != nil {
-		s.log.Error("Failed to list accounts",
-			"groupID", groupID,
-			"error", err)
-		return err
-	}
-
-	var errs []error
-	for _, account := range accounts {
-		if err := s.FetchAccountData(&account); err != nil {
-			s.log.Warn("Failed to refresh account data",
-				"accountID", account.ID,
-				"accountName", account.AccountName,
-				"error", err)
-			errs = append(errs, err)
-			continue
-		}
-	}
-
-	if len(errs) > 0 {
-		return errors.New("some accounts failed to refresh")
-	}
-	return nil
-}
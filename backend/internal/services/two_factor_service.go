@@ -0,0 +1,249 @@
+// internal/services/two_factor_service.go
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/katuhangugi/tiktok-account-system/internal/config"
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+	"github.com/katuhangugi/tiktok-account-system/internal/repositories"
+	"github.com/katuhangugi/tiktok-account-system/internal/utils"
+	"github.com/katuhangugi/tiktok-account-system/pkg/totp"
+	"gorm.io/gorm"
+)
+
+const (
+	// recoveryCodeCount is how many recovery codes each enrollment gets
+	recoveryCodeCount = 10
+	// totpSkew accepts codes from one period before or after now, for
+	// clock drift between server and phone
+	totpSkew = 1
+)
+
+// recoveryAlphabet leaves out i, l, o and 1, which are easily confused. Its
+// 32 characters divide 256, so picking by byte value is unbiased.
+const recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz023456789"
+
+// TwoFactorService manages TOTP enrollment and checks second-factor codes
+type TwoFactorService struct {
+	repo     *repositories.TwoFactorRepository
+	userRepo *repositories.UserRepository
+	config   *config.Config
+	audit    *AuditService
+}
+
+func NewTwoFactorService(repo *repositories.TwoFactorRepository, userRepo *repositories.UserRepository,
+	config *config.Config, audit *AuditService) *TwoFactorService {
+	return &TwoFactorService{repo: repo, userRepo: userRepo, config: config, audit: audit}
+}
+
+// Required reports whether the configured policy makes user's role use 2FA
+func (s *TwoFactorService) Required(user *models.User) bool {
+	for _, role := range s.config.Auth.TwoFactorRoles {
+		if role == string(user.Role) {
+			return true
+		}
+	}
+	return false
+}
+
+// Enabled reports whether the user has a confirmed TOTP secret
+func (s *TwoFactorService) Enabled(userID uint) (bool, error) {
+	secret, err := s.repo.FindSecret(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return secret.ConfirmedAt != nil, nil
+}
+
+func (s *TwoFactorService) Status(userID uint) (*models.TwoFactorStatus, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	enabled, err := s.Enabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.TwoFactorStatus{Enabled: enabled, Required: s.Required(user)}
+	if enabled {
+		left, err := s.repo.CountRecoveryCodes(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		status.RecoveryCodesLeft = int(left)
+	}
+
+	return status, nil
+}
+
+// Enroll creates a new pending secret and recovery codes for the actor,
+// replacing any earlier pending enrollment. The secret protects logins once
+// a first code is verified.
+func (s *TwoFactorService) Enroll(actor Actor) (*models.TwoFactorEnrollment, error) {
+	user, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	enabled, err := s.Enabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, errors.New("failed to generate secret")
+	}
+	encrypted, err := utils.EncryptString(secret, s.config.Auth.TwoFactorKey)
+	if err != nil {
+		return nil, errors.New("two-factor authentication is not configured")
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		if codes[i], err = newRecoveryCode(); err != nil {
+			return nil, errors.New("failed to generate recovery codes")
+		}
+		hashes[i] = utils.HashToken(normalizeRecoveryCode(codes[i]))
+	}
+
+	record := &models.TwoFactorSecret{UserID: user.ID, Secret: encrypted}
+	if err := s.repo.SaveEnrollment(record, hashes); err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(s.config.Auth.TwoFactorIssuer, user.Username, secret),
+		RecoveryCodes:   codes,
+	}, nil
+}
+
+// Confirm verifies the first code of a pending enrollment, turning 2FA on
+func (s *TwoFactorService) Confirm(actor Actor, code string) error {
+	secret, err := s.repo.FindSecret(actor.UserID)
+	if err != nil {
+		return errors.New("no two-factor enrollment in progress")
+	}
+	if secret.ConfirmedAt != nil {
+		return errors.New("two-factor authentication is already enabled")
+	}
+
+	ok, err := s.checkTOTP(secret, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("invalid two-factor code")
+	}
+
+	s.audit.Record(actor, AuditEntry{
+		Action:       models.AuditTwoFactorEnable,
+		Resource:     models.AuditResourceUser,
+		ResourceID:   actor.UserID,
+		TargetUserID: &actor.UserID,
+	})
+
+	return nil
+}
+
+// Disable turns 2FA off after checking a current code. Users whose role
+// requires 2FA cannot turn it off; an admin reset is needed instead.
+func (s *TwoFactorService) Disable(actor Actor, code string) error {
+	user, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if s.Required(user) {
+		return errors.New("two-factor authentication is required for your role")
+	}
+
+	ok, _, err := s.VerifyCode(user.ID, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("invalid two-factor code")
+	}
+
+	if err := s.repo.Delete(user.ID); err != nil {
+		return err
+	}
+
+	s.audit.Record(actor, AuditEntry{
+		Action:        models.AuditTwoFactorDisable,
+		Resource:      models.AuditResourceUser,
+		ResourceID:    user.ID,
+		TargetUserID:  &user.ID,
+		TargetGroupID: user.GroupID,
+	})
+
+	return nil
+}
+
+// VerifyCode checks a TOTP code, or an unused recovery code once 2FA is
+// confirmed. A TOTP code confirms a pending enrollment. The second result
+// reports whether a recovery code was spent.
+func (s *TwoFactorService) VerifyCode(userID uint, code string) (bool, bool, error) {
+	secret, err := s.repo.FindSecret(userID)
+	if err != nil {
+		return false, false, errors.New("two-factor authentication is not set up")
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits && strings.Trim(code, "0123456789") == "" {
+		ok, err := s.checkTOTP(secret, code)
+		return ok, false, err
+	}
+
+	if secret.ConfirmedAt == nil {
+		return false, false, nil
+	}
+	ok, err := s.repo.UseRecoveryCode(userID, utils.HashToken(normalizeRecoveryCode(code)))
+	return ok, ok, err
+}
+
+// checkTOTP validates code against the secret and spends its time step
+func (s *TwoFactorService) checkTOTP(secret *models.TwoFactorSecret, code string) (bool, error) {
+	plain, err := utils.DecryptString(secret.Secret, s.config.Auth.TwoFactorKey)
+	if err != nil {
+		return false, errors.New("failed to read two-factor secret")
+	}
+
+	step, ok := totp.Validate(plain, strings.TrimSpace(code), time.Now(), totpSkew)
+	if !ok {
+		return false, nil
+	}
+	return s.repo.UseStep(secret.UserID, step)
+}
+
+// newRecoveryCode returns a random code formatted as xxxxx-xxxxx
+func newRecoveryCode() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = recoveryAlphabet[int(b)%len(recoveryAlphabet)]
+	}
+	return string(buf[:5]) + "-" + string(buf[5:]), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
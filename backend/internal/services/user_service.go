@@ -7,33 +7,53 @@ import (
 	"github.com/katuhangugi/tiktok-account-system/internal/models"
 	"github.com/katuhangugi/tiktok-account-system/internal/repositories"
 	"github.com/katuhangugi/tiktok-account-system/internal/utils"
+	"gorm.io/gorm"
 )
 
 type UserService struct {
-	userRepo  *repositories.UserRepository
-	groupRepo *repositories.GroupRepository
+	userRepo      *repositories.UserRepository
+	groupRepo     *repositories.GroupRepository
+	tokenRepo     *repositories.RefreshTokenRepository
+	twoFactorRepo *repositories.TwoFactorRepository
+	throttleRepo  *repositories.LoginThrottleRepository
+	policy        *PolicyService
+	groupPolicies *GroupPolicyService
+	audit         *AuditService
 }
 
-func NewUserService(userRepo *repositories.UserRepository, groupRepo *repositories.GroupRepository) *UserService {
-	return &UserService{userRepo: userRepo, groupRepo: groupRepo}
+func NewUserService(userRepo *repositories.UserRepository, groupRepo *repositories.GroupRepository,
+	tokenRepo *repositories.RefreshTokenRepository, twoFactorRepo *repositories.TwoFactorRepository,
+	throttleRepo *repositories.LoginThrottleRepository, policy *PolicyService, groupPolicies *GroupPolicyService,
+	audit *AuditService) *UserService {
+	return &UserService{
+		userRepo:      userRepo,
+		groupRepo:     groupRepo,
+		tokenRepo:     tokenRepo,
+		twoFactorRepo: twoFactorRepo,
+		throttleRepo:  throttleRepo,
+		policy:        policy,
+		groupPolicies: groupPolicies,
+		audit:         audit,
+	}
 }
 
-func (s *UserService) CreateUser(creatorID uint, req *models.UserCreateRequest) (*models.UserResponse, error) {
+func (s *UserService) CreateUser(actor Actor, req *models.UserCreateRequest) (*models.UserResponse, error) {
 	// Check if creator has permission to create this role
+	creatorID := actor.UserID
 	creator, err := s.userRepo.FindByID(creatorID)
 	if err != nil {
 		return nil, errors.New("creator not found")
 	}
 
-	// Super admin can create any role
-	if creator.Role != models.RoleSuperAdmin {
-		// Manager can only create operators
-		if creator.Role == models.RoleManager && req.Role != models.RoleOperator {
-			return nil, errors.New("managers can only create operators")
-		}
-		// Operators cannot create users
-		if creator.Role == models.RoleOperator {
-			return nil, errors.New("operators cannot create users")
+	if err := s.policy.Authorize(creator, models.PermUserCreate, models.Resource{}); err != nil {
+		return nil, err
+	}
+	if err := s.checkRole(creator, req.Role); err != nil {
+		return nil, err
+	}
+	if req.GroupID != nil {
+		if err := s.policy.Authorize(creator, models.PermUserAssignGroup, models.GroupResource(*req.GroupID)); err != nil {
+			return nil, err
 		}
 	}
 
@@ -63,109 +83,102 @@ func (s *UserService) CreateUser(creatorID uint, req *models.UserCreateRequest)
 		return nil, errors.New("managers cannot have managers")
 	}
 
-	if err := s.userRepo.Create(user); err != nil {
-		return nil, err
+	if user.GroupID == nil {
+		if err := s.userRepo.Create(user); err != nil {
+			return nil, err
+		}
+	} else {
+		groupID := *user.GroupID
+		err := s.groupPolicies.AddOperator(groupID, user, models.MembershipMember, func(tx *gorm.DB) error {
+			users := s.userRepo.WithTx(tx)
+			if err := users.Create(user); err != nil {
+				return err
+			}
+			return users.AssignToGroup(user.ID, groupID)
+		})
+		if err != nil {
+			return nil, err
+		}
+		s.policy.InvalidateMemberships()
 	}
 
-	return s.GetUser(user.ID)
+	s.audit.Record(actor, AuditEntry{
+		Action:        models.AuditCreate,
+		Resource:      models.AuditResourceUser,
+		ResourceID:    user.ID,
+		TargetUserID:  &user.ID,
+		TargetGroupID: user.GroupID,
+		After:         user,
+	})
+
+	return s.userResponse(user.ID)
 }
 
-func (s *UserService) GetUser(userID uint) (*models.UserResponse, error) {
+// GetUser returns userID if the viewer may read users in their groups
+func (s *UserService) GetUser(viewerID, userID uint) (*models.UserResponse, error) {
+	viewer, err := s.userRepo.FindByID(viewerID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
 	user, err := s.userRepo.FindByID(userID)
 	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if err := s.policy.Authorize(viewer, models.PermUserRead, models.UserResource(user)); err != nil {
 		return nil, err
 	}
 
-	response := &models.UserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		Role:      user.Role,
-		GroupID:   user.GroupID,
-		CreatedBy: user.CreatedBy,
-		ManagedBy: user.ManagedBy,
-		IsActive:  user.IsActive,
-		CreatedAt: user.CreatedAt,
-	}
+	response := newUserResponse(user)
+	return &response, nil
+}
 
-	if user.Group != nil {
-		response.GroupName = &user.Group.Name
+// ListUsers returns the users matching the filters in the groups where the
+// viewer may read users
+func (s *UserService) ListUsers(viewerID uint, role string, groupID uint, managerID uint) ([]models.UserResponse, error) {
+	viewer, err := s.userRepo.FindByID(viewerID)
+	if err != nil {
+		return nil, errors.New("user not found")
 	}
 
-	if user.Creator != nil {
-		creatorName := user.Creator.Username
-		response.CreatorName = &creatorName
+	scope, err := s.policy.GroupScope(viewer, models.PermUserRead)
+	if err != nil {
+		return nil, err
 	}
-
-	if user.Manager != nil {
-		managerName := user.Manager.Username
-		response.ManagerName = &managerName
+	if groupID != 0 && !scope.Contains(groupID) {
+		return nil, errors.New("no access to this group")
 	}
 
-	return response, nil
-}
-
-func (s *UserService) ListUsers(role string, groupID uint, managerID uint) ([]models.UserResponse, error) {
-	users, err := s.userRepo.ListUsers(role, groupID, managerID)
+	users, err := s.userRepo.ListUsers(role, groupID, managerID, scope)
 	if err != nil {
 		return nil, err
 	}
 
 	var responses []models.UserResponse
-	for _, user := range users {
-		response := models.UserResponse{
-			ID:        user.ID,
-			Username:  user.Username,
-			Role:      user.Role,
-			GroupID:   user.GroupID,
-			CreatedBy: user.CreatedBy,
-			ManagedBy: user.ManagedBy,
-			IsActive:  user.IsActive,
-			CreatedAt: user.CreatedAt,
-		}
-
-		if user.Group != nil {
-			response.GroupName = &user.Group.Name
-		}
-
-		if user.Creator != nil {
-			creatorName := user.Creator.Username
-			response.CreatorName = &creatorName
-		}
-
-		if user.Manager != nil {
-			managerName := user.Manager.Username
-			response.ManagerName = &managerName
-		}
-
-		responses = append(responses, response)
+	for i := range users {
+		responses = append(responses, newUserResponse(&users[i]))
 	}
 
 	return responses, nil
 }
 
-func (s *UserService) UpdateUser(updaterID, userID uint, req *models.UserUpdateRequest) (*models.UserResponse, error) {
+func (s *UserService) UpdateUser(actor Actor, userID uint, req *models.UserUpdateRequest) (*models.UserResponse, error) {
 	user, err := s.userRepo.FindByID(userID)
 	if err != nil {
 		return nil, errors.New("user not found")
 	}
+	before := *user
 
-	updater, err := s.userRepo.FindByID(updaterID)
+	updater, err := s.userRepo.FindByID(actor.UserID)
 	if err != nil {
 		return nil, errors.New("updater not found")
 	}
 
-	// Check if updater has permission to update this user
-	if updater.Role != models.RoleSuperAdmin {
-		if updater.Role == models.RoleManager {
-			// Managers can only update operators they created
-			if user.Role != models.RoleOperator || user.CreatedBy == nil || *user.CreatedBy != updater.ID {
-				return nil, errors.New("no permission to update this user")
-			}
-		} else {
-			// Operators can only update themselves
-			if user.ID != updater.ID {
-				return nil, errors.New("no permission to update this user")
-			}
+	// Everyone may update themselves; other users need user.update and a
+	// role the updater could assign
+	if user.ID != updater.ID {
+		if err := s.authorizeUser(updater, models.PermUserUpdate, user); err != nil {
+			return nil, errors.New("no permission to update this user")
 		}
 	}
 
@@ -183,49 +196,390 @@ func (s *UserService) UpdateUser(updaterID, userID uint, req *models.UserUpdateR
 	}
 
 	if req.Role != nil {
-		// Only super admin can change roles
-		if updater.Role != models.RoleSuperAdmin {
-			return nil, errors.New("only super admin can change roles")
+		if *req.Role != user.Role {
+			if err := s.checkRole(updater, *req.Role); err != nil {
+				return nil, err
+			}
 		}
 		user.Role = *req.Role
 	}
 
-	if req.GroupID != nil {
-		user.GroupID = req.GroupID
+	// The primary group is moved after saving, together with the membership
+	var newGroupID *uint
+	if req.GroupID != nil && (user.GroupID == nil || *user.GroupID != *req.GroupID) {
+		if err := s.policy.Authorize(updater, models.PermUserAssignGroup, models.GroupResource(*req.GroupID)); err != nil {
+			return nil, errors.New("no access to the new group")
+		}
+		newGroupID = req.GroupID
 	}
 
 	if req.IsActive != nil {
 		user.IsActive = *req.IsActive
 	}
 
-	if err := s.userRepo.Update(user); err != nil {
+	if newGroupID == nil {
+		if err := s.userRepo.Update(user); err != nil {
+			return nil, err
+		}
+	} else {
+		err := s.groupPolicies.AddOperator(*newGroupID, user, primaryRole(user, *newGroupID), func(tx *gorm.DB) error {
+			users := s.userRepo.WithTx(tx)
+			if err := users.Update(user); err != nil {
+				return err
+			}
+			return users.AssignToGroup(user.ID, *newGroupID)
+		})
+		if err != nil {
+			return nil, err
+		}
+		user.GroupID = newGroupID
+		s.policy.InvalidateMemberships()
+	}
+
+	// A deactivated or re-roled user must sign in again
+	if !user.IsActive || user.Role != before.Role {
+		if err := s.tokenRepo.RevokeUser(user.ID); err != nil {
+			return nil, errors.New("user updated but sessions could not be revoked")
+		}
+	}
+
+	s.audit.Record(actor, AuditEntry{
+		Action:        models.AuditUpdate,
+		Resource:      models.AuditResourceUser,
+		ResourceID:    user.ID,
+		TargetUserID:  &user.ID,
+		TargetGroupID: user.GroupID,
+		Before:        &before,
+		After:         user,
+	})
+
+	return s.userResponse(user.ID)
+}
+
+func (s *UserService) DeleteUser(actor Actor, userID uint) error {
+	deleter, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return errors.New("deleter not found")
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if user.ID == deleter.ID {
+		return errors.New("you cannot delete yourself")
+	}
+	if err := s.authorizeUser(deleter, models.PermUserDelete, user); err != nil {
+		return errors.New("no permission to delete this user")
+	}
+	// Only role managers may remove one another, and never the last of them,
+	// or nobody could manage roles any more
+	if s.policy.Can(user, models.PermRoleManage) {
+		if !s.policy.Can(deleter, models.PermRoleManage) {
+			return errors.New("no permission to delete this user")
+		}
+		count, err := s.userRepo.CountActiveByRoles(s.policy.RolesWith(models.PermRoleManage))
+		if err != nil {
+			return err
+		}
+		if user.IsActive && count <= 1 {
+			return errors.New("cannot delete the last user who can manage roles")
+		}
+	}
+
+	if err := s.userRepo.Delete(userID); err != nil {
+		return err
+	}
+
+	s.audit.Record(actor, AuditEntry{
+		Action:        models.AuditDelete,
+		Resource:      models.AuditResourceUser,
+		ResourceID:    user.ID,
+		TargetUserID:  &user.ID,
+		TargetGroupID: user.GroupID,
+		Before:        user,
+	})
+
+	return nil
+}
+
+// AssignToGroup moves the user's primary group. Further groups are added
+// through GroupService.SetMembership.
+func (s *UserService) AssignToGroup(actor Actor, userID, groupID uint) error {
+	assigner, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return errors.New("assigner not found")
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if err := s.authorizeUser(assigner, models.PermUserAssignGroup, user); err != nil {
+		return errors.New("no permission to manage this user")
+	}
+	if err := s.policy.Authorize(assigner, models.PermUserAssignGroup, models.GroupResource(groupID)); err != nil {
+		return err
+	}
+	err = s.groupPolicies.AddOperator(groupID, user, primaryRole(user, groupID), func(tx *gorm.DB) error {
+		return s.userRepo.WithTx(tx).AssignToGroup(userID, groupID)
+	})
+	if err != nil {
+		return err
+	}
+	s.policy.InvalidateMemberships()
+
+	// Record the move against the old group too so its manager sees it
+	targetGroupIDs := []uint{groupID}
+	if user.GroupID != nil && *user.GroupID != groupID {
+		targetGroupIDs = append(targetGroupIDs, *user.GroupID)
+	}
+	for _, targetGroupID := range targetGroupIDs {
+		targetGroupID := targetGroupID
+		s.audit.Record(actor, AuditEntry{
+			Action:        models.AuditAssignGroup,
+			Resource:      models.AuditResourceUser,
+			ResourceID:    userID,
+			TargetUserID:  &userID,
+			TargetGroupID: &targetGroupID,
+			Details:       models.JSON{"from_group_id": user.GroupID, "to_group_id": groupID},
+		})
+	}
+
+	return nil
+}
+
+func (s *UserService) AssignManager(actor Actor, userID, managerID uint) error {
+	assigner, err := s.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return errors.New("assigner not found")
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if err := s.authorizeUser(assigner, models.PermUserAssignManager, user); err != nil {
+		return errors.New("no permission to manage this user")
+	}
+	if _, err := s.userRepo.FindByID(managerID); err != nil {
+		return errors.New("manager not found")
+	}
+
+	if err := s.userRepo.AssignManager(userID, managerID); err != nil {
+		return err
+	}
+
+	s.audit.Record(actor, AuditEntry{
+		Action:        models.AuditAssignManager,
+		Resource:      models.AuditResourceUser,
+		ResourceID:    userID,
+		TargetUserID:  &userID,
+		TargetGroupID: user.GroupID,
+		Details:       models.JSON{"from_manager_id": user.ManagedBy, "to_manager_id": managerID},
+	})
+
+	return nil
+}
+
+// ListUserSessions returns the active sessions of a user the caller controls
+func (s *UserService) ListUserSessions(managerID, userID uint) ([]models.Session, error) {
+	if _, err := s.controlledUser(managerID, userID); err != nil {
 		return nil, err
 	}
 
-	return s.GetUser(user.ID)
+	return s.tokenRepo.ListSessions(userID)
 }
 
-func (s *UserService) DeleteUser(deleterID, userID uint) error {
-	canDelete, err := s.userRepo.CanDeleteUser(deleterID, userID)
+// RevokeUserSessions ends one session of a user the caller controls, or all
+// of them when sessionID is empty
+func (s *UserService) RevokeUserSessions(actor Actor, userID uint, sessionID string) error {
+	user, err := s.controlledUser(actor.UserID, userID)
 	if err != nil {
 		return err
 	}
 
-	if !canDelete {
-		return errors.New("no permission to delete this user")
+	details := models.JSON{"all_sessions": true}
+	if sessionID == "" {
+		if err := s.tokenRepo.RevokeUser(user.ID); err != nil {
+			return errors.New("failed to revoke sessions")
+		}
+	} else {
+		revoked, err := s.tokenRepo.RevokeUserFamily(user.ID, sessionID)
+		if err != nil {
+			return errors.New("failed to revoke session")
+		}
+		if !revoked {
+			return errors.New("session not found")
+		}
+		details = models.JSON{"family_id": sessionID}
+	}
+
+	s.audit.Record(actor, AuditEntry{
+		Action:        models.AuditRevokeSession,
+		Resource:      models.AuditResourceSession,
+		ResourceID:    user.ID,
+		TargetUserID:  &user.ID,
+		TargetGroupID: user.GroupID,
+		Details:       details,
+	})
+
+	return nil
+}
+
+// ResetTwoFactor removes the two-factor setup of a user who lost their
+// device and ends their sessions. They enroll again at their next login if
+// their role requires it.
+func (s *UserService) ResetTwoFactor(actor Actor, userID uint) error {
+	user, err := s.controlledUser(actor.UserID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.twoFactorRepo.Delete(user.ID); err != nil {
+		return errors.New("failed to reset two-factor authentication")
+	}
+	if err := s.tokenRepo.RevokeUser(user.ID); err != nil {
+		return errors.New("failed to revoke sessions")
+	}
+
+	s.audit.Record(actor, AuditEntry{
+		Action:        models.AuditTwoFactorReset,
+		Resource:      models.AuditResourceUser,
+		ResourceID:    user.ID,
+		TargetUserID:  &user.ID,
+		TargetGroupID: user.GroupID,
+	})
+
+	return nil
+}
+
+// UnlockUser lifts a login lockout of the user and forgets their failed
+// attempts
+func (s *UserService) UnlockUser(actor Actor, userID uint) error {
+	user, err := s.controlledUser(actor.UserID, userID)
+	if err != nil {
+		return err
+	}
+
+	found, err := s.throttleRepo.Reset(models.LoginScopeUsername, usernameKey(user.Username))
+	if err != nil {
+		return errors.New("failed to unlock user")
+	}
+	if !found {
+		return errors.New("user has no failed login attempts")
 	}
 
-	return s.userRepo.Delete(userID)
+	s.audit.Record(actor, AuditEntry{
+		Action:        models.AuditLoginUnlock,
+		Resource:      models.AuditResourceAuth,
+		ResourceID:    user.ID,
+		TargetUserID:  &user.ID,
+		TargetGroupID: user.GroupID,
+		Details:       models.JSON{"scope": models.LoginScopeUsername, "key": usernameKey(user.Username)},
+	})
+
+	return nil
+}
+
+// controlledUser loads userID if managerID may manage their sessions,
+// two-factor setup, lockouts and API keys
+func (s *UserService) controlledUser(managerID, userID uint) (*models.User, error) {
+	manager, err := s.userRepo.FindByID(managerID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if err := s.authorizeUser(manager, models.PermUserSecurity, user); err != nil {
+		return nil, errors.New("no permission to manage this user")
+	}
+
+	return user, nil
+}
+
+// authorizeUser checks that actor holds action over user's groups and
+// outranks user's role
+func (s *UserService) authorizeUser(actor *models.User, action string, user *models.User) error {
+	if err := s.policy.Authorize(actor, action, models.UserResource(user)); err != nil {
+		return err
+	}
+	if !s.policy.CanAssignRole(actor, user.Role) {
+		return errors.New("no permission to manage users with role " + string(user.Role))
+	}
+	return nil
 }
 
-func (s *UserService) AssignToGroup(assignerID, userID, groupID uint) error {
-	// Check if assigner has permission to assign to this group
-	// Implementation depends on your business logic
-	return s.userRepo.AssignToGroup(userID, groupID)
+// primaryRole is the role user has in groupID once it becomes their primary
+// group: they keep the role of an existing membership and join as a member
+// otherwise
+func primaryRole(user *models.User, groupID uint) models.MembershipRole {
+	for _, m := range user.Memberships {
+		if m.GroupID == groupID {
+			return m.Role
+		}
+	}
+	return models.MembershipMember
 }
 
-func (s *UserService) AssignManager(assignerID, userID, managerID uint) error {
-	// Check if assigner has permission to assign this manager
-	// Implementation depends on your business logic
-	return s.userRepo.AssignManager(userID, managerID)
+// checkRole verifies that role exists and actor may assign it
+func (s *UserService) checkRole(actor *models.User, role models.Role) error {
+	if !s.policy.RoleExists(role) {
+		return errors.New("unknown role " + string(role))
+	}
+	if !s.policy.CanAssignRole(actor, role) {
+		return errors.New("no permission to assign role " + string(role))
+	}
+	return nil
+}
+
+// userResponse reloads userID and builds its API view
+func (s *UserService) userResponse(userID uint) (*models.UserResponse, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := newUserResponse(user)
+	return &response, nil
+}
+
+// newUserResponse builds the API view of a user; associations that were not
+// preloaded are left out
+func newUserResponse(user *models.User) models.UserResponse {
+	response := models.UserResponse{
+		ID:          user.ID,
+		Username:    user.Username,
+		Role:        user.Role,
+		GroupID:     user.GroupID,
+		Memberships: user.Memberships,
+		CreatedBy:   user.CreatedBy,
+		ManagedBy:   user.ManagedBy,
+		IsActive:    user.IsActive,
+		CreatedAt:   user.CreatedAt,
+	}
+
+	if user.Group != nil {
+		groupName := user.Group.Name
+		response.GroupName = &groupName
+	}
+
+	if user.Creator != nil {
+		creatorName := user.Creator.Username
+		response.CreatorName = &creatorName
+	}
+
+	if user.Manager != nil {
+		managerName := user.Manager.Username
+		response.ManagerName = &managerName
+	}
+
+	return response
 }
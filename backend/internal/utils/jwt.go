@@ -2,26 +2,40 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/katuhangugi/tiktok-account-system/internal/config"
 )
 
+// Claims are the claims of an access token. SessionID is the refresh token
+// family the access token was issued from, so revoking the family also
+// invalidates its access tokens. GroupID is the primary group and GroupIDs
+// every group the user belonged to when the token was issued; access checks
+// use the current memberships.
 type Claims struct {
-	UserID  uint   `json:"user_id"`
-	Role    string `json:"role"`
-	GroupID uint   `json:"group_id"`
+	UserID    uint   `json:"user_id"`
+	Role      string `json:"role"`
+	GroupID   *uint  `json:"group_id,omitempty"`
+	GroupIDs  []uint `json:"group_ids,omitempty"`
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID uint, role string, groupID uint, cfg *config.Config) (string, error) {
+func GenerateToken(userID uint, role string, groupID *uint, groupIDs []uint, sessionID string, cfg *config.Config) (string, error) {
 	expirationTime := time.Now().Add(cfg.JWT.AccessTokenTTL)
 
 	claims := &Claims{
-		UserID:  userID,
-		Role:    role,
-		GroupID: groupID,
+		UserID:    userID,
+		Role:      role,
+		GroupID:   groupID,
+		GroupIDs:  groupIDs,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			Issuer:    cfg.JWT.Issuer,
@@ -32,13 +46,51 @@ func GenerateToken(userID uint, role string, groupID uint, cfg *config.Config) (
 	return token.SignedString([]byte(cfg.JWT.Secret))
 }
 
-func GenerateRefreshToken(userID uint, cfg *config.Config) (string, error) {
-	expirationTime := time.Now().Add(cfg.JWT.RefreshTokenTTL)
+// GenerateRefreshToken returns a random opaque refresh token and the hash
+// to store for it. Refresh tokens are not JWTs, so an access token can
+// never be used in their place.
+func GenerateRefreshToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashToken(token), nil
+}
+
+// HashToken returns the hex SHA-256 of an opaque token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
 
-	claims := &Claims{
-		UserID: userID,
+// NewTokenID returns a random 32 character hex ID, used for token families
+func NewTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// challengePurpose marks challenge tokens so they are never mistaken for
+// access tokens or the other way round
+const challengePurpose = "two_factor"
+
+// ChallengeClaims are the claims of a two-factor challenge token, issued
+// once the password step of a login succeeded
+type ChallengeClaims struct {
+	UserID  uint   `json:"user_id"`
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+func GenerateChallengeToken(userID uint, cfg *config.Config) (string, error) {
+	claims := &ChallengeClaims{
+		UserID:  userID,
+		Purpose: challengePurpose,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(cfg.Auth.ChallengeTTL)),
 			Issuer:    cfg.JWT.Issuer,
 		},
 	}
@@ -47,10 +99,35 @@ func GenerateRefreshToken(userID uint, cfg *config.Config) (string, error) {
 	return token.SignedString([]byte(cfg.JWT.Secret))
 }
 
-func ValidateToken(tokenString string) (*Claims, error) {
+// ValidateChallengeToken returns the user ID of a valid challenge token
+func ValidateChallengeToken(tokenString string, cfg *config.Config) (uint, error) {
+	claims := &ChallengeClaims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return []byte(cfg.JWT.Secret), nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !token.Valid || claims.Purpose != challengePurpose {
+		return 0, jwt.ErrSignatureInvalid
+	}
+
+	return claims.UserID, nil
+}
+
+func ValidateToken(tokenString string, cfg *config.Config) (*Claims, error) {
 	claims := &Claims{}
 
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
 		return []byte(cfg.JWT.Secret), nil
 	})
 
@@ -0,0 +1,179 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule computes the next activation time after a given instant
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// Every is a schedule that fires at a fixed interval
+type Every time.Duration
+
+// Next returns t plus the interval
+func (e Every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+// Spec is a parsed five-field cron expression (minute hour dom month dow)
+type Spec struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type bounds struct {
+	name     string
+	min, max int
+}
+
+var fieldBounds = []bounds{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+// Parse parses a standard five-field cron expression, one of the
+// descriptors @yearly, @monthly, @weekly, @daily, @hourly, or "@every <duration>"
+func Parse(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, errors.New("empty schedule")
+	}
+
+	if strings.HasPrefix(expr, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid @every duration: %v", err)
+		}
+		if d < time.Second {
+			return nil, errors.New("@every interval must be at least 1s")
+		}
+		return Every(d), nil
+	}
+
+	if spec, ok := descriptors[expr]; ok {
+		expr = spec
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != len(fieldBounds) {
+		return nil, fmt.Errorf("expected %d fields, found %d", len(fieldBounds), len(fields))
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, field := range fields {
+		b, err := parseField(field, fieldBounds[i])
+		if err != nil {
+			return nil, err
+		}
+		bits[i] = b
+	}
+
+	return &Spec{
+		minute:  bits[0],
+		hour:    bits[1],
+		dom:     bits[2],
+		month:   bits[3],
+		dow:     bits[4],
+		domStar: fields[2] == "*",
+		dowStar: fields[4] == "*",
+	}, nil
+}
+
+// parseField parses a comma separated list of *, n, a-b and */step terms
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, term := range strings.Split(field, ",") {
+		rangePart, step := term, 1
+		if i := strings.Index(term, "/"); i >= 0 {
+			s, err := strconv.Atoi(term[i+1:])
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("invalid step in %s field %q", b.name, term)
+			}
+			rangePart, step = term[:i], s
+		}
+
+		lo, hi := b.min, b.max
+		if rangePart != "*" {
+			parts := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(parts[0]); err != nil {
+				return 0, fmt.Errorf("invalid %s field %q", b.name, term)
+			}
+			hi = lo
+			if len(parts) == 2 {
+				if hi, err = strconv.Atoi(parts[1]); err != nil {
+					return 0, fmt.Errorf("invalid %s field %q", b.name, term)
+				}
+			} else if step > 1 {
+				hi = b.max
+			}
+		}
+
+		if lo < b.min || hi > b.max || lo > hi {
+			return 0, fmt.Errorf("%s field %q out of range %d-%d", b.name, term, b.min, b.max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first matching minute strictly after t, or the zero
+// time if none exists within five years
+func (s *Spec) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// dayMatches follows cron semantics: when both day fields are restricted,
+// either one matching is enough
+func (s *Spec) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -4,98 +4,66 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
-	"time"
 
 	"github.com/katuhangugi/tiktok-account-system/internal/config"
 	"github.com/katuhangugi/tiktok-account-system/internal/models"
-	"github.com/katuhangugi/tiktok-account-system/internal/services"
 )
 
-// Client handles all TikTok API interactions and data operations
+func init() {
+	RegisterProvider(ProviderScraper, func(cfg config.TikTokAPIConfig) (Provider, error) {
+		return NewClient(cfg)
+	})
+}
+
+// Client is the HTML scraper provider
 type Client struct {
-	scraper     *Scraper
-	config      *config.Config
-	dataService *services.DataService
+	scraper *Scraper
+	config  config.TikTokAPIConfig
 }
 
 // NewClient creates a new TikTok client
-func NewClient(cfg *config.Config, dataService *services.DataService) *Client {
-	return &Client{
-		scraper:     NewScraper(cfg.TikTokAPI.Timeout),
-		config:      cfg,
-		dataService: dataService,
+func NewClient(cfg config.TikTokAPIConfig) (*Client, error) {
+	scraper, err := NewScraper(cfg)
+	if err != nil {
+		return nil, err
 	}
-}
 
-// AccountData represents the TikTok account data structure
-type AccountData struct {
-	AccountName    string    `json:"account_name"`
-	Nickname       string    `json:"nickname"`
-	UID            string    `json:"uid"`
-	Location       string    `json:"location"`
-	FollowerCount  int64     `json:"follower_count"`
-	FollowingCount int64     `json:"following_count"`
-	TotalLikes     int64     `json:"total_likes"`
-	VideoCount     int64     `json:"video_count"`
-	DailyUploads   int64     `json:"daily_uploads"`
-	CreatedAt      time.Time `json:"created_at"`
+	return &Client{
+		scraper: scraper,
+		config:  cfg,
+	}, nil
 }
 
 // GetAccountData fetches account data from TikTok
-func (c *Client) GetAccountData(accountName string) (*AccountData, error) {
+func (c *Client) GetAccountData(accountName string) (*models.TikTokData, error) {
 	info, err := c.scraper.GetUserInfo(accountName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user info: %v", err)
+		return nil, fmt.Errorf("failed to get user info: %w", err)
 	}
 
-	return &AccountData{
-		AccountName:    info.Username,
-		Nickname:       info.Name,
-		UID:            info.ID,
-		Location:       info.Region,
-		FollowerCount:  info.Followers,
-		FollowingCount: info.Following,
-		TotalLikes:     info.Likes,
-		VideoCount:     info.Videos,
-		CreatedAt:      info.CreatedAt,
-		DailyUploads:   0, // Will need to calculate from historical data
+	return &models.TikTokData{
+		AccountName: info.Username,
+		Nickname:    info.Name,
+		UID:         info.ID,
+		Region:      info.Region,
+		Followers:   info.Followers,
+		Following:   info.Following,
+		Likes:       info.Likes,
+		Videos:      info.Videos,
+		CreatedAt:   info.CreatedAt,
 	}, nil
 }
 
-// FetchAndStoreAccountData fetches account data from TikTok and stores it
-func (c *Client) FetchAndStoreAccountData(ctx context.Context, account *models.TikTokAccount) error {
-	// Fetch data from TikTok
-	data, err := c.GetAccountData(account.AccountName)
-	if err != nil {
-		return err
-	}
-
-	// Convert to our data model
-	tikTokData := &models.TikTokData{
-		AccountName:  data.AccountName,
-		Nickname:     data.Nickname,
-		UID:          data.UID,
-		Region:       data.Location,
-		CreatedAt:    data.CreatedAt,
-		Followers:    data.FollowerCount,
-		Following:    data.FollowingCount,
-		Likes:        data.TotalLikes,
-		Videos:       data.VideoCount,
-		DailyUploads: data.DailyUploads,
-	}
-
-	// Store the data
-	return c.dataService.StoreTikTokData(ctx, account, tikTokData)
-}
-
 // ValidateAccount checks if a TikTok account exists
 func (c *Client) ValidateAccount(accountName string) (bool, error) {
 	_, err := c.scraper.GetUserInfo(accountName)
 	if err != nil {
-		if strings.Contains(err.Error(), "request failed with status code: 404") {
+		if errors.Is(err, ErrNotFound) {
 			return false, nil
 		}
+		if errors.Is(err, ErrPrivate) {
+			return true, nil
+		}
 		return false, err
 	}
 	return true, nil
@@ -109,12 +77,10 @@ func (c *Client) GetStatus() (string, error) {
 		if errors.Is(err, context.DeadlineExceeded) {
 			return "timeout", nil
 		}
+		if errors.Is(err, ErrBlocked) {
+			return "blocked", err
+		}
 		return "unavailable", err
 	}
 	return "operational", nil
 }
-
-// RefreshAccountData refreshes data for a specific account
-func (c *Client) RefreshAccountData(ctx context.Context, account *models.TikTokAccount) error {
-	return c.FetchAndStoreAccountData(ctx, account)
-}
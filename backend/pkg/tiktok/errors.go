@@ -0,0 +1,17 @@
+package tiktok
+
+import "errors"
+
+// Errors returned by providers. They are wrapped with context, so callers
+// should compare with errors.Is.
+var (
+	// ErrNotFound is returned when the account does not exist
+	ErrNotFound = errors.New("tiktok account not found")
+	// ErrPrivate is returned when the account exists but hides its stats
+	ErrPrivate = errors.New("tiktok account is private")
+	// ErrBlocked is returned when TikTok served a captcha or refused the request
+	ErrBlocked = errors.New("tiktok blocked the request")
+	// ErrFormatChanged is returned when a profile page has no data in any
+	// known format
+	ErrFormatChanged = errors.New("tiktok page format not recognised")
+)
@@ -0,0 +1,329 @@
+// Package faketiktok serves TikTok-like profile pages for offline
+// development and integration tests. Pages embed the same
+// __DEFAULT_SCOPE__ / webapp.user-detail / userInfo structure as TikTok
+// (or, per account, the older SIGI_STATE and __NEXT_DATA__ formats), and each account can be scripted to grow, vanish, go private,
+// hit a captcha or respond slowly.
+package faketiktok
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Behaviour names understood by Profile.Behavior
+const (
+	BehaviorOK       = "ok"
+	BehaviorNotFound = "not_found"
+	BehaviorPrivate  = "private"
+	BehaviorCaptcha  = "captcha"
+	BehaviorError    = "error"
+)
+
+// Page formats understood by Profile.Format
+const (
+	FormatUniversal = "universal"
+	FormatSigi      = "sigi"
+	FormatNext      = "next"
+	// FormatUnknown embeds no recognisable data, as after a TikTok redesign
+	FormatUnknown = "unknown"
+)
+
+// TikTok webapp status codes embedded in user-detail
+const (
+	statusOK       = 0
+	statusNotFound = 10221
+	statusPrivate  = 10222
+)
+
+// Profile scripts how one account responds
+type Profile struct {
+	Behavior string `json:"behavior"`
+	// Format selects how the data is embedded; defaults to universal
+	Format    string `json:"format"`
+	Nickname  string `json:"nickname"`
+	UID       string `json:"uid"`
+	Region    string `json:"region"`
+	CreatedAt int64  `json:"create_time"`
+	Followers int64  `json:"followers"`
+	Following int64  `json:"following"`
+	Likes     int64  `json:"likes"`
+	Videos    int64  `json:"videos"`
+	// FollowerCurve and VideoCurve override Followers and Videos: the nth
+	// fetch of the account returns the nth value, repeating the last one
+	FollowerCurve []int64 `json:"follower_curve"`
+	VideoCurve    []int64 `json:"video_curve"`
+	// Delay is waited before responding, e.g. "3s"
+	Delay string `json:"delay"`
+	// Status is the HTTP status returned by the error behaviour
+	Status int `json:"status"`
+}
+
+// Scenario is the full set of scripted accounts
+type Scenario struct {
+	// Default applies to accounts not listed in Accounts; when nil they are not found
+	Default  *Profile           `json:"default"`
+	Accounts map[string]Profile `json:"accounts"`
+}
+
+// LoadScenario reads a JSON scenario file
+func LoadScenario(path string) (*Scenario, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var scenario Scenario
+	if err := json.Unmarshal(raw, &scenario); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario %s: %v", path, err)
+	}
+	return &scenario, nil
+}
+
+// Server is an http.Handler serving scripted profile pages at /@<username>.
+// It also exposes a small control API under /_fake/ for tests:
+//
+//	PUT    /_fake/accounts/<name>   set or replace an account profile (JSON body)
+//	DELETE /_fake/accounts/<name>   remove an account
+//	GET    /_fake/fetches           fetch counts per account
+//	POST   /_fake/reset             reset fetch counts
+type Server struct {
+	mu       sync.Mutex
+	scenario Scenario
+	fetches  map[string]int
+}
+
+// NewServer creates a server for the scenario; a nil scenario starts empty
+func NewServer(scenario *Scenario) *Server {
+	s := &Server{
+		scenario: Scenario{Accounts: make(map[string]Profile)},
+		fetches:  make(map[string]int),
+	}
+	if scenario != nil {
+		s.scenario.Default = scenario.Default
+		for name, p := range scenario.Accounts {
+			s.scenario.Accounts[name] = p
+		}
+	}
+	return s
+}
+
+// SetProfile sets or replaces an account profile
+func (s *Server) SetProfile(name string, p Profile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scenario.Accounts[name] = p
+}
+
+// Fetches returns how many times the account's page has been served
+func (s *Server) Fetches(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetches[name]
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/_fake/"):
+		s.serveControl(w, r)
+	case strings.HasPrefix(r.URL.Path, "/@"):
+		s.serveProfile(w, r, strings.TrimPrefix(r.URL.Path, "/@"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveProfile(w http.ResponseWriter, r *http.Request, name string) {
+	s.mu.Lock()
+	profile, ok := s.scenario.Accounts[name]
+	if !ok && s.scenario.Default != nil {
+		profile, ok = *s.scenario.Default, true
+	}
+	fetch := s.fetches[name]
+	s.fetches[name]++
+	s.mu.Unlock()
+
+	if !ok {
+		profile = Profile{Behavior: BehaviorNotFound}
+	}
+
+	if profile.Delay != "" {
+		if d, err := time.ParseDuration(profile.Delay); err == nil {
+			select {
+			case <-time.After(d):
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	switch profile.Behavior {
+	case BehaviorNotFound:
+		w.WriteHeader(http.StatusNotFound)
+		writePage(w, name, profile.Format, map[string]interface{}{
+			"statusCode": statusNotFound,
+			"statusMsg":  "user not exist",
+		})
+	case BehaviorPrivate:
+		writePage(w, name, profile.Format, map[string]interface{}{
+			"statusCode": statusPrivate,
+			"statusMsg":  "private account",
+			"userInfo": map[string]interface{}{
+				"user":  userNode(name, profile, true),
+				"stats": map[string]interface{}{},
+			},
+		})
+	case BehaviorCaptcha:
+		fmt.Fprint(w, captchaPage)
+	case BehaviorError:
+		status := profile.Status
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		http.Error(w, http.StatusText(status), status)
+	default:
+		writePage(w, name, profile.Format, map[string]interface{}{
+			"statusCode": statusOK,
+			"statusMsg":  "",
+			"userInfo": map[string]interface{}{
+				"user": userNode(name, profile, false),
+				"stats": map[string]interface{}{
+					"followerCount":  curveValue(profile.FollowerCurve, fetch, profile.Followers),
+					"followingCount": profile.Following,
+					"heartCount":     profile.Likes,
+					"videoCount":     curveValue(profile.VideoCurve, fetch, profile.Videos),
+				},
+			},
+		})
+	}
+}
+
+func userNode(name string, p Profile, private bool) map[string]interface{} {
+	nickname := p.Nickname
+	if nickname == "" {
+		nickname = name
+	}
+	uid := p.UID
+	if uid == "" {
+		uid = fmt.Sprintf("fake-%s", name)
+	}
+	return map[string]interface{}{
+		"id":             uid,
+		"uniqueId":       name,
+		"nickname":       nickname,
+		"region":         p.Region,
+		"createTime":     p.CreatedAt,
+		"privateAccount": private,
+	}
+}
+
+func curveValue(curve []int64, fetch int, fallback int64) int64 {
+	if len(curve) == 0 {
+		return fallback
+	}
+	if fetch >= len(curve) {
+		return curve[len(curve)-1]
+	}
+	return curve[fetch]
+}
+
+func writePage(w http.ResponseWriter, name, format string, detail map[string]interface{}) {
+	scriptID := "__UNIVERSAL_DATA_FOR_REHYDRATION__"
+	var root interface{} = map[string]interface{}{
+		"__DEFAULT_SCOPE__": map[string]interface{}{
+			"webapp.user-detail": detail,
+		},
+	}
+
+	switch format {
+	case FormatSigi:
+		userInfo, _ := detail["userInfo"].(map[string]interface{})
+		users := map[string]interface{}{}
+		stats := map[string]interface{}{}
+		if userInfo != nil {
+			users[name] = userInfo["user"]
+			stats[name] = userInfo["stats"]
+		}
+		scriptID = "SIGI_STATE"
+		root = map[string]interface{}{
+			"UserModule": map[string]interface{}{"users": users, "stats": stats},
+			"UserPage":   map[string]interface{}{"uniqueId": name, "statusCode": detail["statusCode"]},
+		}
+	case FormatNext:
+		scriptID = "__NEXT_DATA__"
+		root = map[string]interface{}{
+			"props": map[string]interface{}{"pageProps": detail},
+		}
+	case FormatUnknown:
+		scriptID = "__APP_STATE__"
+		root = map[string]interface{}{"profile": detail}
+	}
+
+	data, _ := json.Marshal(root)
+
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html><head><title>%s | TikTok</title></head>
+<body>
+<div id="app"></div>
+<script id="%s" type="application/json">%s</script>
+</body></html>`, html.EscapeString(name), scriptID, data)
+}
+
+const captchaPage = `<!DOCTYPE html>
+<html><head><title>Security Check</title></head>
+<body>
+<div id="captcha-verify-container"><div class="captcha_verify_container">Verify to continue</div></div>
+</body></html>`
+
+func (s *Server) serveControl(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/_fake/")
+
+	switch {
+	case strings.HasPrefix(path, "accounts/"):
+		name := strings.TrimPrefix(path, "accounts/")
+		if name == "" {
+			http.Error(w, "account name required", http.StatusBadRequest)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			var p Profile
+			if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			s.SetProfile(name, p)
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodDelete:
+			s.mu.Lock()
+			delete(s.scenario.Accounts, name)
+			s.mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	case path == "fetches" && r.Method == http.MethodGet:
+		s.mu.Lock()
+		counts := make(map[string]int, len(s.fetches))
+		for name, n := range s.fetches {
+			counts[name] = n
+		}
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(counts)
+	case path == "reset" && r.Method == http.MethodPost:
+		s.mu.Lock()
+		s.fetches = make(map[string]int)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
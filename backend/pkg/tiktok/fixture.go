@@ -0,0 +1,90 @@
+package tiktok
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/katuhangugi/tiktok-account-system/internal/config"
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+)
+
+func init() {
+	RegisterProvider(ProviderFixture, func(cfg config.TikTokAPIConfig) (Provider, error) {
+		return NewFixtureClient(cfg.FixtureDir)
+	})
+}
+
+// FixtureClient serves account data from JSON files, one per account
+// named <account_name>.json, so the stack can run without network access
+type FixtureClient struct {
+	dir string
+}
+
+// NewFixtureClient creates a fixture provider reading from dir
+func NewFixtureClient(dir string) (*FixtureClient, error) {
+	if dir == "" {
+		return nil, errors.New("fixture provider requires tiktokapi.fixtureDir")
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("fixture directory: %v", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("fixture directory %s is not a directory", dir)
+	}
+
+	return &FixtureClient{dir: dir}, nil
+}
+
+// GetAccountData reads the fixture file for the account
+func (c *FixtureClient) GetAccountData(accountName string) (*models.TikTokData, error) {
+	name := strings.TrimSpace(accountName)
+	if name == "" {
+		return nil, errors.New("username cannot be empty")
+	}
+	if filepath.Base(name) != name {
+		return nil, fmt.Errorf("invalid username %q", accountName)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(c.dir, name+".json"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to read fixture: %v", err)
+	}
+
+	var data models.TikTokData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %v", name, err)
+	}
+	if data.AccountName == "" {
+		data.AccountName = name
+	}
+
+	return &data, nil
+}
+
+// ValidateAccount reports whether a fixture exists for the account
+func (c *FixtureClient) ValidateAccount(accountName string) (bool, error) {
+	if _, err := c.GetAccountData(accountName); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// GetStatus reports whether the fixture directory is readable
+func (c *FixtureClient) GetStatus() (string, error) {
+	if _, err := os.ReadDir(c.dir); err != nil {
+		return "unavailable", err
+	}
+	return "operational", nil
+}
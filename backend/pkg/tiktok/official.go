@@ -0,0 +1,139 @@
+package tiktok
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/katuhangugi/tiktok-account-system/internal/config"
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+)
+
+// DefaultOfficialEndpoint is the TikTok Research API user info endpoint
+const DefaultOfficialEndpoint = "https://open.tiktokapis.com/v2/research/user/info/"
+
+const officialUserFields = "display_name,follower_count,following_count,likes_count,video_count"
+
+func init() {
+	RegisterProvider(ProviderOfficial, func(cfg config.TikTokAPIConfig) (Provider, error) {
+		return NewOfficialClient(cfg)
+	})
+}
+
+// OfficialClient reads account data from the official TikTok API
+type OfficialClient struct {
+	client   *http.Client
+	endpoint string
+	key      string
+}
+
+// NewOfficialClient creates a client for the official API; an access token
+// must be configured in tiktokapi.key
+func NewOfficialClient(cfg config.TikTokAPIConfig) (*OfficialClient, error) {
+	if strings.TrimSpace(cfg.Key) == "" {
+		return nil, errors.New("official provider requires tiktokapi.key")
+	}
+
+	endpoint := cfg.APIEndpoint
+	if endpoint == "" {
+		endpoint = DefaultOfficialEndpoint
+	}
+
+	return &OfficialClient{
+		client:   &http.Client{Timeout: cfg.Timeout},
+		endpoint: endpoint,
+		key:      cfg.Key,
+	}, nil
+}
+
+type officialUserInfoResponse struct {
+	Data struct {
+		DisplayName    string `json:"display_name"`
+		FollowerCount  int64  `json:"follower_count"`
+		FollowingCount int64  `json:"following_count"`
+		LikesCount     int64  `json:"likes_count"`
+		VideoCount     int64  `json:"video_count"`
+	} `json:"data"`
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		LogID   string `json:"log_id"`
+	} `json:"error"`
+}
+
+// GetAccountData fetches account data from the official API. The API does
+// not expose UID or region, so those fields are left empty.
+func (c *OfficialClient) GetAccountData(accountName string) (*models.TikTokData, error) {
+	if strings.TrimSpace(accountName) == "" {
+		return nil, errors.New("username cannot be empty")
+	}
+
+	body, err := json.Marshal(map[string]string{"username": accountName})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.endpoint+"?fields="+officialUserFields, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.key)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	var result officialUserInfoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response (status %d): %v", resp.StatusCode, err)
+	}
+
+	if result.Error.Code != "" && result.Error.Code != "ok" {
+		return nil, fmt.Errorf("tiktok api error %s: %s (log_id %s)", result.Error.Code, result.Error.Message, result.Error.LogID)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request failed with status code: %d", resp.StatusCode)
+	}
+
+	return &models.TikTokData{
+		AccountName: accountName,
+		Nickname:    result.Data.DisplayName,
+		Followers:   result.Data.FollowerCount,
+		Following:   result.Data.FollowingCount,
+		Likes:       result.Data.LikesCount,
+		Videos:      result.Data.VideoCount,
+	}, nil
+}
+
+// ValidateAccount checks if a TikTok account exists
+func (c *OfficialClient) ValidateAccount(accountName string) (bool, error) {
+	if _, err := c.GetAccountData(accountName); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// GetStatus checks the official API status
+func (c *OfficialClient) GetStatus() (string, error) {
+	if _, err := c.GetAccountData("tiktok"); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return "timeout", nil
+		}
+		return "unavailable", err
+	}
+	return "operational", nil
+}
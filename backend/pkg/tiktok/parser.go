@@ -0,0 +1,291 @@
+package tiktok
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// TikTok webapp status codes embedded in profile pages
+const (
+	statusOK           = 0
+	statusUserNotExist = 10202
+	statusUserNotFound = 10221
+	statusUserPrivate  = 10222
+)
+
+// profileData is the format-independent part of an embedded profile
+type profileData struct {
+	statusCode int64
+	user       map[string]interface{}
+	stats      map[string]interface{}
+}
+
+// profileFormat extracts profile data from the JSON of one embedding format.
+// It returns false when the JSON does not have the expected shape.
+type profileFormat struct {
+	name     string
+	scriptID string
+	extract  func(root map[string]interface{}, username string) (*profileData, bool)
+}
+
+// profileFormats lists the known embedding formats, newest first
+var profileFormats = []profileFormat{
+	{"universal data", "__UNIVERSAL_DATA_FOR_REHYDRATION__", extractUniversalData},
+	{"SIGI state", "SIGI_STATE", extractSigiState},
+	{"next data", "__NEXT_DATA__", extractNextData},
+}
+
+// ParseProfile extracts user information from a TikTok profile page. It
+// returns an error wrapping ErrNotFound, ErrPrivate, ErrBlocked or
+// ErrFormatChanged when the page has no usable data.
+func ParseProfile(page []byte, username string) (*TikTokUserInfo, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to parse HTML: %v", ErrFormatChanged, err)
+	}
+
+	if isCaptchaPage(doc) {
+		return nil, fmt.Errorf("%w: captcha page served", ErrBlocked)
+	}
+
+	var problems []string
+	for _, format := range profileFormats {
+		script := doc.Find("script#" + format.scriptID).First()
+		if script.Length() == 0 {
+			continue
+		}
+
+		var root map[string]interface{}
+		decoder := json.NewDecoder(strings.NewReader(script.Text()))
+		decoder.UseNumber()
+		if err := decoder.Decode(&root); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: invalid JSON: %v", format.name, err))
+			continue
+		}
+
+		data, ok := format.extract(root, username)
+		if !ok {
+			problems = append(problems, format.name+": user detail not found")
+			continue
+		}
+		return data.userInfo(username)
+	}
+
+	if len(problems) == 0 {
+		return nil, fmt.Errorf("%w: no embedded profile data", ErrFormatChanged)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrFormatChanged, strings.Join(problems, "; "))
+}
+
+// isCaptchaPage reports whether TikTok served its bot verification page
+func isCaptchaPage(doc *goquery.Document) bool {
+	if doc.Find("#captcha-verify-container, .captcha_verify_container, #tiktok-verify-ele").Length() > 0 {
+		return true
+	}
+	return strings.Contains(strings.ToLower(doc.Find("title").Text()), "security check")
+}
+
+func extractUniversalData(root map[string]interface{}, username string) (*profileData, bool) {
+	detail := objectAt(root, "__DEFAULT_SCOPE__", "webapp.user-detail")
+	if detail == nil {
+		return nil, false
+	}
+
+	status, _ := toInt64(detail["statusCode"])
+	return &profileData{
+		statusCode: status,
+		user:       objectAt(detail, "userInfo", "user"),
+		stats:      statsOf(objectAt(detail, "userInfo")),
+	}, true
+}
+
+func extractSigiState(root map[string]interface{}, username string) (*profileData, bool) {
+	users := objectAt(root, "UserModule", "users")
+	if users == nil {
+		return nil, false
+	}
+
+	status, _ := toInt64(objectAt(root, "UserPage")["statusCode"])
+	return &profileData{
+		statusCode: status,
+		user:       entryFor(users, username),
+		stats:      entryFor(objectAt(root, "UserModule", "stats"), username),
+	}, true
+}
+
+func extractNextData(root map[string]interface{}, username string) (*profileData, bool) {
+	props := objectAt(root, "props", "pageProps")
+	if props == nil {
+		return nil, false
+	}
+	if _, ok := props["userInfo"]; !ok {
+		if _, ok := props["statusCode"]; !ok {
+			return nil, false
+		}
+	}
+
+	status, _ := toInt64(props["statusCode"])
+	return &profileData{
+		statusCode: status,
+		user:       objectAt(props, "userInfo", "user"),
+		stats:      statsOf(objectAt(props, "userInfo")),
+	}, true
+}
+
+// userInfo maps the extracted data onto TikTokUserInfo
+func (d *profileData) userInfo(username string) (*TikTokUserInfo, error) {
+	switch d.statusCode {
+	case statusOK:
+	case statusUserNotExist, statusUserNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, username)
+	case statusUserPrivate:
+		return nil, fmt.Errorf("%w: %s", ErrPrivate, username)
+	default:
+		if d.user == nil {
+			return nil, fmt.Errorf("%w: unexpected status code %d", ErrFormatChanged, d.statusCode)
+		}
+	}
+
+	if d.user == nil {
+		return nil, fmt.Errorf("%w: user node not found", ErrFormatChanged)
+	}
+
+	info := &TikTokUserInfo{
+		Username: username,
+		Name:     toString(d.user["nickname"]),
+		Region:   toString(d.user["region"]),
+		ID:       toString(d.user["id"]),
+	}
+	if name := toString(d.user["uniqueId"]); name != "" {
+		info.Username = name
+	}
+	if t, ok := toInt64(d.user["createTime"]); ok && t > 0 {
+		info.CreatedAt = time.Unix(t, 0).UTC()
+	}
+
+	counts := []struct {
+		key   string
+		value *int64
+	}{
+		{"followerCount", &info.Followers},
+		{"followingCount", &info.Following},
+		{"heartCount", &info.Likes},
+		{"videoCount", &info.Videos},
+	}
+	found := 0
+	for _, c := range counts {
+		if v, ok := toInt64(d.stats[c.key]); ok {
+			*c.value = v
+			found++
+		}
+	}
+
+	if found == 0 {
+		if private, _ := d.user["privateAccount"].(bool); private {
+			return nil, fmt.Errorf("%w: %s", ErrPrivate, username)
+		}
+		return nil, fmt.Errorf("%w: stats not found", ErrFormatChanged)
+	}
+
+	return info, nil
+}
+
+// statsOf returns the stats of a userInfo node. Newer pages carry the
+// counts as strings in statsV2, which is used for any count missing from stats.
+func statsOf(userInfo map[string]interface{}) map[string]interface{} {
+	stats := objectAt(userInfo, "stats")
+	v2 := objectAt(userInfo, "statsV2")
+	if v2 == nil {
+		return stats
+	}
+
+	merged := make(map[string]interface{}, len(v2))
+	for k, v := range v2 {
+		merged[k] = v
+	}
+	for k, v := range stats {
+		if _, ok := toInt64(v); ok {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// objectAt follows path through nested JSON objects, returning nil if any
+// step is missing or not an object
+func objectAt(node map[string]interface{}, path ...string) map[string]interface{} {
+	for _, key := range path {
+		if node == nil {
+			return nil
+		}
+		next, ok := node[key].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+// entryFor returns the object keyed by username, ignoring case, or the only
+// entry when there is exactly one
+func entryFor(entries map[string]interface{}, username string) map[string]interface{} {
+	if entry, ok := entries[username].(map[string]interface{}); ok {
+		return entry
+	}
+	for key, value := range entries {
+		if strings.EqualFold(key, username) || len(entries) == 1 {
+			entry, _ := value.(map[string]interface{})
+			return entry
+		}
+	}
+	return nil
+}
+
+// toInt64 converts a JSON number, numeric string or float to int64
+func toInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return i, true
+		}
+		if f, err := n.Float64(); err == nil {
+			return int64(math.Round(f)), true
+		}
+	case float64:
+		return int64(math.Round(n)), true
+	case int64:
+		return n, true
+	case int:
+		return int64(n), true
+	case string:
+		s := strings.ReplaceAll(strings.TrimSpace(n), ",", "")
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, true
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return int64(math.Round(f)), true
+		}
+	}
+	return 0, false
+}
+
+// toString converts a JSON string or number to a string; anything else is empty
+func toString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case json.Number:
+		return s.String()
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	}
+	return ""
+}
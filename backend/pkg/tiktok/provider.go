@@ -0,0 +1,118 @@
+package tiktok
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/katuhangugi/tiktok-account-system/internal/config"
+	"github.com/katuhangugi/tiktok-account-system/internal/models"
+)
+
+// Built-in provider names
+const (
+	ProviderScraper  = "scraper"
+	ProviderOfficial = "official"
+	ProviderFixture  = "fixture"
+)
+
+// Provider is a source of TikTok account data. Every provider returns the
+// same models.TikTokData so callers never depend on where it came from.
+type Provider interface {
+	GetAccountData(accountName string) (*models.TikTokData, error)
+	ValidateAccount(accountName string) (bool, error)
+	GetStatus() (string, error)
+}
+
+// ProviderFactory builds a provider from the TikTok API configuration
+type ProviderFactory func(cfg config.TikTokAPIConfig) (Provider, error)
+
+var (
+	providersMu sync.RWMutex
+	providers   = make(map[string]ProviderFactory)
+)
+
+// RegisterProvider makes a provider available by name. It panics if the
+// name is registered twice.
+func RegisterProvider(name string, factory ProviderFactory) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	if _, dup := providers[name]; dup {
+		panic("tiktok: provider registered twice: " + name)
+	}
+	providers[name] = factory
+}
+
+// Providers returns the sorted names of all registered providers
+func Providers() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewProvider creates the named provider
+func NewProvider(name string, cfg config.TikTokAPIConfig) (Provider, error) {
+	providersMu.RLock()
+	factory, ok := providers[name]
+	providersMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown tiktok provider %q (available: %v)", name, Providers())
+	}
+	return factory(cfg)
+}
+
+// Router dispatches to the configured default provider, or to the
+// provider configured for an account's group
+type Router struct {
+	Provider
+	groups map[uint]Provider
+}
+
+// NewRouter builds the default provider and every per-group override
+func NewRouter(cfg config.TikTokAPIConfig) (*Router, error) {
+	def, err := NewProvider(cfg.Provider, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	router := &Router{
+		Provider: def,
+		groups:   make(map[uint]Provider),
+	}
+
+	// Groups sharing a provider name share one instance
+	byName := map[string]Provider{cfg.Provider: def}
+	for groupID, name := range cfg.GroupProviders {
+		p, ok := byName[name]
+		if !ok {
+			if p, err = NewProvider(name, cfg); err != nil {
+				return nil, fmt.Errorf("group %d: %v", groupID, err)
+			}
+			byName[name] = p
+		}
+		router.groups[groupID] = p
+	}
+
+	return router, nil
+}
+
+// ForGroup returns the provider used for accounts in the group
+func (r *Router) ForGroup(groupID uint) Provider {
+	if p, ok := r.groups[groupID]; ok {
+		return p
+	}
+	return r.Provider
+}
+
+// GetGroupAccountData fetches account data using the group's provider
+func (r *Router) GetGroupAccountData(groupID uint, accountName string) (*models.TikTokData, error) {
+	return r.ForGroup(groupID).GetAccountData(accountName)
+}
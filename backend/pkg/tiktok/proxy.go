@@ -0,0 +1,100 @@
+package tiktok
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// proxy is one upstream proxy and its recent health
+type proxy struct {
+	url       *url.URL
+	client    *http.Client
+	failures  int
+	downUntil time.Time
+}
+
+// proxyPool rotates requests across proxies. A proxy that fails maxFailures
+// times in a row is taken out of rotation for cooldown, then tried again.
+type proxyPool struct {
+	mu          sync.Mutex
+	proxies     []*proxy
+	next        int
+	maxFailures int
+	cooldown    time.Duration
+}
+
+// newProxyPool returns nil, which means direct connections, when no proxies
+// are configured
+func newProxyPool(urls []string, timeout time.Duration, maxFailures int, cooldown time.Duration) (*proxyPool, error) {
+	if len(urls) == 0 {
+		return nil, nil
+	}
+	if maxFailures < 1 {
+		maxFailures = 1
+	}
+
+	pool := &proxyPool{maxFailures: maxFailures, cooldown: cooldown}
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", raw)
+		}
+		pool.proxies = append(pool.proxies, &proxy{
+			url: u,
+			client: &http.Client{
+				Timeout:   timeout,
+				Transport: &http.Transport{Proxy: http.ProxyURL(u)},
+			},
+		})
+	}
+	return pool, nil
+}
+
+// pick returns the next healthy proxy. When every proxy is resting, the one
+// due back first is used rather than failing the request.
+func (p *proxyPool) pick() *proxy {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var soonest *proxy
+	for i := 0; i < len(p.proxies); i++ {
+		idx := (p.next + i) % len(p.proxies)
+		candidate := p.proxies[idx]
+		if !now.Before(candidate.downUntil) {
+			p.next = idx + 1
+			return candidate
+		}
+		if soonest == nil || candidate.downUntil.Before(soonest.downUntil) {
+			soonest = candidate
+		}
+	}
+	return soonest
+}
+
+// report records the outcome of a request made through pr
+func (p *proxyPool) report(pr *proxy, ok bool) {
+	if p == nil || pr == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if ok {
+		pr.failures = 0
+		return
+	}
+	pr.failures++
+	if pr.failures >= p.maxFailures {
+		pr.failures = 0
+		pr.downUntil = time.Now().Add(p.cooldown)
+	}
+}
@@ -0,0 +1,83 @@
+package tiktok
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenBucket allows rate requests per second with bursts of up to burst.
+// Callers that find it empty reserve a future token, so waiters are served
+// in arrival order.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// reserve takes a token and returns how long to wait before using it
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// hostLimiter keeps one token bucket per host
+type hostLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   int
+	buckets map[string]*tokenBucket
+}
+
+// newHostLimiter returns nil, which never waits, when rate is not positive
+func newHostLimiter(rate float64, burst int) *hostLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &hostLimiter{
+		rate:    rate,
+		burst:   burst,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// wait blocks until a request to host is allowed
+func (l *hostLimiter) wait(host string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	bucket, ok := l.buckets[host]
+	if !ok {
+		bucket = &tokenBucket{
+			rate:   l.rate,
+			burst:  float64(l.burst),
+			tokens: float64(l.burst),
+			last:   time.Now(),
+		}
+		l.buckets[host] = bucket
+	}
+	l.mu.Unlock()
+
+	if delay := bucket.reserve(); delay > 0 {
+		time.Sleep(delay)
+	}
+}
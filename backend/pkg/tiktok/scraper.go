@@ -1,16 +1,20 @@
 package tiktok
 
 import (
-	"bytes"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math/rand"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
-	"github.com/PuerkitoBio/goquery"
+	"github.com/katuhangugi/tiktok-account-system/internal/config"
 )
 
 // TikTokUserInfo represents the user information scraped from TikTok
@@ -26,127 +30,252 @@ type TikTokUserInfo struct {
 	Videos    int64     `json:"videos"`
 }
 
+// DefaultProfileURL is the profile page URL format used when none is configured
+const DefaultProfileURL = "https://www.tiktok.com/@%s"
+
+// defaultUserAgents are rotated when no user agents are configured
+var defaultUserAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:125.0) Gecko/20100101 Firefox/125.0",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.0.0",
+}
+
 // Scraper handles TikTok data scraping
 type Scraper struct {
-	client *http.Client
+	client   *http.Client
+	endpoint string
+	// failedPageDir receives the raw HTML of pages that could not be parsed
+	failedPageDir string
+
+	limiter     *hostLimiter
+	proxies     *proxyPool
+	userAgents  []string
+	nextAgent   uint32
+	maxRetries  int
+	backoffBase time.Duration
+	backoffMax  time.Duration
+}
+
+// retryableError marks a failed attempt that may succeed when repeated
+type retryableError struct {
+	err error
+	// after is the delay requested by the server via Retry-After, if any
+	after time.Duration
 }
 
-// NewScraper creates a new TikTok scraper instance
-func NewScraper(timeout time.Duration) *Scraper {
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// NewScraper creates a new TikTok scraper instance from the tiktokapi
+// settings. The endpoint is either a URL format containing %s for the
+// username, or a base URL to which "/@<username>" is appended.
+func NewScraper(cfg config.TikTokAPIConfig) (*Scraper, error) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultProfileURL
+	}
+
+	proxies, err := newProxyPool(cfg.Proxies, cfg.Timeout, cfg.ProxyMaxFailures, cfg.ProxyCooldown)
+	if err != nil {
+		return nil, err
+	}
+
+	userAgents := cfg.UserAgents
+	if len(userAgents) == 0 {
+		userAgents = defaultUserAgents
+	}
+
 	return &Scraper{
 		client: &http.Client{
-			Timeout: timeout,
+			Timeout: cfg.Timeout,
 		},
+		endpoint:      endpoint,
+		failedPageDir: cfg.FailedPageDir,
+		limiter:       newHostLimiter(cfg.RateLimit, cfg.Burst),
+		proxies:       proxies,
+		userAgents:    userAgents,
+		maxRetries:    cfg.MaxRetries,
+		backoffBase:   cfg.RetryBackoffBase,
+		backoffMax:    cfg.RetryBackoffMax,
+	}, nil
+}
+
+// profileURL returns the profile page URL for username
+func (s *Scraper) profileURL(username string) string {
+	escaped := url.PathEscape(username)
+	if strings.Contains(s.endpoint, "%s") {
+		return fmt.Sprintf(s.endpoint, escaped)
 	}
+	return strings.TrimRight(s.endpoint, "/") + "/@" + escaped
 }
 
-// GetUserInfo scrapes TikTok user information
+// GetUserInfo scrapes TikTok user information, retrying rate limited,
+// server and network failures. Errors wrap ErrNotFound, ErrPrivate,
+// ErrBlocked or ErrFormatChanged when TikTok answered but no data could be
+// extracted.
 func (s *Scraper) GetUserInfo(username string) (*TikTokUserInfo, error) {
 	if strings.TrimSpace(username) == "" {
 		return nil, errors.New("username cannot be empty")
 	}
 
-	url := fmt.Sprintf("https://www.tiktok.com/@%s", username)
-	req, err := http.NewRequest("GET", url, nil)
+	target, err := url.Parse(s.profileURL(username))
+	if err != nil {
+		return nil, fmt.Errorf("invalid profile URL: %v", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		info, err := s.fetch(target, username)
+		if err == nil {
+			return info, nil
+		}
+
+		var retryable *retryableError
+		if !errors.As(err, &retryable) {
+			return nil, err
+		}
+		if attempt >= s.maxRetries {
+			return nil, fmt.Errorf("giving up after %d attempts: %w", attempt+1, retryable.err)
+		}
+
+		delay := s.backoff(attempt + 1)
+		if retryable.after > delay {
+			delay = retryable.after
+			if s.backoffMax > 0 && delay > s.backoffMax {
+				delay = s.backoffMax
+			}
+		}
+		time.Sleep(delay)
+	}
+}
+
+// fetch makes a single attempt at downloading and parsing the profile page
+func (s *Scraper) fetch(target *url.URL, username string) (*TikTokUserInfo, error) {
+	s.limiter.wait(target.Host)
+
+	client := s.client
+	proxy := s.proxies.pick()
+	if proxy != nil {
+		client = proxy.client
+	}
+
+	req, err := http.NewRequest("GET", target.String(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
 
 	// Set headers to mimic a browser request
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/99.0.4844.88 Safari/537.36")
+	req.Header.Set("User-Agent", s.userAgent())
 	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
 
-	resp, err := s.client.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %v", err)
+		s.proxies.report(proxy, false)
+		return nil, &retryableError{err: fmt.Errorf("request failed: %v", err)}
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		s.proxies.report(proxy, true)
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, username)
+	case resp.StatusCode == http.StatusTooManyRequests:
+		s.proxies.report(proxy, false)
+		return nil, &retryableError{
+			err:   fmt.Errorf("%w: status code %d", ErrBlocked, resp.StatusCode),
+			after: retryAfter(resp.Header.Get("Retry-After")),
+		}
+	case resp.StatusCode == http.StatusForbidden:
+		s.proxies.report(proxy, false)
+		return nil, fmt.Errorf("%w: status code %d", ErrBlocked, resp.StatusCode)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		s.proxies.report(proxy, true)
+		return nil, &retryableError{
+			err:   fmt.Errorf("request failed with status code: %d", resp.StatusCode),
+			after: retryAfter(resp.Header.Get("Retry-After")),
+		}
+	case resp.StatusCode != http.StatusOK:
 		return nil, fmt.Errorf("request failed with status code: %d", resp.StatusCode)
 	}
 
-	htmlBytes, err := ioutil.ReadAll(resp.Body)
+	page, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
+		s.proxies.report(proxy, false)
+		return nil, &retryableError{err: fmt.Errorf("failed to read response body: %v", err)}
 	}
 
-	// Parse the HTML to find user info
-	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(htmlBytes))
+	info, err := ParseProfile(page, username)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse HTML: %v", err)
+		// A captcha means this exit address is flagged
+		s.proxies.report(proxy, !errors.Is(err, ErrBlocked))
+		if errors.Is(err, ErrFormatChanged) || errors.Is(err, ErrBlocked) {
+			path, saveErr := s.saveFailedPage(username, page)
+			if saveErr != nil {
+				return nil, fmt.Errorf("%w (saving page failed: %v)", err, saveErr)
+			}
+			if path != "" {
+				return nil, fmt.Errorf("%w (page saved to %s)", err, path)
+			}
+		}
+		return nil, err
 	}
 
-	var scriptContent string
-	doc.Find("script").EachWithBreak(func(i int, s *goquery.Selection) bool {
-		txt := s.Text()
-		if strings.Contains(txt, "userInfo") {
-			scriptContent = txt
-			return false
-		}
-		return true
-	})
+	s.proxies.report(proxy, true)
+	return info, nil
+}
 
-	if scriptContent == "" {
-		return nil, errors.New("user info script not found")
-	}
+// userAgent returns the next user agent in rotation
+func (s *Scraper) userAgent() string {
+	n := atomic.AddUint32(&s.nextAgent, 1)
+	return s.userAgents[int(n-1)%len(s.userAgents)]
+}
 
-	// Extract JSON from script
-	start := strings.Index(scriptContent, "{")
-	end := strings.LastIndex(scriptContent, "}")
-	if start < 0 || end < 0 || end <= start {
-		return nil, errors.New("invalid JSON in script")
+// backoff returns an exponential delay for the given retry, capped at
+// backoffMax, with up to half of it randomised
+func (s *Scraper) backoff(retry int) time.Duration {
+	delay := s.backoffBase
+	for i := 1; i < retry && delay < s.backoffMax; i++ {
+		delay *= 2
 	}
-	rawJSON := scriptContent[start : end+1]
-
-	// Parse JSON
-	var root map[string]interface{}
-	if err := json.Unmarshal([]byte(rawJSON), &root); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %v", err)
+	if delay > s.backoffMax {
+		delay = s.backoffMax
 	}
-
-	// Navigate through the JSON structure
-	scope, ok := root["__DEFAULT_SCOPE__"].(map[string]interface{})
-	if !ok {
-		return nil, errors.New("__DEFAULT_SCOPE__ not found")
+	if half := int64(delay / 2); half > 0 {
+		delay = delay/2 + time.Duration(rand.Int63n(half))
 	}
+	return delay
+}
 
-	detail, ok := scope["webapp.user-detail"].(map[string]interface{})
-	if !ok {
-		return nil, errors.New("webapp.user-detail not found")
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
 	}
-
-	userInfo, ok := detail["userInfo"].(map[string]interface{})
-	if !ok {
-		return nil, errors.New("userInfo not found")
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
 	}
-
-	user, ok := userInfo["user"].(map[string]interface{})
-	if !ok {
-		return nil, errors.New("user node not found")
+	if t, err := http.ParseTime(header); err == nil {
+		return time.Until(t)
 	}
+	return 0
+}
 
-	stats, ok := userInfo["stats"].(map[string]interface{})
-	if !ok {
-		return nil, errors.New("stats node not found")
+// saveFailedPage writes page to the failed page directory and returns its
+// path, or "" when no directory is configured
+func (s *Scraper) saveFailedPage(username string, page []byte) (string, error) {
+	if s.failedPageDir == "" {
+		return "", nil
 	}
-
-	// Build the result
-	info := &TikTokUserInfo{
-		Username:  username,
-		Name:      fmt.Sprint(user["nickname"]),
-		Region:    fmt.Sprint(user["region"]),
-		ID:        fmt.Sprint(user["id"]),
-		Followers: int64(stats["followerCount"].(float64)),
-		Following: int64(stats["followingCount"].(float64)),
-		Likes:     int64(stats["heartCount"].(float64)),
-		Videos:    int64(stats["videoCount"].(float64)),
+	if err := os.MkdirAll(s.failedPageDir, 0o755); err != nil {
+		return "", err
 	}
 
-	// Parse creation time
-	if t, ok := user["createTime"].(float64); ok && t > 0 {
-		info.CreatedAt = time.Unix(int64(t), 0).UTC()
+	path := filepath.Join(s.failedPageDir, fmt.Sprintf("%s-%s.html",
+		url.PathEscape(username), time.Now().UTC().Format("20060102T150405.000000000")))
+	if err := os.WriteFile(path, page, 0o644); err != nil {
+		return "", err
 	}
-
-	return info, nil
+	return path, nil
 }